@@ -0,0 +1,38 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notwork
+
+import (
+	"fmt"
+
+	"github.com/onsi/gomega/gcustom"
+	"github.com/onsi/gomega/types"
+	"github.com/vishvananda/netlink"
+)
+
+// BeOfKind succeeds if the actual [netlink.Link] is of the specified kind, such
+// as "veth", "macvlan", or "device" (for “hardware” network interfaces), as
+// returned by the link's Type() method.
+//
+//	Expect(l).To(notwork.BeOfKind("veth"))
+func BeOfKind(kind string) types.GomegaMatcher {
+	return gcustom.MakeMatcher(func(actual any) (bool, error) {
+		l, ok := actual.(netlink.Link)
+		if !ok {
+			return false, fmt.Errorf("BeOfKind expects a netlink.Link, but got %T", actual)
+		}
+		return l.Type() == kind, nil
+	}).WithTemplate("Expected network interface of kind {{printf \"%q\" .Actual.Type}}\n{{.To}} be of kind {{printf \"%q\" .Data}}", kind)
+}
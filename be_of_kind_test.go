@@ -0,0 +1,47 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notwork
+
+import (
+	"github.com/vishvananda/netlink"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("BeOfKind matcher", func() {
+
+	It("matches the kind of network interfaces", func() {
+		Expect(&netlink.Veth{}).To(BeOfKind("veth"))
+		Expect(&netlink.Device{}).To(BeOfKind("device"))
+		Expect(&netlink.Macvlan{}).NotTo(BeOfKind("veth"))
+	})
+
+	It("rejects invalid actual values", func() {
+		Expect(BeOfKind("veth").Match(42)).Error().To(
+			MatchError(ContainSubstring("expects a netlink.Link")))
+		Expect(BeOfKind("veth").Match(nil)).Error().To(
+			MatchError(ContainSubstring("expects a netlink.Link")))
+	})
+
+	It("reports the actual kind", func() {
+		Expect(InterceptGomegaFailure(func() {
+			Expect(&netlink.Macvlan{}).To(BeOfKind("veth"))
+		})).To(MatchError(And(
+			ContainSubstring(`of kind "macvlan"`),
+			ContainSubstring(`to be of kind "veth"`))))
+	})
+
+})
@@ -0,0 +1,86 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bond
+
+import (
+	"net"
+
+	"github.com/thediveo/notwork/link"
+	"github.com/thediveo/notwork/netns"
+	"github.com/vishvananda/netlink"
+
+	. "github.com/onsi/ginkgo/v2" //lint:ignore ST1001 rule does not apply
+	. "github.com/onsi/gomega"    //lint:ignore ST1001 rule does not apply
+)
+
+// BondPrefix is the name prefix used for transient bond network interfaces.
+const BondPrefix = "bond-"
+
+// Opt is a configuration option when creating a new bond network interface.
+type Opt func(*link.Link) error
+
+// NewTransient creates and returns a new (and transient) bond network
+// interface. NewTransient automatically defers proper automatic removal of the
+// bond, which in turn automatically releases any slaves still enslaved to it.
+func NewTransient(opts ...Opt) netlink.Link {
+	GinkgoHelper()
+
+	bond := &link.Link{
+		Link: netlink.NewLinkBond(netlink.LinkAttrs{}),
+	}
+	for _, opt := range opts {
+		Expect(opt(bond)).To(Succeed())
+	}
+	return link.NewTransient(bond, BondPrefix)
+}
+
+// Enslave the slave network interface to the specified bond and update the
+// slave's [netlink.LinkAttrs.MasterIndex] accordingly. Bond and slave must be
+// located in the same network namespace, as referenced by the bond's
+// [netlink.LinkAttrs.Namespace] in form of a [netlink.NsFd]; if unset, in the
+// current network namespace. As the kernel refuses to enslave network
+// interfaces that are up, Enslave first sets the slave down, if necessary.
+func Enslave(bond netlink.Link, slave netlink.Link) {
+	GinkgoHelper()
+
+	Expect(bond).NotTo(BeNil(), "need a non-nil bond link description")
+	Expect(slave).NotTo(BeNil(), "need a non-nil slave link description")
+	Expect(bond.Type()).To(Equal("bond"), "network interface %q is not a bond", bond.Attrs().Name)
+
+	master, ok := bond.(*netlink.Bond)
+	if !ok {
+		master = &netlink.Bond{LinkAttrs: *bond.Attrs()}
+	}
+	// As netlink.LinkSetBondSlave uses the bonding ioctl() interface that
+	// works only in the current network namespace, we need to switch into the
+	// bond's network namespace where necessary.
+	enslave := func() {
+		nif, err := netlink.LinkByName(slave.Attrs().Name)
+		Expect(err).NotTo(HaveOccurred(),
+			"cannot find network interface %q", slave.Attrs().Name)
+		if nif.Attrs().Flags&net.FlagUp != 0 {
+			Expect(netlink.LinkSetDown(nif)).To(Succeed(),
+				"cannot set network interface %q down", slave.Attrs().Name)
+		}
+		Expect(netlink.LinkSetBondSlave(nif, master)).To(Succeed(),
+			"cannot enslave network interface %q to bond %q", slave.Attrs().Name, bond.Attrs().Name)
+	}
+	if netnsfd, ok := bond.Attrs().Namespace.(netlink.NsFd); ok {
+		netns.Execute(int(netnsfd), enslave)
+	} else {
+		enslave()
+	}
+	slave.Attrs().MasterIndex = bond.Attrs().Index
+}
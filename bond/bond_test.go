@@ -0,0 +1,84 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bond
+
+import (
+	"os"
+	"time"
+
+	"github.com/thediveo/notwork/dummy"
+	"github.com/thediveo/notwork/netns"
+	"github.com/vishvananda/netlink"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gleak"
+	. "github.com/thediveo/fdooze"
+	. "github.com/thediveo/success"
+)
+
+var _ = Describe("transient bonds", func() {
+
+	BeforeEach(func() {
+		if os.Getuid() != 0 {
+			Skip("needs root")
+		}
+		goodfds := Filedescriptors()
+		goodgos := Goroutines()
+		DeferCleanup(func() {
+			Eventually(Goroutines).Within(2 * time.Second).ProbeEvery(250 * time.Millisecond).
+				ShouldNot(HaveLeaked(goodgos))
+			Expect(Filedescriptors()).NotTo(HaveLeakedFds(goodfds))
+		})
+	})
+
+	It("creates an 802.3ad bond with two slaves", func() {
+		defer netns.EnterTransient()()
+
+		bond := NewTransient(WithMode(netlink.BOND_MODE_802_3AD), WithMiimon(100))
+		Expect(bond.Attrs().Name).To(HavePrefix(BondPrefix))
+		Expect(Successful(netlink.LinkByIndex(bond.Attrs().Index))).To(
+			HaveField("Mode", netlink.BOND_MODE_802_3AD))
+
+		slave1 := dummy.NewTransient()
+		slave2 := dummy.NewTransient()
+		Expect(netlink.LinkSetUp(slave2)).To(Succeed())
+		Enslave(bond, slave1)
+		Enslave(bond, slave2)
+		for _, slave := range []netlink.Link{slave1, slave2} {
+			Expect(slave.Attrs().MasterIndex).To(Equal(bond.Attrs().Index))
+			Expect(Successful(netlink.LinkByIndex(slave.Attrs().Index))).To(
+				HaveField("Attrs().MasterIndex", bond.Attrs().Index))
+		}
+	})
+
+	It("enslaves in a different network namespace", func() {
+		netnsfd := netns.NewTransient()
+
+		bond := NewTransient(InNamespace(netnsfd))
+		slave := dummy.NewTransient(dummy.InNamespace(netnsfd))
+		Enslave(bond, slave)
+		nlh := netns.NewNetlinkHandle(netnsfd)
+		Expect(Successful(nlh.LinkByIndex(slave.Attrs().Index))).To(
+			HaveField("Attrs().MasterIndex", bond.Attrs().Index))
+	})
+
+	It("rejects enslaving to non-bonds", func() {
+		Expect(InterceptGomegaFailure(func() {
+			Enslave(&netlink.Veth{LinkAttrs: netlink.LinkAttrs{Name: "foo"}}, &netlink.Veth{})
+		})).To(MatchError(ContainSubstring("is not a bond")))
+	})
+
+})
@@ -0,0 +1,64 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bond
+
+import (
+	"fmt"
+
+	"github.com/thediveo/notwork/link"
+	"github.com/vishvananda/netlink"
+)
+
+// InNamespace configures a bond network interface to be created in the
+// network namespace referenced by fdref, instead of creating it in the current
+// network namespace.
+func InNamespace(fdref int) Opt {
+	return func(l *link.Link) error {
+		l.Attrs().Namespace = netlink.NsFd(fdref)
+		return nil
+	}
+}
+
+// WithMode configures the bonding mode of a bond network interface, such as
+// [netlink.BOND_MODE_802_3AD]. If not specified, the kernel defaults to
+// [netlink.BOND_MODE_BALANCE_RR].
+func WithMode(mode netlink.BondMode) Opt {
+	return func(l *link.Link) error {
+		if mode < netlink.BOND_MODE_BALANCE_RR || mode >= netlink.BOND_MODE_UNKNOWN {
+			return fmt.Errorf("invalid bonding mode %d", mode)
+		}
+		l.Link.(*netlink.Bond).Mode = mode
+		return nil
+	}
+}
+
+// WithMiimon configures the MII link monitoring interval of a bond network
+// interface in milliseconds; zero disables MII link monitoring.
+func WithMiimon(ms int) Opt {
+	return func(l *link.Link) error {
+		if ms < 0 {
+			return fmt.Errorf("invalid negative MII monitoring interval %d", ms)
+		}
+		l.Link.(*netlink.Bond).Miimon = ms
+		return nil
+	}
+}
+
+// WithAddr configures an IPv4 or IPv6 address in CIDR notation (such as
+// “10.0.0.1/24” or “fd00::1/64”) to be assigned to a bond network interface
+// right after creation.
+func WithAddr(cidr string) Opt {
+	return Opt(link.WithAddr(cidr))
+}
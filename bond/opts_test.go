@@ -0,0 +1,50 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bond
+
+import (
+	"github.com/thediveo/notwork/link"
+	"github.com/vishvananda/netlink"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("bond configuration options", func() {
+
+	It("configures bonds", func() {
+		l := &link.Link{Link: netlink.NewLinkBond(netlink.LinkAttrs{})}
+		for _, opt := range []Opt{
+			InNamespace(42),
+			WithMode(netlink.BOND_MODE_802_3AD),
+			WithMiimon(100),
+			WithAddr("10.0.0.1/24"),
+		} {
+			Expect(opt(l)).To(Succeed())
+		}
+		Expect(l.Link).To(HaveField("Namespace", netlink.NsFd(42)))
+		Expect(l.Link).To(HaveField("Mode", netlink.BOND_MODE_802_3AD))
+		Expect(l.Link).To(HaveField("Miimon", 100))
+		Expect(l.Addrs).To(ConsistOf(HaveField("IPNet.String()", "10.0.0.1/24")))
+	})
+
+	It("rejects invalid configurations", func() {
+		l := &link.Link{Link: netlink.NewLinkBond(netlink.LinkAttrs{})}
+		Expect(WithMode(netlink.BOND_MODE_UNKNOWN)(l)).NotTo(Succeed())
+		Expect(WithMode(-1)(l)).NotTo(Succeed())
+		Expect(WithMiimon(-1)(l)).NotTo(Succeed())
+	})
+
+})
@@ -0,0 +1,170 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bridge
+
+import (
+	"fmt"
+
+	"github.com/thediveo/notwork/link"
+	"github.com/thediveo/notwork/netns"
+	"github.com/thediveo/notwork/veth"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+
+	. "github.com/onsi/ginkgo/v2"   //lint:ignore ST1001 rule does not apply
+	. "github.com/onsi/gomega"      //lint:ignore ST1001 rule does not apply
+	. "github.com/thediveo/success" //lint:ignore ST1001 rule does not apply
+)
+
+var fail = Fail // allow testing Fails without terminally failing the current test.
+
+// BridgePrefix is the name prefix used for transient bridge network
+// interfaces.
+const BridgePrefix = "brdg-"
+
+// Opt is a configuration option when creating a new bridge network interface.
+type Opt func(*link.Link) error
+
+// NewTransient creates and returns a new (and transient) Linux kernel bridge
+// network interface. NewTransient automatically defers proper automatic
+// removal of the bridge, which in turn automatically detaches any members
+// still enslaved to it.
+func NewTransient(opts ...Opt) netlink.Link {
+	GinkgoHelper()
+
+	br := &link.Link{
+		Link: &netlink.Bridge{},
+	}
+	for _, opt := range opts {
+		Expect(opt(br)).To(Succeed())
+	}
+	return link.NewTransient(br, BridgePrefix)
+}
+
+// Enslave the member network interface to the specified bridge and update the
+// member's [netlink.LinkAttrs.MasterIndex] accordingly. Bridge and member must
+// be located in the same network namespace, as referenced by their
+// [netlink.LinkAttrs.Namespace]; if unset, in the current network namespace.
+// In contrast to [ConnectBridges], Enslave doesn't change the operational state
+// of the member.
+func Enslave(br netlink.Link, member netlink.Link) {
+	GinkgoHelper()
+
+	Expect(br).NotTo(BeNil(), "need a non-nil bridge link description")
+	Expect(member).NotTo(BeNil(), "need a non-nil member link description")
+	Expect(br.Type()).To(Equal("bridge"), "network interface %q is not a bridge", br.Attrs().Name)
+	Expect(netnsIno(member)).To(Equal(netnsIno(br)),
+		"bridge %q and member %q must be in the same network namespace",
+		br.Attrs().Name, member.Attrs().Name)
+
+	nlh := Successful(link.NewHandle(br))
+	defer nlh.Close()
+	Expect(nlh.LinkSetMaster(member, br)).To(Succeed(),
+		"cannot enslave network interface %q to bridge %q", member.Attrs().Name, br.Attrs().Name)
+	member.Attrs().MasterIndex = br.Attrs().Index
+}
+
+// ConnectBridges connects the two bridges a and b using a transient VETH pair,
+// enslaving one VETH end to each bridge and bringing both VETH ends up. This
+// creates the topology of “two switches connected by a trunk” in a single
+// call. ConnectBridges returns the VETH ends enslaved to bridge a and b,
+// respectively.
+//
+// The bridges may live in different network namespaces, as referenced by their
+// [netlink.LinkAttrs.Namespace] in form of either a [netlink.NsFd] or
+// [netlink.NsPid]; if unset, a bridge is considered to be in the current
+// network namespace. The returned VETH ends reference the same network
+// namespaces as their bridges.
+func ConnectBridges(a, b netlink.Link) (aPort, bPort netlink.Link) {
+	GinkgoHelper()
+
+	Expect(a).NotTo(BeNil(), "need a non-nil bridge link description")
+	Expect(b).NotTo(BeNil(), "need a non-nil bridge link description")
+	Expect(a.Type()).To(Equal("bridge"), "network interface %q is not a bridge", a.Attrs().Name)
+	Expect(b.Type()).To(Equal("bridge"), "network interface %q is not a bridge", b.Attrs().Name)
+
+	var opts []veth.Opt
+	if netnsfd, ok := netnsfdOf(a); ok {
+		opts = append(opts, veth.InNamespace(netnsfd))
+	}
+	if netnsfd, ok := netnsfdOf(b); ok {
+		opts = append(opts, veth.WithPeerNamespace(netnsfd))
+	}
+	aPort, bPort = veth.NewTransient(opts...)
+
+	return enslave(a, aPort), enslave(b, bPort)
+}
+
+// enslave enslaves the port to the specified bridge, brings the port up, and
+// returns the updated port link information. The port must be located in the
+// same network namespace as the bridge.
+func enslave(bridge, port netlink.Link) netlink.Link {
+	GinkgoHelper()
+
+	netnsref := bridge.Attrs().Namespace
+	nlh := Successful(link.NewHandle(bridge))
+	defer nlh.Close()
+
+	Expect(nlh.LinkSetMasterByIndex(port, bridge.Attrs().Index)).To(Succeed(),
+		"cannot enslave network interface %q to bridge %q", port.Attrs().Name, bridge.Attrs().Name)
+	Expect(nlh.LinkSetUp(port)).To(Succeed(),
+		"cannot bring up network interface %q", port.Attrs().Name)
+	port = Successful(nlh.LinkByIndex(port.Attrs().Index))
+	port.Attrs().Namespace = netnsref
+	return port
+}
+
+// netnsfdOf returns a file descriptor referencing the network namespace of the
+// specified link, as referenced by its [netlink.LinkAttrs.Namespace], and true;
+// if unset, it returns false instead. A PID reference gets resolved into a file
+// descriptor that is closed as part of the Ginkgo deferred cleanups.
+func netnsfdOf(l netlink.Link) (int, bool) {
+	GinkgoHelper()
+
+	switch ref := l.Attrs().Namespace.(type) {
+	case nil:
+		return 0, false
+	case netlink.NsFd:
+		return int(ref), true
+	case netlink.NsPid:
+		netnsfd, err := unix.Open(fmt.Sprintf("/proc/%d/ns/net", ref), unix.O_RDONLY|unix.O_CLOEXEC, 0)
+		Expect(err).NotTo(HaveOccurred(),
+			"cannot reference network namespace of process with PID %d", ref)
+		defer unix.Close(netnsfd)
+		return netns.Adopt(netnsfd), true
+	}
+	fail(fmt.Sprintf("unsupported network namespace reference %T of network interface %q",
+		l.Attrs().Namespace, l.Attrs().Name))
+	return 0, false // not reachable
+}
+
+// netnsIno returns the inode number of the network namespace of the specified
+// link, as referenced by its [netlink.LinkAttrs.Namespace]; if unset, of the
+// current network namespace.
+func netnsIno(l netlink.Link) uint64 {
+	GinkgoHelper()
+
+	switch ref := l.Attrs().Namespace.(type) {
+	case nil:
+		return netns.CurrentIno()
+	case netlink.NsFd:
+		return netns.Ino(int(ref))
+	case netlink.NsPid:
+		return netns.Ino(fmt.Sprintf("/proc/%d/ns/net", ref))
+	}
+	fail(fmt.Sprintf("unsupported network namespace reference %T of network interface %q",
+		l.Attrs().Namespace, l.Attrs().Name))
+	return 0 // not reachable
+}
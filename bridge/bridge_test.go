@@ -0,0 +1,258 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bridge
+
+import (
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/thediveo/notwork/link"
+	"github.com/thediveo/notwork/mntns"
+	"github.com/thediveo/notwork/netns"
+	"github.com/thediveo/notwork/veth"
+	"github.com/vishvananda/netlink"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gleak"
+	. "github.com/thediveo/fdooze"
+	. "github.com/thediveo/success"
+)
+
+var _ = Describe("transient bridges", func() {
+
+	BeforeEach(func() {
+		if os.Getuid() != 0 {
+			Skip("needs root")
+		}
+		goodfds := Filedescriptors()
+		goodgos := Goroutines()
+		DeferCleanup(func() {
+			Eventually(Goroutines).Within(2 * time.Second).ProbeEvery(250 * time.Millisecond).
+				ShouldNot(HaveLeaked(goodgos))
+			Expect(Filedescriptors()).NotTo(HaveLeakedFds(goodfds))
+		})
+	})
+
+	It("creates a configured bridge", func() {
+		defer netns.EnterTransient()()
+
+		br := NewTransient(
+			WithSTP(true),
+			WithAgeingTime(42*time.Second))
+		Expect(br.Attrs().Name).To(HavePrefix(BridgePrefix))
+		Expect(bridgeAttrs(br, "stp_state", "ageing_time")).To(Equal(map[string]string{
+			"stp_state":   "1",
+			"ageing_time": "4200",
+		}))
+	})
+
+	It("creates a VLAN filtering bridge", func() {
+		defer netns.EnterTransient()()
+
+		br := NewTransient(WithVLANFiltering(true))
+		Expect(bridgeAttrs(br, "vlan_filtering")).To(HaveKeyWithValue("vlan_filtering", "1"))
+	})
+
+	It("rejects enslaving to non-bridges", func() {
+		Expect(InterceptGomegaFailure(func() {
+			Enslave(&netlink.Veth{LinkAttrs: netlink.LinkAttrs{Name: "foo"}}, &netlink.Veth{})
+		})).To(MatchError(ContainSubstring("is not a bridge")))
+	})
+
+	It("rejects enslaving members in a different network namespace", func() {
+		netnsfd := netns.NewTransient()
+		br := NewTransient(InNamespace(netnsfd))
+		member, _ := veth.NewTransient(veth.InNamespace(netns.NewTransient()))
+		Expect(InterceptGomegaFailure(func() {
+			Enslave(br, member)
+		})).To(MatchError(ContainSubstring("must be in the same network namespace")))
+	})
+
+	It("enslaves using PID-referenced network namespaces", func() {
+		br := NewTransient()
+		member, _ := veth.NewTransient()
+		// switch this OS-level thread elsewhere, so that only the PID
+		// reference leads back to where bridge and member are.
+		defer netns.EnterTransient()()
+		br.Attrs().Namespace = netlink.NsPid(os.Getpid())
+		member.Attrs().Namespace = netlink.NsPid(os.Getpid())
+		Enslave(br, member)
+		nlh := Successful(link.NewHandle(member))
+		defer nlh.Close()
+		Expect(Successful(nlh.LinkByIndex(member.Attrs().Index))).To(
+			HaveField("Attrs().MasterIndex", br.Attrs().Index))
+	})
+
+	It("rejects unsupported network namespace references", func() {
+		oldfail := fail
+		defer func() { fail = oldfail }()
+		fail = func(message string, callerSkip ...int) { panic(message) }
+		Expect(func() {
+			_ = netnsIno(&netlink.Bridge{LinkAttrs: netlink.LinkAttrs{Namespace: "foo"}})
+		}).To(PanicWith(ContainSubstring("unsupported network namespace reference string")))
+		Expect(netnsIno(&netlink.Bridge{LinkAttrs: netlink.LinkAttrs{
+			Namespace: netlink.NsPid(os.Getpid()),
+		}})).To(Equal(netns.CurrentIno()))
+	})
+
+})
+
+var _ = Describe("enslaving bridge members", Ordered, func() {
+
+	var netnsfd int
+
+	BeforeAll(func() {
+		if os.Getuid() != 0 {
+			Skip("needs root")
+		}
+		netnsfd = netns.NewTransient()
+	})
+
+	BeforeEach(func() {
+		goodfds := Filedescriptors()
+		goodgos := Goroutines()
+		DeferCleanup(func() {
+			Eventually(Goroutines).Within(2 * time.Second).ProbeEvery(250 * time.Millisecond).
+				ShouldNot(HaveLeaked(goodgos))
+			Expect(Filedescriptors()).NotTo(HaveLeakedFds(goodfds))
+		})
+	})
+
+	It("enslaves a member", func() {
+		member, _ := veth.NewTransient(veth.InNamespace(netnsfd))
+		br := NewTransient(InNamespace(netnsfd))
+		Enslave(br, member)
+		Expect(member.Attrs().MasterIndex).To(Equal(br.Attrs().Index))
+
+		nlh := netns.NewNetlinkHandle(netnsfd)
+		Expect(Successful(nlh.LinkByName(member.Attrs().Name))).To(
+			HaveField("Attrs().MasterIndex", br.Attrs().Index))
+	})
+
+	It("has removed the bridge, detaching its members", func() {
+		nlh := netns.NewNetlinkHandle(netnsfd)
+		Expect(Successful(nlh.LinkList())).NotTo(ContainElement(HaveField("Type()", "bridge")))
+	})
+
+})
+
+var _ = Describe("connecting bridges", func() {
+
+	BeforeEach(func() {
+		if os.Getuid() != 0 {
+			Skip("needs root")
+		}
+		goodfds := Filedescriptors()
+		goodgos := Goroutines()
+		DeferCleanup(func() {
+			Eventually(Goroutines).Within(2 * time.Second).ProbeEvery(250 * time.Millisecond).
+				ShouldNot(HaveLeaked(goodgos))
+			Expect(Filedescriptors()).NotTo(HaveLeakedFds(goodfds))
+		})
+	})
+
+	It("connects two bridges in the current network namespace", func() {
+		defer netns.EnterTransient()()
+
+		a := link.NewTransient(&netlink.Bridge{}, "br-")
+		b := link.NewTransient(&netlink.Bridge{}, "br-")
+		aPort, bPort := ConnectBridges(a, b)
+		Expect(aPort.Attrs().MasterIndex).To(Equal(a.Attrs().Index))
+		Expect(aPort.Attrs().Flags & net.FlagUp).NotTo(BeZero())
+		Expect(bPort.Attrs().MasterIndex).To(Equal(b.Attrs().Index))
+		Expect(bPort.Attrs().Flags & net.FlagUp).NotTo(BeZero())
+	})
+
+	It("connects two bridges in different network namespaces", func() {
+		anetnsfd := netns.NewTransient()
+		bnetnsfd := netns.NewTransient()
+
+		a := link.NewTransient(&netlink.Bridge{
+			LinkAttrs: netlink.LinkAttrs{Namespace: netlink.NsFd(anetnsfd)},
+		}, "br-")
+		b := link.NewTransient(&netlink.Bridge{
+			LinkAttrs: netlink.LinkAttrs{Namespace: netlink.NsFd(bnetnsfd)},
+		}, "br-")
+		aPort, bPort := ConnectBridges(a, b)
+		Expect(aPort.Attrs().Namespace).To(Equal(netlink.NsFd(anetnsfd)))
+		Expect(bPort.Attrs().Namespace).To(Equal(netlink.NsFd(bnetnsfd)))
+
+		anlh := netns.NewNetlinkHandle(anetnsfd)
+		Expect(Successful(anlh.LinkByName(aPort.Attrs().Name))).To(
+			HaveField("Attrs().MasterIndex", a.Attrs().Index))
+		bnlh := netns.NewNetlinkHandle(bnetnsfd)
+		Expect(Successful(bnlh.LinkByName(bPort.Attrs().Name))).To(
+			HaveField("Attrs().MasterIndex", b.Attrs().Index))
+	})
+
+	It("connects bridges in PID-referenced network namespaces", func() {
+		anetnsfd := netns.NewTransient()
+		a := link.NewTransient(&netlink.Bridge{
+			LinkAttrs: netlink.LinkAttrs{Namespace: netlink.NsFd(anetnsfd)},
+		}, "br-")
+		b := link.NewTransient(&netlink.Bridge{}, "br-")
+		// switch this OS-level thread elsewhere, so that only the PID
+		// reference leads back to where bridge b is.
+		defer netns.EnterTransient()()
+		b.Attrs().Namespace = netlink.NsPid(os.Getpid())
+
+		aPort, bPort := ConnectBridges(a, b)
+		Expect(aPort.Attrs().MasterIndex).To(Equal(a.Attrs().Index))
+		Expect(bPort.Attrs().Namespace).To(Equal(netlink.NsPid(os.Getpid())))
+		nlh := Successful(link.NewHandle(b))
+		defer nlh.Close()
+		Expect(Successful(nlh.LinkByName(bPort.Attrs().Name))).To(
+			HaveField("Attrs().MasterIndex", b.Attrs().Index))
+	})
+
+	It("rejects unsupported network namespace references when connecting", func() {
+		oldfail := fail
+		defer func() { fail = oldfail }()
+		fail = func(message string, callerSkip ...int) { panic(message) }
+		Expect(func() {
+			_, _ = netnsfdOf(&netlink.Bridge{LinkAttrs: netlink.LinkAttrs{Namespace: "foo"}})
+		}).To(PanicWith(ContainSubstring("unsupported network namespace reference string")))
+	})
+
+	It("rejects non-bridges", func() {
+		defer netns.EnterTransient()()
+
+		a := link.NewTransient(&netlink.Bridge{}, "br-")
+		Expect(InterceptGomegaFailure(func() {
+			_, _ = ConnectBridges(a, &netlink.Veth{LinkAttrs: netlink.LinkAttrs{Name: "foo"}})
+		})).To(MatchError(ContainSubstring("is not a bridge")))
+	})
+
+})
+
+// bridgeAttrs returns the values of the specified bridge sysfs attributes of
+// the bridge in the current network namespace.
+func bridgeAttrs(br netlink.Link, attrs ...string) map[string]string {
+	GinkgoHelper()
+
+	mntnsfd, _ := mntns.NewTransient()
+	values := map[string]string{}
+	mntns.Execute(mntnsfd, func() {
+		mntns.MountSysfsRO()
+		for _, attr := range attrs {
+			values[attr] = strings.TrimSpace(string(Successful(
+				os.ReadFile("/sys/class/net/" + br.Attrs().Name + "/bridge/" + attr))))
+		}
+	})
+	return values
+}
@@ -0,0 +1,15 @@
+/*
+Package bridge helps with creating transient Linux kernel [bridges], enslaving
+members, and connecting bridges for testing purposes. It leverages the [Ginkgo]
+testing framework and matching (erm, sic!) [Gomega] matchers.
+
+These bridge network interfaces are transient because they automatically get
+removed at the end of the a test (spec, block/group, suite, et cetera) using
+Ginkgo's [DeferCleanup].
+
+[bridges]: https://wiki.linuxfoundation.org/networking/bridge
+[Ginkgo]: https://github.com/onsi/ginkgo
+[Gomega]: https://github.com/onsi/gomega
+[DeferCleanup]: https://pkg.go.dev/github.com/onsi/ginkgo/v2#DeferCleanup
+*/
+package bridge
@@ -0,0 +1,128 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bridge
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/thediveo/notwork/link"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+
+	mdnetlink "github.com/mdlayher/netlink"
+)
+
+// InNamespace configures a bridge network interface to be created in the
+// network namespace referenced by fdref, instead of creating it in the current
+// network namespace.
+func InNamespace(fdref int) Opt {
+	return func(l *link.Link) error {
+		l.Attrs().Namespace = netlink.NsFd(fdref)
+		return nil
+	}
+}
+
+// WithVLANFiltering configures a bridge network interface to filter VLANs on
+// its ports, or not.
+func WithVLANFiltering(on bool) Opt {
+	return func(l *link.Link) error {
+		l.Link.(*netlink.Bridge).VlanFiltering = &on
+		return nil
+	}
+}
+
+// WithAgeingTime configures the time after which a bridge network interface
+// forgets learned MAC addresses. As the kernel works with 1/100 seconds, the
+// ageing time gets truncated accordingly.
+func WithAgeingTime(d time.Duration) Opt {
+	return func(l *link.Link) error {
+		if d < 0 {
+			return fmt.Errorf("invalid negative ageing time %s", d)
+		}
+		centisecs := uint32(d / (10 * time.Millisecond))
+		l.Link.(*netlink.Bridge).AgeingTime = &centisecs
+		return nil
+	}
+}
+
+// WithSTP configures a bridge network interface to run the (kernel) spanning
+// tree protocol, or not. As the STP state cannot be specified when creating
+// a bridge, it gets set right after creation.
+func WithSTP(on bool) Opt {
+	return func(l *link.Link) error {
+		l.SetupFns = append(l.SetupFns, func(br netlink.Link) error {
+			return setSTP(br, on)
+		})
+		return nil
+	}
+}
+
+// WithAddr configures an IPv4 or IPv6 address in CIDR notation (such as
+// “10.0.0.1/24” or “fd00::1/64”) to be assigned to a bridge network interface
+// right after creation.
+func WithAddr(cidr string) Opt {
+	return Opt(link.WithAddr(cidr))
+}
+
+// setSTP sets the STP state of the specified bridge network interface in its
+// network namespace, as referenced by [netlink.LinkAttrs.Namespace] in form
+// of a [netlink.NsFd]; if unset, in the current network namespace.
+//
+// As the vishvananda/netlink package doesn't support the STP state, we roll
+// our own RTM_NEWLINK message.
+func setSTP(br netlink.Link, on bool) error {
+	var netnsfd int // zero means current network namespace
+	if fd, ok := br.Attrs().Namespace.(netlink.NsFd); ok {
+		netnsfd = int(fd)
+	}
+	conn, err := mdnetlink.Dial(unix.NETLINK_ROUTE, &mdnetlink.Config{NetNS: netnsfd})
+	if err != nil {
+		return fmt.Errorf("cannot dial RTNETLINK, reason: %w", err)
+	}
+	defer conn.Close()
+
+	ae := mdnetlink.NewAttributeEncoder()
+	ae.Nested(unix.IFLA_LINKINFO, func(nae *mdnetlink.AttributeEncoder) error {
+		nae.String(unix.IFLA_INFO_KIND, "bridge")
+		nae.Nested(unix.IFLA_INFO_DATA, func(dae *mdnetlink.AttributeEncoder) error {
+			var state uint32
+			if on {
+				state = 1
+			}
+			dae.Uint32(unix.IFLA_BR_STP_STATE, state)
+			return nil
+		})
+		return nil
+	})
+	attrs, err := ae.Encode()
+	if err != nil {
+		return err
+	}
+	ifinfomsg := make([]byte, unix.SizeofIfInfomsg)
+	ifinfomsg[0] = unix.AF_UNSPEC
+	binary.NativeEndian.PutUint32(ifinfomsg[4:8], uint32(br.Attrs().Index))
+	if _, err := conn.Execute(mdnetlink.Message{
+		Header: mdnetlink.Header{
+			Type:  unix.RTM_NEWLINK,
+			Flags: mdnetlink.Request | mdnetlink.Acknowledge,
+		},
+		Data: append(ifinfomsg, attrs...),
+	}); err != nil {
+		return fmt.Errorf("cannot set STP state of bridge %q, reason: %w", br.Attrs().Name, err)
+	}
+	return nil
+}
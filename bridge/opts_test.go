@@ -0,0 +1,55 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bridge
+
+import (
+	"time"
+
+	"github.com/thediveo/notwork/link"
+	"github.com/vishvananda/netlink"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("bridge configuration options", func() {
+
+	It("configures bridges", func() {
+		l := &link.Link{Link: &netlink.Bridge{}}
+		for _, opt := range []Opt{
+			InNamespace(42),
+			WithVLANFiltering(true),
+			WithAgeingTime(42*time.Second + 5*time.Millisecond),
+			WithSTP(true),
+		} {
+			Expect(opt(l)).To(Succeed())
+		}
+		Expect(l.Link).To(HaveField("Namespace", netlink.NsFd(42)))
+		Expect(l.Link).To(HaveField("VlanFiltering", HaveValue(BeTrue())))
+		Expect(l.Link).To(HaveField("AgeingTime", HaveValue(Equal(uint32(4200)))))
+		Expect(l.SetupFns).To(HaveLen(1))
+	})
+
+	It("rejects negative ageing times", func() {
+		Expect(WithAgeingTime(-time.Second)(&link.Link{Link: &netlink.Bridge{}})).NotTo(Succeed())
+	})
+
+	It("configures an address", func() {
+		l := &link.Link{Link: &netlink.Bridge{}}
+		Expect(WithAddr("10.0.0.1/24")(l)).To(Succeed())
+		Expect(l.Addrs).To(ConsistOf(HaveField("IPNet.String()", "10.0.0.1/24")))
+	})
+
+})
@@ -28,9 +28,10 @@ const DummyPrefix = "dumy-"
 // Opt is a configuration option when creating a new dummy network interface.
 type Opt func(*link.Link) error
 
-// NewTransient creates a transient network interface of type “[dummy]”. It does
-// not configure any IP address(es) though. NewTransient automatically defers
-// proper automatic removal of the dummy network interface.
+// NewTransient creates a transient network interface of type “[dummy]”. It
+// configures IP addresses only when asked to using [WithAddr]; see also
+// [NewTransientConfigured]. NewTransient automatically defers proper automatic
+// removal of the dummy network interface.
 //
 // [dummy]: https://tldp.org/LDP/nag/node72.html
 func NewTransient(opts ...Opt) netlink.Link {
@@ -44,8 +45,22 @@ func NewTransient(opts ...Opt) netlink.Link {
 	return link.NewTransient(dummy, DummyPrefix)
 }
 
+// NewTransientInInitial creates a transient network interface of type
+// “[dummy]” in the initial network namespace of this process, regardless of the
+// current network namespace. This is useful for creating, say, MACVLAN parents
+// “in the host”, while their MACVLAN children go into other network
+// namespaces. NewTransientInInitial automatically defers proper automatic
+// removal of the dummy network interface.
+//
+// [dummy]: https://tldp.org/LDP/nag/node72.html
+func NewTransientInInitial(opts ...Opt) netlink.Link {
+	GinkgoHelper()
+	return NewTransient(append(opts, Opt(link.InInitialNamespace()))...)
+}
+
 // NewTransientUp creates a transient network interface of type “[dummy]” and
-// additionally brings it up. It does not configure any IP address(es) though.
+// additionally brings it up. It configures IP addresses only when asked to
+// using [WithAddr].
 // NewTransient automatically defers proper automatic removal of the dummy
 // network interface.
 //
@@ -57,3 +72,17 @@ func NewTransientUp(opts ...Opt) netlink.Link {
 		Succeed(), "cannot bring transient interface %q up", dummy.Attrs().Name)
 	return dummy
 }
+
+// NewTransientConfigured creates a transient network interface of type
+// “[dummy]”, assigns the specified IPv4 or IPv6 address in CIDR notation (such
+// as “10.0.0.1/24”), and finally brings it up, waiting for it to become
+// operationally up. NewTransientConfigured automatically defers proper
+// automatic removal of the dummy network interface, including its address.
+//
+// [dummy]: https://tldp.org/LDP/nag/node72.html
+func NewTransientConfigured(cidr string, opts ...Opt) netlink.Link {
+	GinkgoHelper()
+	dummy := NewTransient(append(opts, WithAddr(cidr))...)
+	link.EnsureUp(dummy)
+	return dummy
+}
@@ -16,10 +16,12 @@ package dummy
 
 import (
 	"errors"
+	"net"
 	"os"
 	"time"
 
 	"github.com/thediveo/notwork/link"
+	"github.com/thediveo/notwork/netns"
 	"github.com/vishvananda/netlink"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -69,6 +71,55 @@ var _ = Describe("creating transient dummy network interfaces", func() {
 		Expect(ql.Attrs().OperState).NotTo(Equal(netlink.OperDown))
 	})
 
+	It("creates a transient dummy network interface with a shorter name", func() {
+		defer netns.EnterTransient()()
+		dl := NewTransient(WithMaxNameLen(11))
+		Expect(dl.Attrs().Name).To(And(HavePrefix(DummyPrefix), HaveLen(11)))
+	})
+
+	It("creates a transient dummy network interface in the initial network namespace", func() {
+		var dl netlink.Link
+		netns.Execute(netns.NewTransient(), func() {
+			dl = NewTransientInInitial()
+		})
+		Expect(netlink.LinkByName(dl.Attrs().Name)).Error().NotTo(HaveOccurred())
+	})
+
+	It("creates a configured transient dummy network interface in a different network namespace", func() {
+		netnsfd := netns.NewTransient()
+		dl := NewTransientConfigured("10.0.0.1/24", InNamespace(netnsfd))
+		nlh := netns.NewNetlinkHandle(netnsfd)
+		Expect(Successful(nlh.LinkByIndex(dl.Attrs().Index)).Attrs().OperState).To(
+			Equal(netlink.LinkOperState(netlink.OperUnknown)))
+		Expect(Successful(nlh.AddrList(dl, netlink.FAMILY_V4))).To(ContainElement(
+			HaveField("IPNet.String()", "10.0.0.1/24")))
+	})
+
+	It("creates transient dummy network interfaces with derived MAC addresses", func() {
+		netnsfd := netns.NewTransient()
+		oui := [3]byte{0x03, 0x42, 0x00}
+		dl1 := NewTransient(InNamespace(netnsfd), WithDerivedMAC(oui))
+		dl2 := NewTransient(InNamespace(netnsfd), WithDerivedMAC(oui))
+		Expect(dl1.Attrs().HardwareAddr).NotTo(Equal(dl2.Attrs().HardwareAddr))
+		nlh := netns.NewNetlinkHandle(netnsfd)
+		for _, dl := range []netlink.Link{dl1, dl2} {
+			index := dl.Attrs().Index
+			Expect(Successful(nlh.LinkByName(dl.Attrs().Name)).Attrs().HardwareAddr).To(Equal(
+				net.HardwareAddr{0x02, 0x42, 0x00, byte(index >> 16), byte(index >> 8), byte(index)}))
+		}
+	})
+
+	It("creates a transient dummy network interface with a jumbo MTU", func() {
+		defer netns.EnterTransient()()
+		dl := NewTransient(WithMTU(9000))
+		Expect(Successful(netlink.LinkByName(dl.Attrs().Name)).Attrs().MTU).To(Equal(9000))
+	})
+
+	It("rejects an invalid address", func() {
+		Expect(InterceptGomegaFailure(func() { _ = NewTransientConfigured("10.0.0.666/24") })).
+			To(MatchError(ContainSubstring("invalid address")))
+	})
+
 	When("using options", func() {
 
 		It("configures a different destination network namespace", func() {
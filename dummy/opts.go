@@ -15,6 +15,9 @@
 package dummy
 
 import (
+	"fmt"
+	"net"
+
 	"github.com/thediveo/notwork/link"
 	"github.com/vishvananda/netlink"
 )
@@ -28,3 +31,73 @@ func InNamespace(fdref int) Opt {
 		return nil
 	}
 }
+
+// WithAddr configures an IPv4 or IPv6 address in CIDR notation (such as
+// “10.0.0.1/24” or “fd00::1/64”) to be assigned to a dummy network interface
+// right after creation.
+func WithAddr(cidr string) Opt {
+	return Opt(link.WithAddr(cidr))
+}
+
+// WithMaxNameLen configures a dummy network interface to be created with a
+// random name of exactly n characters, instead of the maximum length of 15
+// characters. The length must leave room for at least four random characters
+// after the [DummyPrefix].
+func WithMaxNameLen(n int) Opt {
+	return Opt(link.WithMaxNameLen(n))
+}
+
+// WithDerivedMAC configures a dummy network interface to get a MAC address
+// derived from its interface index right after creation: the MAC address
+// consists of the specified OUI, followed by the three low bytes of the
+// interface index. This gives a batch of dummy network interfaces unique and
+// predictable MAC addresses without the need to track a counter. To ensure a
+// unicast MAC address, the multicast bit of the OUI is always cleared.
+func WithDerivedMAC(oui [3]byte) Opt {
+	return func(l *link.Link) error {
+		l.SetupFns = append(l.SetupFns, func(dummy netlink.Link) error {
+			return setDerivedMAC(dummy, oui)
+		})
+		return nil
+	}
+}
+
+// derivedMAC returns the unicast MAC address derived from the specified OUI
+// and interface index.
+func derivedMAC(oui [3]byte, index int) net.HardwareAddr {
+	return net.HardwareAddr{
+		oui[0] &^ 0x01, oui[1], oui[2],
+		byte(index >> 16), byte(index >> 8), byte(index),
+	}
+}
+
+// setDerivedMAC sets the MAC address of the specified dummy network interface
+// in its network namespace, as referenced by [netlink.LinkAttrs.Namespace]; if
+// unset, in the current network namespace.
+func setDerivedMAC(dummy netlink.Link, oui [3]byte) error {
+	nlh, err := link.NewHandle(dummy)
+	if err != nil {
+		return err
+	}
+	defer nlh.Close()
+	// Don't rely on the interface index being up to date, as the index fixup
+	// might have been skipped.
+	current, err := nlh.LinkByName(dummy.Attrs().Name)
+	if err != nil {
+		return fmt.Errorf("cannot determine index of network interface %q, reason: %w",
+			dummy.Attrs().Name, err)
+	}
+	mac := derivedMAC(oui, current.Attrs().Index)
+	if err := nlh.LinkSetHardwareAddr(current, mac); err != nil {
+		return fmt.Errorf("cannot set MAC address of network interface %q to %s, reason: %w",
+			dummy.Attrs().Name, mac, err)
+	}
+	dummy.Attrs().HardwareAddr = mac
+	return nil
+}
+
+// WithMTU configures a dummy network interface to be created with the
+// specified MTU, which must be positive and must not exceed 65535.
+func WithMTU(mtu int) Opt {
+	return Opt(link.WithMTU(mtu))
+}
@@ -15,6 +15,8 @@
 package dummy
 
 import (
+	"net"
+
 	"github.com/thediveo/notwork/link"
 	"github.com/vishvananda/netlink"
 
@@ -30,4 +32,35 @@ var _ = Describe("dummy configuration options", func() {
 		Expect(l.Link).To(HaveField("Namespace", netlink.NsFd(-42)))
 	})
 
+	It("configures the name length", func() {
+		l := &link.Link{Link: &netlink.Dummy{}}
+		Expect(WithMaxNameLen(11)(l)).To(Succeed())
+		Expect(l.NameLen).To(Equal(11))
+		Expect(WithMaxNameLen(42)(l)).NotTo(Succeed())
+	})
+
+	It("configures an address", func() {
+		l := &link.Link{Link: &netlink.Dummy{}}
+		Expect(WithAddr("10.0.0.1/24")(l)).To(Succeed())
+		Expect(l.Addrs).To(ConsistOf(HaveField("IPNet.String()", "10.0.0.1/24")))
+	})
+
+	It("derives unicast MAC addresses", func() {
+		l := &link.Link{Link: &netlink.Dummy{}}
+		Expect(WithDerivedMAC([3]byte{0x02, 0x42, 0x00})(l)).To(Succeed())
+		Expect(l.SetupFns).To(HaveLen(1))
+
+		Expect(derivedMAC([3]byte{0x02, 0x42, 0x00}, 0x12345678)).To(
+			Equal(net.HardwareAddr{0x02, 0x42, 0x00, 0x34, 0x56, 0x78}))
+		Expect(derivedMAC([3]byte{0x03, 0x42, 0x00}, 1)).To(
+			Equal(net.HardwareAddr{0x02, 0x42, 0x00, 0x00, 0x00, 0x01}))
+	})
+
+	It("configures the MTU", func() {
+		l := &link.Link{Link: &netlink.Dummy{}}
+		Expect(WithMTU(9000)(l)).To(Succeed())
+		Expect(l.Attrs().MTU).To(Equal(9000))
+		Expect(WithMTU(0)(l)).NotTo(Succeed())
+	})
+
 })
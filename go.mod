@@ -3,6 +3,8 @@ module github.com/thediveo/notwork
 go 1.22
 
 require (
+	github.com/mdlayher/genetlink v0.0.0-20191008151445-a2cadeac9a63
+	github.com/mdlayher/netlink v0.0.0-20191009155606-de872b0d824b
 	github.com/onsi/ginkgo/v2 v2.20.2
 	github.com/onsi/gomega v1.34.2
 	github.com/vishvananda/netlink v1.3.0
@@ -11,8 +13,6 @@ require (
 require (
 	github.com/go-task/slim-sprig/v3 v3.0.0 // indirect
 	github.com/kr/pretty v0.3.1 // indirect
-	github.com/mdlayher/genetlink v0.0.0-20191008151445-a2cadeac9a63 // indirect
-	github.com/mdlayher/netlink v0.0.0-20191009155606-de872b0d824b // indirect
 	golang.org/x/exp v0.0.0-20240719175910-8a7402abbf56 // indirect
 )
 
@@ -0,0 +1,70 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notwork
+
+import (
+	"fmt"
+
+	"github.com/onsi/gomega/gcustom"
+	"github.com/onsi/gomega/types"
+	"github.com/thediveo/notwork/link"
+	"github.com/vishvananda/netlink"
+)
+
+// HaveAddress succeeds if the actual [netlink.Link] has been assigned the
+// specified IPv4 or IPv6 address in CIDR notation (such as “10.0.0.1/24” or
+// “fd00::1/64”). HaveAddress lists the addresses in the network namespace of
+// the link, as referenced by its [netlink.LinkAttrs.Namespace]; if unset, in
+// the current network namespace.
+//
+//	Expect(l).To(notwork.HaveAddress("10.0.0.1/24"))
+func HaveAddress(cidr string) types.GomegaMatcher {
+	data := &haveAddressData{CIDR: cidr}
+	return gcustom.MakeMatcher(func(actual any) (bool, error) {
+		l, ok := actual.(netlink.Link)
+		if !ok || l == nil {
+			return false, fmt.Errorf("HaveAddress expects a netlink.Link, but got %T", actual)
+		}
+		addr, err := netlink.ParseAddr(cidr)
+		if err != nil {
+			return false, fmt.Errorf("HaveAddress expects an address in CIDR notation, reason: %w", err)
+		}
+		nlh, err := link.NewHandle(l)
+		if err != nil {
+			return false, fmt.Errorf("HaveAddress cannot create netlink handle for network namespace, reason: %w", err)
+		}
+		defer nlh.Close()
+		addrs, err := nlh.AddrList(l, netlink.FAMILY_ALL)
+		if err != nil {
+			return false, fmt.Errorf("HaveAddress cannot list addresses of network interface %q, reason: %w",
+				l.Attrs().Name, err)
+		}
+		data.Addrs = data.Addrs[:0]
+		found := false
+		for _, a := range addrs {
+			data.Addrs = append(data.Addrs, a.IPNet.String())
+			if a.IPNet.String() == addr.IPNet.String() {
+				found = true
+			}
+		}
+		return found, nil
+	}).WithTemplate("Expected network interface {{printf \"%q\" .Actual.Attrs.Name}} with addresses {{.Data.Addrs}}\n{{.To}} have address {{.Data.CIDR}}", data)
+}
+
+// haveAddressData is passed to the failure message template of [HaveAddress].
+type haveAddressData struct {
+	CIDR  string
+	Addrs []string
+}
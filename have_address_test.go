@@ -0,0 +1,70 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notwork
+
+import (
+	"os"
+
+	"github.com/thediveo/notwork/link"
+	"github.com/thediveo/notwork/netns"
+	"github.com/vishvananda/netlink"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("HaveAddress matcher", func() {
+
+	It("rejects invalid actual values and addresses", func() {
+		Expect(HaveAddress("10.0.0.1/24").Match(42)).Error().To(
+			MatchError(ContainSubstring("expects a netlink.Link")))
+		Expect(HaveAddress("10.0.0.1/24").Match(nil)).Error().To(
+			MatchError(ContainSubstring("expects a netlink.Link")))
+		Expect(HaveAddress("10.0.0.666/24").Match(&netlink.Veth{})).Error().To(
+			MatchError(ContainSubstring("expects an address in CIDR notation")))
+		Expect(HaveAddress("10.0.0.1/24").Match(&netlink.Veth{
+			LinkAttrs: netlink.LinkAttrs{Namespace: "foo"},
+		})).Error().To(
+			MatchError(ContainSubstring("must be nil, a netlink.NsFd, or a netlink.NsPid")))
+	})
+
+	It("matches addresses of network interfaces in their network namespace", func() {
+		if os.Getuid() != 0 {
+			Skip("needs root")
+		}
+		netnsfd := netns.NewTransient()
+		l := link.NewTransient(&netlink.Veth{
+			LinkAttrs: netlink.LinkAttrs{
+				Namespace: netlink.NsFd(netnsfd),
+			},
+			PeerNamespace: netlink.NsFd(netnsfd),
+		}, "veth-",
+			link.WithAddr("10.0.0.1/24"),
+			link.WithAddr("fd00::1/64"))
+
+		Expect(l).To(HaveAddress("10.0.0.1/24"))
+		Expect(l).To(HaveAddress("fd00::1/64"))
+		Expect(l).NotTo(HaveAddress("10.0.0.1/16"))
+		Expect(l).NotTo(HaveAddress("10.0.0.2/24"))
+
+		Expect(InterceptGomegaFailure(func() {
+			Expect(l).To(HaveAddress("10.0.0.2/24"))
+		})).To(MatchError(And(
+			ContainSubstring(l.Attrs().Name),
+			ContainSubstring("10.0.0.1/24"),
+			ContainSubstring("to have address 10.0.0.2/24"))))
+	})
+
+})
@@ -0,0 +1,45 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notwork
+
+import (
+	"fmt"
+
+	"github.com/onsi/gomega/gcustom"
+	"github.com/onsi/gomega/types"
+	"github.com/vishvananda/netlink"
+)
+
+// HaveReceivedAtLeast succeeds if the actual statistics delta between two
+// snapshots of network interface statistics shows that at least the specified
+// number of bytes have been received. The actual value must be a
+// [netlink.LinkStatistics] or *[netlink.LinkStatistics], such as returned by
+// [github.com/thediveo/notwork/link.StatsDelta].
+//
+//	Expect(link.StatsDelta(&before, &after)).To(notwork.HaveReceivedAtLeast(1000))
+func HaveReceivedAtLeast(bytes uint64) types.GomegaMatcher {
+	return gcustom.MakeMatcher(func(actual any) (bool, error) {
+		switch stats := actual.(type) {
+		case netlink.LinkStatistics:
+			return stats.RxBytes >= bytes, nil
+		case *netlink.LinkStatistics:
+			if stats == nil {
+				return false, fmt.Errorf("HaveReceivedAtLeast expects non-nil *netlink.LinkStatistics")
+			}
+			return stats.RxBytes >= bytes, nil
+		}
+		return false, fmt.Errorf("HaveReceivedAtLeast expects a netlink.LinkStatistics or *netlink.LinkStatistics, but got %T", actual)
+	}).WithTemplate("Expected:\n{{.FormattedActual}}\n{{.To}} have received at least {{.Data}} bytes", bytes)
+}
@@ -0,0 +1,46 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notwork
+
+import (
+	"github.com/vishvananda/netlink"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("HaveReceivedAtLeast matcher", func() {
+
+	It("matches statistics deltas", func() {
+		delta := netlink.LinkStatistics{RxBytes: 1000}
+		Expect(delta).To(HaveReceivedAtLeast(1000))
+		Expect(&delta).To(HaveReceivedAtLeast(999))
+		Expect(delta).NotTo(HaveReceivedAtLeast(1001))
+	})
+
+	It("rejects invalid actual values", func() {
+		Expect(HaveReceivedAtLeast(0).Match(42)).Error().To(
+			MatchError(ContainSubstring("expects a netlink.LinkStatistics")))
+		Expect(HaveReceivedAtLeast(0).Match((*netlink.LinkStatistics)(nil))).Error().To(
+			MatchError(ContainSubstring("expects non-nil")))
+	})
+
+	It("reports failures", func() {
+		Expect(InterceptGomegaFailure(func() {
+			Expect(netlink.LinkStatistics{RxBytes: 1}).To(HaveReceivedAtLeast(42))
+		})).To(MatchError(ContainSubstring("to have received at least 42 bytes")))
+	})
+
+})
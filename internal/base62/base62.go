@@ -0,0 +1,38 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+Package base62 generates random strings consisting of only digits as well as
+lowercase and uppercase ASCII letters, such as for use in random network
+interface names.
+*/
+package base62
+
+import "math/rand"
+
+// The set of characters to create a random string from.
+const chars = "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+// Random returns a random string of length n, consisting of only digits as well
+// as lowercase and uppercase ASCII letters.
+func Random(n int) string {
+	if n <= 0 {
+		return ""
+	}
+	s := make([]byte, n)
+	for idx := range s {
+		s[idx] = chars[rand.Intn(len(chars))]
+	}
+	return string(s)
+}
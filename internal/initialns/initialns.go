@@ -0,0 +1,40 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+Package initialns references the initial network namespace of this process, as
+captured when initializing this package and thus before any test could have
+switched network namespaces.
+*/
+package initialns
+
+import "golang.org/x/sys/unix"
+
+// netnsfd references the initial network namespace of this process; it is -1
+// if the initial network namespace couldn't be determined. This fd is never
+// closed, as it is needed for the whole lifetime of the process.
+var netnsfd = func() int {
+	fd, err := unix.Open("/proc/self/ns/net", unix.O_RDONLY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return -1
+	}
+	return fd
+}()
+
+// Fd returns the file descriptor referencing the initial network namespace of
+// this process, or -1 if the initial network namespace couldn't be
+// determined. The caller must not close the file descriptor returned.
+func Fd() int {
+	return netnsfd
+}
@@ -0,0 +1,35 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+Package wait handles the optional maximum wait durations passed to the various
+waiting helpers of this module.
+*/
+package wait
+
+import "time"
+
+// Duration returns the optional maximum wait duration passed in within, or def
+// if none has been passed. Duration panics if within contains more than a
+// single duration.
+func Duration(within []time.Duration, def time.Duration) time.Duration {
+	switch len(within) {
+	case 0:
+		return def
+	case 1:
+		return within[0]
+	default:
+		panic("only a single optional maximum wait duration allowed")
+	}
+}
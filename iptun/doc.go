@@ -0,0 +1,18 @@
+/*
+Package iptun helps with creating transient IP tunnel network interfaces of the
+“ipip” (IPv4-in-IPv4) and “sit” (IPv6-in-IPv4) kinds for testing purposes. It
+leverages the [Ginkgo] testing framework and matching (erm, sic!) [Gomega]
+matchers.
+
+These IP tunnel network interfaces are transient because they automatically get
+removed at the end of the a test (spec, block/group, suite, et cetera) using
+Ginkgo's [DeferCleanup].
+
+Not all kernels have the “ipip” and “sit” modules available; use [Supported] to
+skip tests gracefully where necessary.
+
+[Ginkgo]: https://github.com/onsi/ginkgo
+[Gomega]: https://github.com/onsi/gomega
+[DeferCleanup]: https://pkg.go.dev/github.com/onsi/ginkgo/v2#DeferCleanup
+*/
+package iptun
@@ -0,0 +1,98 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package iptun
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/thediveo/notwork/link"
+	"github.com/thediveo/notwork/netns"
+	"github.com/vishvananda/netlink"
+
+	. "github.com/onsi/ginkgo/v2" //lint:ignore ST1001 rule does not apply
+	. "github.com/onsi/gomega"    //lint:ignore ST1001 rule does not apply
+)
+
+// IptunPrefix is the name prefix used for transient IP tunnel network
+// interfaces.
+const IptunPrefix = "iptn-"
+
+// IP tunnel modes supported by [NewTransient].
+const (
+	ModeIPIP = "ipip" // IPv4-in-IPv4
+	ModeSIT  = "sit"  // IPv6-in-IPv4
+)
+
+var fail = Fail // allow testing Fails without terminally failing the current test.
+
+// Opt is a configuration option when creating a new IP tunnel network
+// interface.
+type Opt func(*link.Link) error
+
+// NewTransient creates and returns a new (and transient) IP tunnel network
+// interface with the specified local and remote IPv4 tunnel endpoint addresses.
+// The mode selects between an “ipip” ([ModeIPIP]) and a “sit” ([ModeSIT])
+// tunnel. NewTransient automatically defers proper automatic removal of the IP
+// tunnel network interface.
+func NewTransient(local, remote net.IP, mode string, opts ...Opt) netlink.Link {
+	GinkgoHelper()
+
+	tun := &link.Link{Link: newTunnel(local, remote, mode)}
+	for _, opt := range opts {
+		Expect(opt(tun)).To(Succeed())
+	}
+	return link.NewTransient(tun, IptunPrefix)
+}
+
+// newTunnel returns the link description for the specified IP tunnel mode.
+func newTunnel(local, remote net.IP, mode string) netlink.Link {
+	GinkgoHelper()
+
+	switch mode {
+	case ModeIPIP:
+		return &netlink.Iptun{Local: local, Remote: remote}
+	case ModeSIT:
+		return &netlink.Sittun{Local: local, Remote: remote}
+	}
+	fail(fmt.Sprintf("unsupported IP tunnel mode %q", mode))
+	return nil // not reachable
+}
+
+// Supported returns true if IP tunnel network interfaces of the specified mode
+// can be created, otherwise false. Supported probes by creating an IP tunnel
+// network interface in a transient network namespace, thus also triggering
+// automatic loading of the required kernel module where possible.
+//
+// As Supported relies on a transient network namespace, it must be called
+// from within a Ginkgo node, such as BeforeAll or BeforeEach.
+func Supported(mode string) bool {
+	GinkgoHelper()
+
+	if mode != ModeIPIP && mode != ModeSIT {
+		return false
+	}
+	supported := false
+	netns.Execute(netns.NewTransient(), func() {
+		probe := newTunnel(net.ParseIP("127.0.0.1"), net.ParseIP("127.0.0.2"), mode)
+		probe.Attrs().Name = IptunPrefix + "probe"
+		if netlink.LinkAdd(probe) != nil {
+			return
+		}
+		supported = true
+		_ = netlink.LinkDel(probe)
+	})
+	return supported
+}
@@ -0,0 +1,86 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package iptun
+
+import (
+	"net"
+	"os"
+	"time"
+
+	"github.com/thediveo/notwork/netns"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gleak"
+	. "github.com/thediveo/fdooze"
+	. "github.com/thediveo/success"
+)
+
+var _ = Describe("provides transient IP tunnel network interfaces", func() {
+
+	BeforeEach(func() {
+		if os.Getuid() != 0 {
+			Skip("needs root")
+		}
+		goodfds := Filedescriptors()
+		goodgos := Goroutines()
+		DeferCleanup(func() {
+			Eventually(Goroutines).Within(2 * time.Second).ProbeEvery(250 * time.Millisecond).
+				ShouldNot(HaveLeaked(goodgos))
+			Expect(Filedescriptors()).NotTo(HaveLeakedFds(goodfds))
+		})
+	})
+
+	It("rejects unsupported modes", func() {
+		Expect(Supported("gre")).To(BeFalse())
+		oldfail := fail
+		defer func() { fail = oldfail }()
+		var msg string
+		fail = func(message string, callerSkip ...int) {
+			msg = message
+			panic("canary")
+		}
+		Expect(func() {
+			_ = NewTransient(net.ParseIP("10.0.0.1"), net.ParseIP("10.0.0.2"), "gre")
+		}).To(PanicWith("canary"))
+		Expect(msg).To(Equal("unsupported IP tunnel mode \"gre\""))
+	})
+
+	DescribeTable("creating IP tunnels",
+		func(mode string, tunType string) {
+			if !Supported(mode) {
+				Skip("needs " + mode + " kernel support")
+			}
+			netnsfd := netns.NewTransient()
+			local := net.ParseIP("10.0.0.1")
+			remote := net.ParseIP("10.0.0.2")
+			tun := NewTransient(local, remote, mode,
+				InNamespace(netnsfd),
+				WithTTL(42))
+			Expect(tun.Attrs().Index).NotTo(BeZero())
+
+			nlh := netns.NewNetlinkHandle(netnsfd)
+			l := Successful(nlh.LinkByName(tun.Attrs().Name))
+			Expect(l.Type()).To(Equal(tunType))
+			Expect(l).To(And(
+				HaveField("Local.String()", local.String()),
+				HaveField("Remote.String()", remote.String()),
+				HaveField("Ttl", uint8(42))))
+		},
+		Entry("ipip", ModeIPIP, "ipip"),
+		Entry("sit", ModeSIT, "sit"),
+	)
+
+})
@@ -0,0 +1,64 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package iptun
+
+import (
+	"fmt"
+
+	"github.com/thediveo/notwork/link"
+	"github.com/vishvananda/netlink"
+)
+
+// InNamespace configures an IP tunnel network interface to be created in the
+// network namespace referenced by fdref, instead of creating it in the current
+// network namespace.
+func InNamespace(fdref int) Opt {
+	return func(l *link.Link) error {
+		l.Attrs().Namespace = netlink.NsFd(fdref)
+		return nil
+	}
+}
+
+// WithLinkNamespace specifies the “reference” or “link” network namespace other
+// than the current network namespace when creating a new network interface.
+func WithLinkNamespace(fdref int) Opt {
+	return func(l *link.Link) error {
+		l.LinkNamespace = netlink.NsFd(fdref)
+		return nil
+	}
+}
+
+// WithTTL configures the TTL of the encapsulating IPv4 packets; a zero TTL
+// means to inherit the TTL from the encapsulated packets.
+func WithTTL(ttl uint8) Opt {
+	return func(l *link.Link) error {
+		switch tun := l.Link.(type) {
+		case *netlink.Iptun:
+			tun.Ttl = ttl
+		case *netlink.Sittun:
+			tun.Ttl = ttl
+		default:
+			return fmt.Errorf("cannot set TTL on network interface of type %q", l.Type())
+		}
+		return nil
+	}
+}
+
+// WithAddr configures an IPv4 or IPv6 address in CIDR notation (such as
+// “10.0.0.1/24” or “fd00::1/64”) to be assigned to an IP tunnel network
+// interface right after creation.
+func WithAddr(cidr string) Opt {
+	return Opt(link.WithAddr(cidr))
+}
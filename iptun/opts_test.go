@@ -0,0 +1,55 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package iptun
+
+import (
+	"github.com/thediveo/notwork/link"
+	"github.com/vishvananda/netlink"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("IP tunnel configuration options", func() {
+
+	It("configures IP tunnels", func() {
+		for _, l := range []*link.Link{
+			{Link: &netlink.Iptun{}},
+			{Link: &netlink.Sittun{}},
+		} {
+			for _, opt := range []Opt{
+				InNamespace(42),
+				WithLinkNamespace(666),
+				WithTTL(64),
+			} {
+				Expect(opt(l)).To(Succeed())
+			}
+			Expect(l.Link).To(HaveField("Namespace", netlink.NsFd(42)))
+			Expect(l.LinkNamespace).To(Equal(netlink.NsFd(666)))
+			Expect(l.Link).To(HaveField("Ttl", uint8(64)))
+		}
+	})
+
+	It("rejects a TTL for non-tunnels", func() {
+		Expect(WithTTL(64)(&link.Link{Link: &netlink.Dummy{}})).NotTo(Succeed())
+	})
+
+	It("configures an address", func() {
+		l := &link.Link{Link: &netlink.Iptun{}}
+		Expect(WithAddr("10.0.0.1/24")(l)).To(Succeed())
+		Expect(l.Addrs).To(ConsistOf(HaveField("IPNet.String()", "10.0.0.1/24")))
+	})
+
+})
@@ -0,0 +1,15 @@
+/*
+Package ipvlan helps with creating transient [IPVLAN] network interfaces for
+testing purposes. It leverages the [Ginkgo] testing framework and matching
+(erm, sic!) [Gomega] matchers.
+
+These IPVLAN network interfaces are transient because they automatically get
+removed at the end of the a test (spec, block/group, suite, et cetera) using
+Ginkgo's [DeferCleanup].
+
+[IPVLAN]: https://docs.kernel.org/networking/ipvlan.html
+[Ginkgo]: https://github.com/onsi/ginkgo
+[Gomega]: https://github.com/onsi/gomega
+[DeferCleanup]: https://pkg.go.dev/github.com/onsi/ginkgo/v2#DeferCleanup
+*/
+package ipvlan
@@ -0,0 +1,54 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ipvlan
+
+import (
+	"github.com/thediveo/notwork/link"
+	"github.com/vishvananda/netlink"
+
+	. "github.com/onsi/ginkgo/v2" //lint:ignore ST1001 rule does not apply
+	. "github.com/onsi/gomega"    //lint:ignore ST1001 rule does not apply
+)
+
+// IpvlanPrefix is the name prefix used for transient IPVLAN network
+// interfaces.
+const IpvlanPrefix = "ipvl-"
+
+// Opt is a configuration option when creating a new IPVLAN network interface.
+type Opt func(*link.Link) error
+
+// NewTransient creates and returns a new (and transient) IPVLAN network
+// interface attached to the specified parent network interface, defaulting to
+// L2 mode. NewTransient automatically defers proper automatic removal of the
+// IPVLAN network interface.
+//
+// If the parent network interface is located in a different network namespace
+// than the current one, use [WithLinkNamespace].
+func NewTransient(parent netlink.Link, opts ...Opt) netlink.Link {
+	GinkgoHelper()
+
+	ipvlan := &link.Link{
+		Link: &netlink.IPVlan{
+			LinkAttrs: netlink.LinkAttrs{
+				ParentIndex: parent.Attrs().Index,
+			},
+			Mode: netlink.IPVLAN_MODE_L2,
+		},
+	}
+	for _, opt := range opts {
+		Expect(opt(ipvlan)).To(Succeed())
+	}
+	return link.NewTransient(ipvlan, IpvlanPrefix)
+}
@@ -0,0 +1,79 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ipvlan
+
+import (
+	"os"
+	"time"
+
+	"github.com/thediveo/notwork/dummy"
+	"github.com/thediveo/notwork/netns"
+	"github.com/vishvananda/netlink"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gleak"
+	. "github.com/thediveo/fdooze"
+	. "github.com/thediveo/success"
+)
+
+var _ = Describe("provides transient IPVLAN network interfaces", func() {
+
+	BeforeEach(func() {
+		if os.Getuid() != 0 {
+			Skip("needs root")
+		}
+		goodfds := Filedescriptors()
+		goodgos := Goroutines()
+		DeferCleanup(func() {
+			Eventually(Goroutines).Within(2 * time.Second).ProbeEvery(250 * time.Millisecond).
+				ShouldNot(HaveLeaked(goodgos))
+			Expect(Filedescriptors()).NotTo(HaveLeakedFds(goodfds))
+		})
+	})
+
+	It("creates an IPVLAN with a dummy parent in L2 mode by default", func() {
+		netnsfd := netns.NewTransient()
+		dmy := dummy.NewTransient(dummy.InNamespace(netnsfd))
+		ipvlan := NewTransient(dmy, InNamespace(netnsfd), WithLinkNamespace(netnsfd))
+		Expect(ipvlan.Attrs().Name).To(HavePrefix(IpvlanPrefix))
+
+		nlh := netns.NewNetlinkHandle(netnsfd)
+		l := Successful(nlh.LinkByName(ipvlan.Attrs().Name))
+		Expect(l.Type()).To(Equal("ipvlan"))
+		Expect(l).To(HaveField("Mode", netlink.IPVLAN_MODE_L2))
+		Expect(l.Attrs().ParentIndex).To(Equal(dmy.Attrs().Index))
+	})
+
+	It("creates an IPVLAN with its parent in a different network namespace", func() {
+		dmyNetnsfd := netns.NewTransient()
+		dmy := dummy.NewTransient(dummy.InNamespace(dmyNetnsfd))
+
+		destNetnsfd := netns.NewTransient()
+		ipvlan := NewTransient(dmy,
+			InNamespace(destNetnsfd),
+			WithLinkNamespace(dmyNetnsfd),
+			WithMode(netlink.IPVLAN_MODE_L3),
+			WithFlag(netlink.IPVLAN_FLAG_PRIVATE))
+
+		destnlh := netns.NewNetlinkHandle(destNetnsfd)
+		l := Successful(destnlh.LinkByName(ipvlan.Attrs().Name))
+		Expect(l).To(And(
+			HaveField("Attrs().Index", ipvlan.Attrs().Index),
+			HaveField("Mode", netlink.IPVLAN_MODE_L3),
+			HaveField("Flag", netlink.IPVLAN_FLAG_PRIVATE)))
+	})
+
+})
@@ -0,0 +1,75 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ipvlan
+
+import (
+	"fmt"
+
+	"github.com/thediveo/notwork/link"
+	"github.com/vishvananda/netlink"
+)
+
+// InNamespace configures an IPVLAN network interface to be created in the
+// network namespace referenced by fdref, instead of creating it in the current
+// network namespace.
+func InNamespace(fdref int) Opt {
+	return func(l *link.Link) error {
+		l.Attrs().Namespace = netlink.NsFd(fdref)
+		return nil
+	}
+}
+
+// WithLinkNamespace specifies the “reference” or “link” network namespace other
+// than the current network namespace when creating a new network interface.
+func WithLinkNamespace(fdref int) Opt {
+	return func(l *link.Link) error {
+		l.LinkNamespace = netlink.NsFd(fdref)
+		return nil
+	}
+}
+
+// WithMode configures the IPVLAN mode.
+//
+// See also: [netlink.IPVlanMode].
+func WithMode(mode netlink.IPVlanMode) Opt {
+	return func(l *link.Link) error {
+		if mode >= netlink.IPVLAN_MODE_MAX {
+			return fmt.Errorf("invalid IPVLAN mode %d", mode)
+		}
+		l.Link.(*netlink.IPVlan).Mode = mode
+		return nil
+	}
+}
+
+// WithFlag configures the IPVLAN flag, that is, either bridge, private, or VEPA
+// behavior.
+//
+// See also: [netlink.IPVlanFlag].
+func WithFlag(flag netlink.IPVlanFlag) Opt {
+	return func(l *link.Link) error {
+		if flag > netlink.IPVLAN_FLAG_VEPA {
+			return fmt.Errorf("invalid IPVLAN flag %d", flag)
+		}
+		l.Link.(*netlink.IPVlan).Flag = flag
+		return nil
+	}
+}
+
+// WithAddr configures an IPv4 or IPv6 address in CIDR notation (such as
+// “10.0.0.1/24” or “fd00::1/64”) to be assigned to an IPVLAN network interface
+// right after creation.
+func WithAddr(cidr string) Opt {
+	return Opt(link.WithAddr(cidr))
+}
@@ -0,0 +1,55 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ipvlan
+
+import (
+	"github.com/thediveo/notwork/link"
+	"github.com/vishvananda/netlink"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("IPVLAN configuration options", func() {
+
+	It("configures IPVLAN", func() {
+		l := &link.Link{Link: &netlink.IPVlan{}}
+		for _, opt := range []Opt{
+			InNamespace(42),
+			WithLinkNamespace(666),
+			WithMode(netlink.IPVLAN_MODE_L3S),
+			WithFlag(netlink.IPVLAN_FLAG_PRIVATE),
+		} {
+			Expect(opt(l)).To(Succeed())
+		}
+		Expect(l.Link).To(HaveField("Namespace", netlink.NsFd(42)))
+		Expect(l.LinkNamespace).To(Equal(netlink.NsFd(666)))
+		Expect(l.Link).To(And(
+			HaveField("Mode", netlink.IPVLAN_MODE_L3S),
+			HaveField("Flag", netlink.IPVLAN_FLAG_PRIVATE)))
+	})
+
+	It("rejects invalid modes and flags", func() {
+		Expect(WithMode(netlink.IPVLAN_MODE_MAX)(&link.Link{Link: &netlink.IPVlan{}})).NotTo(Succeed())
+		Expect(WithFlag(netlink.IPVLAN_FLAG_VEPA + 1)(&link.Link{Link: &netlink.IPVlan{}})).NotTo(Succeed())
+	})
+
+	It("configures an address", func() {
+		l := &link.Link{Link: &netlink.IPVlan{}}
+		Expect(WithAddr("10.0.0.1/24")(l)).To(Succeed())
+		Expect(l.Addrs).To(ConsistOf(HaveField("IPNet.String()", "10.0.0.1/24")))
+	})
+
+})
@@ -0,0 +1,126 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package link
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/thediveo/notwork/internal/wait"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+
+	. "github.com/onsi/ginkgo/v2" //lint:ignore ST1001 rule does not apply
+	. "github.com/onsi/gomega"    //lint:ignore ST1001 rule does not apply
+)
+
+// AddAddr assigns one or more IPv4 and/or IPv6 addresses in CIDR notation
+// (such as “10.0.0.1/24” or “fd00::1/64”) to the network interface l, in the
+// network namespace referenced by l.Attrs().Namespace; if unset, in the
+// current network namespace. If l has no interface index set, AddAddr looks up
+// the index by name. AddAddr schedules a DeferCleanup for each address to
+// remove it again, unless the address or network interface have gone in the
+// meantime.
+func AddAddr(l netlink.Link, addrs ...string) {
+	GinkgoHelper()
+
+	Expect(l).NotTo(BeNil(), "need a non-nil link description")
+
+	parsed := make([]*netlink.Addr, 0, len(addrs))
+	for _, cidr := range addrs {
+		addr, err := netlink.ParseAddr(cidr)
+		Expect(err).NotTo(HaveOccurred(), "invalid address %q", cidr)
+		parsed = append(parsed, addr)
+	}
+
+	// resolve an unset network namespace reference immediately, as the
+	// deferred cleanup might run when the caller has already switched back
+	// into a different network namespace.
+	nlh, err := layerHandle(l)
+	Expect(err).NotTo(HaveOccurred())
+	if l.Attrs().Index == 0 {
+		nif, err := nlh.LinkByName(l.Attrs().Name)
+		if err != nil {
+			nlh.Close()
+			Expect(err).NotTo(HaveOccurred(),
+				"cannot determine index of network interface %q", l.Attrs().Name)
+		}
+		l.Attrs().Index = nif.Attrs().Index
+	}
+	DeferCleanup(func() {
+		nlh.Close()
+	})
+
+	for _, addr := range parsed {
+		Expect(nlh.AddrAdd(l, addr)).To(Succeed(),
+			"cannot assign address %s to network interface %q", addr, l.Attrs().Name)
+		DeferCleanup(func() {
+			// when the network interface has already gone, so have its
+			// addresses.
+			if _, err := nlh.LinkByIndex(l.Attrs().Index); err != nil {
+				return
+			}
+			By(fmt.Sprintf("removing address %s from network interface %q", addr, l.Attrs().Name))
+			if err := nlh.AddrDel(l, addr); err != nil && !errors.Is(err, unix.EADDRNOTAVAIL) {
+				Expect(err).NotTo(HaveOccurred(),
+					"cannot remove address %s from network interface %q", addr, l.Attrs().Name)
+			}
+		})
+	}
+}
+
+// WaitAddressReady waits for the IP address ip assigned to the network
+// interface l to become ready, that is, for its IFA_F_TENTATIVE flag to clear
+// after duplicate address detection (DAD) has finished. WaitAddressReady polls
+// the address in the network namespace as referenced by l.Attrs().Namespace.
+// The maximum wait duration can be optionally specified; it defaults to
+// [DefaultUpTimeout].
+//
+// WaitAddressReady fails immediately when the address isn't assigned to the
+// network interface, or when DAD failed, as indicated by IFA_F_DADFAILED.
+//
+// Please note that DAD applies only to IPv6 addresses and that the Linux kernel
+// only starts DAD after the network interface has become operationally up.
+func WaitAddressReady(l netlink.Link, ip net.IP, within ...time.Duration) {
+	GinkgoHelper()
+
+	Expect(l).NotTo(BeNil(), "need a non-nil link description")
+
+	atmost := wait.Duration(within, DefaultUpTimeout)
+
+	nlh, err := NewHandle(l)
+	Expect(err).NotTo(HaveOccurred())
+	defer nlh.Close()
+	Eventually(func() bool {
+		addrs, err := nlh.AddrList(l, netlink.FAMILY_ALL)
+		if err != nil {
+			StopTrying("cannot list addresses").Wrap(err).Now()
+		}
+		for _, addr := range addrs {
+			if !addr.IP.Equal(ip) {
+				continue
+			}
+			if addr.Flags&unix.IFA_F_DADFAILED != 0 {
+				StopTrying("duplicate address detection failed").Now()
+			}
+			return addr.Flags&unix.IFA_F_TENTATIVE == 0
+		}
+		StopTrying("address not assigned").Now()
+		return false
+	}).Within(atmost).ProbeEvery(20*time.Millisecond).
+		Should(BeTrue(), "address %s of network interface %q never became ready", ip, l.Attrs().Name)
+}
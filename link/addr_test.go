@@ -0,0 +1,124 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package link
+
+import (
+	"net"
+	"os"
+	"time"
+
+	"github.com/thediveo/notwork/netns"
+	"github.com/vishvananda/netlink"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gleak"
+	. "github.com/thediveo/fdooze"
+	. "github.com/thediveo/success"
+)
+
+var _ = Describe("assigning and waiting for addresses", func() {
+
+	BeforeEach(func() {
+		if os.Getuid() != 0 {
+			Skip("needs root")
+		}
+
+		goodfds := Filedescriptors()
+		goodgos := Goroutines()
+		DeferCleanup(func() {
+			Eventually(Goroutines).Within(2 * time.Second).ProbeEvery(100 * time.Millisecond).
+				ShouldNot(HaveLeaked(goodgos))
+			Expect(Filedescriptors()).NotTo(HaveLeakedFds(goodfds))
+		})
+	})
+
+	It("doesn't accept multiple optional durations", func() {
+		Expect(func() {
+			WaitAddressReady(&netlink.Dummy{}, net.ParseIP("fd00::1"), time.Millisecond, time.Millisecond)
+		}).To(PanicWith(ContainSubstring("single optional maximum wait duration")))
+	})
+
+	It("waits for an IPv6 address to become non-tentative", func() {
+		netnsfd := netns.NewTransient()
+		veth := NewTransient(&netlink.Veth{
+			LinkAttrs: netlink.LinkAttrs{
+				Namespace: netlink.NsFd(netnsfd),
+			},
+			PeerNamespace: netlink.NsFd(netnsfd),
+		}, "veth-")
+		nlh := netns.NewNetlinkHandle(netnsfd)
+		addr := Successful(netlink.ParseAddr("fd00::1/64"))
+		Expect(nlh.AddrAdd(veth, addr)).To(Succeed())
+		Expect(nlh.LinkSetUp(veth)).To(Succeed())
+		Expect(nlh.LinkSetUp(Successful(nlh.LinkByName(veth.(*netlink.Veth).PeerName)))).To(Succeed())
+
+		WaitAddressReady(veth, addr.IP, 5*time.Second)
+
+		Expect(InterceptGomegaFailure(func() {
+			WaitAddressReady(veth, net.ParseIP("fd00::2"))
+		})).To(MatchError(ContainSubstring("address not assigned")))
+	})
+
+	It("assigns IPv4 and IPv6 addresses to a dummy", func() {
+		netnsfd := netns.NewTransient()
+		nlh := netns.NewNetlinkHandle(netnsfd)
+		dmy := NewTransient(&netlink.Dummy{
+			LinkAttrs: netlink.LinkAttrs{
+				Namespace: netlink.NsFd(netnsfd),
+			},
+		}, "dumy-")
+		AddAddr(dmy, "10.0.0.1/24", "fd00::1/64")
+		Expect(Successful(nlh.AddrList(dmy, netlink.FAMILY_ALL))).To(ContainElements(
+			HaveField("IPNet.String()", "10.0.0.1/24"),
+			HaveField("IPNet.String()", "fd00::1/64")))
+	})
+
+	It("assigns addresses by network interface name and removes them", func() {
+		netnsfd := netns.NewTransient()
+		nlh := netns.NewNetlinkHandle(netnsfd)
+		veth := NewTransient(&netlink.Veth{
+			LinkAttrs: netlink.LinkAttrs{
+				Namespace: netlink.NsFd(netnsfd),
+			},
+			PeerNamespace: netlink.NsFd(netnsfd),
+		}, "veth-")
+		By("assigning addresses in a separate scope", func() {
+			DeferCleanup(func() {
+				Expect(Successful(nlh.AddrList(veth, netlink.FAMILY_V4))).To(BeEmpty())
+			})
+			AddAddr(&netlink.Veth{
+				LinkAttrs: netlink.LinkAttrs{
+					Name:      veth.Attrs().Name,
+					Namespace: netlink.NsFd(netnsfd),
+				},
+			}, "10.0.0.1/24", "10.0.1.1/24")
+			Expect(Successful(nlh.AddrList(veth, netlink.FAMILY_V4))).To(ConsistOf(
+				HaveField("IPNet.String()", "10.0.0.1/24"),
+				HaveField("IPNet.String()", "10.0.1.1/24")))
+		})
+	})
+
+	It("rejects malformed addresses and unresolvable network interfaces", func() {
+		defer netns.EnterTransient()()
+		Expect(InterceptGomegaFailure(func() {
+			AddAddr(&netlink.Veth{}, "10.0.0.666/24")
+		})).To(MatchError(ContainSubstring(`invalid address "10.0.0.666/24"`)))
+		Expect(InterceptGomegaFailure(func() {
+			AddAddr(&netlink.Veth{LinkAttrs: netlink.LinkAttrs{Name: "foobar"}}, "10.0.0.1/24")
+		})).To(MatchError(ContainSubstring(`cannot determine index of network interface "foobar"`)))
+	})
+
+})
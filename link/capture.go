@@ -0,0 +1,106 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package link
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+
+	. "github.com/onsi/ginkgo/v2" //lint:ignore ST1001 rule does not apply
+	. "github.com/onsi/gomega"    //lint:ignore ST1001 rule does not apply
+)
+
+// Capture captures up to count frames on the network interface l, waiting at
+// most for the specified duration, and returns the captured frames including
+// their link-layer headers. Capture opens an AF_PACKET socket bound to l in
+// the network namespace referenced by l.Attrs().Namespace; if unset, in the
+// current network namespace. Capture sees both incoming and outgoing frames.
+//
+// As Capture blocks while capturing, the traffic to be captured needs to be
+// generated concurrently, such as from a separate Go routine started before
+// calling Capture.
+//
+// The returned function closes the packet socket; it is safe to call it
+// multiple times. Capture additionally schedules a DeferCleanup to close the
+// packet socket, so calling the returned function is optional.
+func Capture(l netlink.Link, count int, within time.Duration) ([][]byte, func()) {
+	GinkgoHelper()
+
+	Expect(l).NotTo(BeNil(), "need a non-nil link description")
+	Expect(count).To(BeNumerically(">", 0), "invalid frame count %d", count)
+
+	// The packet socket stays attached to the network namespace it was created
+	// in, so we need to switch only while creating and binding it.
+	proto := htons(unix.ETH_P_ALL)
+	fd := -1
+	err := inLinkNetns(l, func() error {
+		sock, err := unix.Socket(unix.AF_PACKET, unix.SOCK_RAW|unix.SOCK_CLOEXEC, int(proto))
+		if err != nil {
+			return err
+		}
+		if err := unix.Bind(sock, &unix.SockaddrLinklayer{
+			Protocol: proto,
+			Ifindex:  l.Attrs().Index,
+		}); err != nil {
+			unix.Close(sock)
+			return err
+		}
+		fd = sock
+		return nil
+	})
+	Expect(err).NotTo(HaveOccurred(),
+		"cannot capture on network interface %q", l.Attrs().Name)
+	closer := sync.OnceFunc(func() { _ = unix.Close(fd) })
+	DeferCleanup(closer)
+
+	frames := [][]byte{}
+	buff := make([]byte, 65536)
+	deadline := time.Now().Add(within)
+	for len(frames) < count {
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			break
+		}
+		pollfds := []unix.PollFd{{Fd: int32(fd), Events: unix.POLLIN}}
+		n, err := unix.Poll(pollfds, int(remaining.Milliseconds())+1)
+		if errors.Is(err, unix.EINTR) {
+			continue
+		}
+		Expect(err).NotTo(HaveOccurred(), "cannot wait for frames")
+		if n == 0 {
+			break
+		}
+		size, _, err := unix.Recvfrom(fd, buff, unix.MSG_DONTWAIT)
+		// Packet sockets report the network interface going or being down
+		// once, so we simply continue waiting for frames.
+		if errors.Is(err, unix.EAGAIN) || errors.Is(err, unix.EINTR) || errors.Is(err, unix.ENETDOWN) {
+			continue
+		}
+		Expect(err).NotTo(HaveOccurred(), "cannot receive frame")
+		frames = append(frames, bytes.Clone(buff[:size]))
+	}
+	return frames, closer
+}
+
+// htons converts a 16 bit value from host to network byte order.
+func htons(v uint16) uint16 {
+	return binary.NativeEndian.Uint16(binary.BigEndian.AppendUint16(nil, v))
+}
@@ -0,0 +1,116 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package link
+
+import (
+	"net"
+	"os"
+	"time"
+
+	"github.com/thediveo/notwork/netns"
+	"github.com/vishvananda/netlink"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gleak"
+	. "github.com/thediveo/fdooze"
+	. "github.com/thediveo/success"
+)
+
+var _ = Describe("capturing", func() {
+
+	BeforeEach(func() {
+		if os.Getuid() != 0 {
+			Skip("needs root")
+		}
+
+		goodfds := Filedescriptors()
+		goodgos := Goroutines()
+		DeferCleanup(func() {
+			Eventually(Goroutines).Within(2 * time.Second).ProbeEvery(100 * time.Millisecond).
+				ShouldNot(HaveLeaked(goodgos))
+			Expect(Filedescriptors()).NotTo(HaveLeakedFds(goodfds))
+		})
+	})
+
+	It("captures frames in a different network namespace", func() {
+		netnsfd := netns.NewTransient()
+		netns.SetSysctl(netnsfd, "net.ipv6.conf.default.disable_ipv6", "1")
+		veth := NewTransient(&netlink.Veth{
+			LinkAttrs: netlink.LinkAttrs{
+				Namespace: netlink.NsFd(netnsfd),
+			},
+			PeerNamespace: netlink.NsFd(netnsfd),
+		}, "veth-", WithAddr("10.0.0.1/24"))
+		nlh := netns.NewNetlinkHandle(netnsfd)
+		peer := Successful(nlh.LinkByName(veth.(*netlink.Veth).PeerName))
+		peer.Attrs().Namespace = netlink.NsFd(netnsfd)
+		Expect(nlh.LinkSetUp(veth)).To(Succeed())
+		Expect(nlh.LinkSetUp(peer)).To(Succeed())
+		Expect(nlh.NeighAdd(&netlink.Neigh{
+			LinkIndex:    veth.Attrs().Index,
+			Family:       netlink.FAMILY_V4,
+			State:        netlink.NUD_PERMANENT,
+			IP:           net.ParseIP("10.0.0.2"),
+			HardwareAddr: peer.Attrs().HardwareAddr,
+		})).To(Succeed())
+
+		var conn net.Conn
+		netns.Execute(netnsfd, func() {
+			conn = Successful(net.Dial("udp", "10.0.0.2:4242"))
+		})
+		defer conn.Close()
+		payload := []byte("Ceci n'est pas un paquet")
+		done := make(chan struct{})
+		defer close(done)
+		go func() {
+			for {
+				select {
+				case <-done:
+					return
+				case <-time.After(20 * time.Millisecond):
+					_, _ = conn.Write(payload)
+				}
+			}
+		}()
+
+		frames, closer := Capture(peer, 2, 2*time.Second)
+		Expect(frames).To(HaveLen(2))
+		Expect(frames).To(HaveEach(HaveSuffix(string(payload))))
+		closer()
+		Expect(closer).NotTo(Panic())
+	})
+
+	It("times out", func() {
+		netnsfd := netns.NewTransient()
+		veth := NewTransient(&netlink.Veth{
+			LinkAttrs: netlink.LinkAttrs{
+				Namespace: netlink.NsFd(netnsfd),
+			},
+			PeerNamespace: netlink.NsFd(netnsfd),
+		}, "veth-")
+		frames, _ := Capture(veth, 1, 100*time.Millisecond)
+		Expect(frames).To(BeEmpty())
+	})
+
+	It("fails for a missing network interface", func() {
+		defer netns.EnterTransient()()
+		Expect(InterceptGomegaFailure(func() {
+			_, _ = Capture(&netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Index: 4242, Name: "foo"}},
+				1, time.Second)
+		})).To(MatchError(ContainSubstring("cannot capture on network interface \"foo\"")))
+	})
+
+})
@@ -0,0 +1,77 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package link
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netlink/nl"
+	"golang.org/x/sys/unix"
+
+	. "github.com/onsi/ginkgo/v2" //lint:ignore ST1001 rule does not apply
+	. "github.com/onsi/gomega"    //lint:ignore ST1001 rule does not apply
+)
+
+// Carrier returns true if the network interface l has carrier, otherwise false.
+// Carrier queries the network interface in its network namespace as referenced
+// by l.Attrs().Namespace; if unset, in the current network namespace.
+//
+// In contrast to the operational state and the IFF_LOWER_UP flag, Carrier
+// reports the carrier state independent of the network interface being
+// administratively up or down. Carrier reads the IFLA_CARRIER netlink
+// attribute, as the netlink package doesn't decode it.
+func Carrier(l netlink.Link) bool {
+	GinkgoHelper()
+
+	Expect(l).NotTo(BeNil(), "need a non-nil link description")
+
+	var carrier bool
+	getCarrier := func() (err error) {
+		carrier, err = carrierByIndex(l.Attrs().Index)
+		return
+	}
+	err := inLinkNetns(l, getCarrier)
+	Expect(err).NotTo(HaveOccurred(),
+		"cannot determine carrier of network interface %q", l.Attrs().Name)
+	return carrier
+}
+
+// carrierByIndex returns the carrier state of the network interface with the
+// specified index in the current network namespace.
+func carrierByIndex(index int) (bool, error) {
+	req := nl.NewNetlinkRequest(unix.RTM_GETLINK, unix.NLM_F_ACK)
+	msg := nl.NewIfInfomsg(unix.AF_UNSPEC)
+	msg.Index = int32(index)
+	req.AddData(msg)
+	msgs, err := req.Execute(unix.NETLINK_ROUTE, unix.RTM_NEWLINK)
+	if err != nil {
+		return false, err
+	}
+	if len(msgs) == 0 {
+		return false, fmt.Errorf("no network interface with index %d", index)
+	}
+	attrs, err := nl.ParseRouteAttr(msgs[0][unix.SizeofIfInfomsg:])
+	if err != nil {
+		return false, err
+	}
+	for _, attr := range attrs {
+		if attr.Attr.Type == unix.IFLA_CARRIER && len(attr.Value) > 0 {
+			return attr.Value[0] != 0, nil
+		}
+	}
+	return false, errors.New("missing carrier information")
+}
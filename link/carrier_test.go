@@ -0,0 +1,77 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package link
+
+import (
+	"os"
+	"time"
+
+	"github.com/thediveo/notwork/netns"
+	"github.com/vishvananda/netlink"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gleak"
+	. "github.com/thediveo/fdooze"
+)
+
+var _ = Describe("carrier", func() {
+
+	BeforeEach(func() {
+		if os.Getuid() != 0 {
+			Skip("needs root")
+		}
+
+		goodfds := Filedescriptors()
+		goodgos := Goroutines()
+		DeferCleanup(func() {
+			Eventually(Goroutines).Within(2 * time.Second).ProbeEvery(100 * time.Millisecond).
+				ShouldNot(HaveLeaked(goodgos))
+			Expect(Filedescriptors()).NotTo(HaveLeakedFds(goodfds))
+		})
+	})
+
+	It("reads the carrier in a different network namespace", func() {
+		netnsfd := netns.NewTransient()
+		veth := NewTransient(&netlink.Veth{
+			LinkAttrs: netlink.LinkAttrs{
+				Namespace: netlink.NsFd(netnsfd),
+			},
+			PeerNamespace: netlink.NsFd(netnsfd),
+		}, "carr-")
+		nlh := netns.NewNetlinkHandle(netnsfd)
+		peer, err := nlh.LinkByName(veth.(*netlink.Veth).PeerName)
+		Expect(err).NotTo(HaveOccurred())
+		peer.Attrs().Namespace = netlink.NsFd(netnsfd)
+
+		Expect(Carrier(veth)).To(BeFalse())
+
+		Expect(nlh.LinkSetUp(veth)).To(Succeed())
+		Expect(nlh.LinkSetUp(peer)).To(Succeed())
+		Eventually(func() bool { return Carrier(veth) }).Should(BeTrue())
+
+		By("taking the peer down")
+		Expect(nlh.LinkSetDown(peer)).To(Succeed())
+		Eventually(func() bool { return Carrier(veth) }).Should(BeFalse())
+	})
+
+	It("fails for a missing network interface", func() {
+		defer netns.EnterTransient()()
+		Expect(InterceptGomegaFailure(func() {
+			_ = Carrier(&netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Index: 4242, Name: "foo"}})
+		})).To(MatchError(ContainSubstring("cannot determine carrier")))
+	})
+
+})
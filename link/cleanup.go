@@ -0,0 +1,44 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package link
+
+import (
+	"github.com/vishvananda/netlink"
+
+	. "github.com/onsi/ginkgo/v2" //lint:ignore ST1001 rule does not apply
+	. "github.com/onsi/gomega"    //lint:ignore ST1001 rule does not apply
+)
+
+// OnCleanup registers the callback fn to be run right after the transient
+// network interface l has been removed by its scheduled DeferCleanup. This
+// allows tests to verify side effects of the removal, such as code under test
+// having seen the corresponding RTM_DELLINK message, without having to
+// carefully order their own DeferCleanup's relative to the removal. Multiple
+// callbacks run in the order of their registration.
+//
+// The network interface l must have been returned by [NewTransient] (or one of
+// its siblings) and be scheduled for automatic removal; in case of VETH pairs,
+// this is the “first” VETH end only.
+func OnCleanup(l netlink.Link, fn func()) {
+	GinkgoHelper()
+
+	Expect(l).NotTo(BeNil(), "need a non-nil link description")
+	Expect(fn).NotTo(BeNil(), "need a non-nil callback")
+	t, ok := transients.Load(l)
+	Expect(ok).To(BeTrue(),
+		"network interface %q is not scheduled for automatic removal", l.Attrs().Name)
+	tracked := t.(*transient)
+	tracked.onCleanup = append(tracked.onCleanup, fn)
+}
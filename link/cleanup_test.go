@@ -0,0 +1,79 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package link
+
+import (
+	"os"
+	"time"
+
+	"github.com/thediveo/notwork/netns"
+	"github.com/vishvananda/netlink"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gleak"
+	. "github.com/thediveo/fdooze"
+)
+
+var _ = Describe("cleanup callbacks", func() {
+
+	BeforeEach(func() {
+		if os.Getuid() != 0 {
+			Skip("needs root")
+		}
+		goodfds := Filedescriptors()
+		goodgos := Goroutines()
+		DeferCleanup(func() {
+			Eventually(Goroutines).Within(2 * time.Second).ProbeEvery(250 * time.Millisecond).
+				ShouldNot(HaveLeaked(goodgos))
+			Expect(Filedescriptors()).NotTo(HaveLeakedFds(goodfds))
+		})
+	})
+
+	It("runs callbacks after removing a network interface", func() {
+		var calls []string
+		DeferCleanup(func() {
+			Expect(calls).To(Equal([]string{"first", "second"}))
+		})
+
+		By("creating a transient network interface in a separate scope", func() {
+			netnsfd := netns.NewTransient()
+			nlh := netns.NewNetlinkHandle(netnsfd)
+			veth := NewTransient(&netlink.Veth{
+				LinkAttrs: netlink.LinkAttrs{Namespace: netlink.NsFd(netnsfd)},
+				PeerName:  "peer",
+			}, "veth-")
+			index := veth.Attrs().Index
+			OnCleanup(veth, func() {
+				Expect(nlh.LinkByIndex(index)).Error().To(HaveOccurred())
+				calls = append(calls, "first")
+			})
+			OnCleanup(veth, func() {
+				calls = append(calls, "second")
+			})
+			Expect(calls).To(BeEmpty())
+		})
+	})
+
+	It("rejects network interfaces not scheduled for removal", func() {
+		Expect(InterceptGomegaFailure(func() {
+			OnCleanup(&netlink.Veth{LinkAttrs: netlink.LinkAttrs{Name: "foo"}}, func() {})
+		})).To(MatchError(ContainSubstring("is not scheduled for automatic removal")))
+		Expect(InterceptGomegaFailure(func() {
+			OnCleanup(nil, func() {})
+		})).To(MatchError(ContainSubstring("need a non-nil link description")))
+	})
+
+})
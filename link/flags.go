@@ -0,0 +1,76 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package link
+
+import (
+	"net"
+	"time"
+
+	"github.com/thediveo/notwork/internal/wait"
+	"github.com/vishvananda/netlink"
+
+	. "github.com/onsi/ginkgo/v2" //lint:ignore ST1001 rule does not apply
+	. "github.com/onsi/gomega"    //lint:ignore ST1001 rule does not apply
+)
+
+// WaitForFlag waits for the specified flag of the network interface l to become
+// either set or reset, polling the network interface in its network namespace
+// as referenced by l.Attrs().Namespace. The maximum wait duration can be
+// optionally specified; it defaults to [DefaultUpTimeout].
+//
+// Please note that the netlink package maps only the “up”, “broadcast”,
+// “loopback”, “point-to-point”, and “multicast” flags to [net.Flags]. Use
+// [WaitForRawFlag] for other flags, such as IFF_NOARP and IFF_PROMISC.
+func WaitForFlag(l netlink.Link, flag net.Flags, set bool, within ...time.Duration) {
+	GinkgoHelper()
+	waitForFlag(l, func(attrs *netlink.LinkAttrs) bool {
+		return attrs.Flags&flag != 0
+	}, set, within...)
+}
+
+// WaitForRawFlag waits for the specified raw IFF_* flag (such as
+// [unix.IFF_NOARP]) of the network interface l to become either set or reset,
+// polling the network interface in its network namespace as referenced by
+// l.Attrs().Namespace. The maximum wait duration can be optionally specified;
+// it defaults to [DefaultUpTimeout].
+//
+// [unix.IFF_NOARP]: https://pkg.go.dev/golang.org/x/sys/unix#IFF_NOARP
+func WaitForRawFlag(l netlink.Link, flag uint32, set bool, within ...time.Duration) {
+	GinkgoHelper()
+	waitForFlag(l, func(attrs *netlink.LinkAttrs) bool {
+		return attrs.RawFlags&flag != 0
+	}, set, within...)
+}
+
+// waitForFlag waits for the flag tested by isSet to reach the desired state.
+func waitForFlag(l netlink.Link, isSet func(*netlink.LinkAttrs) bool, set bool, within ...time.Duration) {
+	GinkgoHelper()
+
+	Expect(l).NotTo(BeNil(), "need a non-nil link description")
+
+	atmost := wait.Duration(within, DefaultUpTimeout)
+
+	nlh, err := NewHandle(l)
+	Expect(err).NotTo(HaveOccurred())
+	defer nlh.Close()
+	Eventually(func() bool {
+		lnk, err := nlh.LinkByIndex(l.Attrs().Index)
+		if err != nil {
+			StopTrying("network interface went missing").Wrap(err).Now()
+		}
+		return isSet(lnk.Attrs())
+	}).Within(atmost).ProbeEvery(20*time.Millisecond).
+		Should(Equal(set), "network interface %q flag never reached desired state", l.Attrs().Name)
+}
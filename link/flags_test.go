@@ -0,0 +1,85 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package link
+
+import (
+	"net"
+	"os"
+	"time"
+
+	"github.com/thediveo/notwork/netns"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gleak"
+	. "github.com/thediveo/fdooze"
+)
+
+var _ = Describe("waiting for network interface flags", func() {
+
+	BeforeEach(func() {
+		if os.Getuid() != 0 {
+			Skip("needs root")
+		}
+
+		goodfds := Filedescriptors()
+		goodgos := Goroutines()
+		DeferCleanup(func() {
+			Eventually(Goroutines).Within(2 * time.Second).ProbeEvery(100 * time.Millisecond).
+				ShouldNot(HaveLeaked(goodgos))
+			Expect(Filedescriptors()).NotTo(HaveLeakedFds(goodfds))
+		})
+	})
+
+	It("doesn't accept multiple optional durations", func() {
+		Expect(func() {
+			WaitForFlag(&netlink.Dummy{}, net.FlagUp, true, time.Millisecond, time.Millisecond)
+		}).To(PanicWith(ContainSubstring("single optional maximum wait duration")))
+	})
+
+	It("waits for flags in a different network namespace", func() {
+		netnsfd := netns.NewTransient()
+		veth := NewTransient(&netlink.Veth{
+			LinkAttrs: netlink.LinkAttrs{
+				Namespace: netlink.NsFd(netnsfd),
+			},
+			PeerNamespace: netlink.NsFd(netnsfd),
+		}, "veth-")
+		nlh := netns.NewNetlinkHandle(netnsfd)
+
+		WaitForFlag(veth, net.FlagUp, false)
+		Expect(nlh.LinkSetUp(veth)).To(Succeed())
+		WaitForFlag(veth, net.FlagUp, true)
+
+		WaitForRawFlag(veth, unix.IFF_NOARP, false)
+		Expect(nlh.LinkSetARPOff(veth)).To(Succeed())
+		WaitForRawFlag(veth, unix.IFF_NOARP, true)
+
+		Expect(InterceptGomegaFailure(func() {
+			WaitForFlag(veth, net.FlagLoopback, true, 50*time.Millisecond)
+		})).To(MatchError(ContainSubstring("flag never reached desired state")))
+	})
+
+	It("stops when the network interface is missing", func() {
+		Expect(InterceptGomegaFailure(func() {
+			WaitForFlag(&netlink.Dummy{
+				LinkAttrs: netlink.LinkAttrs{Index: 666666},
+			}, net.FlagUp, true)
+		})).To(MatchError(ContainSubstring("network interface went missing")))
+	})
+
+})
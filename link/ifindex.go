@@ -0,0 +1,73 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package link
+
+import (
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netns"
+
+	. "github.com/onsi/ginkgo/v2" //lint:ignore ST1001 rule does not apply
+	. "github.com/onsi/gomega"    //lint:ignore ST1001 rule does not apply
+)
+
+// NextIfIndex returns the interface index that is one greater than the current
+// maximum interface index in the network namespace referenced by netnsfd.
+//
+// Please note that this is only a best-effort prediction of the interface
+// index a new network interface will get: it is subject to races with other
+// network interfaces getting created in the same network namespace, and the
+// Linux kernel might also decide to allocate indices differently, such as after
+// network interfaces have been removed. In order to reliably pin the interface
+// index of a new network interface, use [WithIfIndex] instead.
+func NextIfIndex(netnsfd int) int {
+	GinkgoHelper()
+
+	nlh, err := netlink.NewHandleAt(netns.NsHandle(netnsfd))
+	Expect(err).NotTo(HaveOccurred(), "cannot create NETLINK handle for network namespace")
+	defer nlh.Close()
+	links, err := nlh.LinkList()
+	Expect(err).NotTo(HaveOccurred(), "cannot retrieve list of network interfaces")
+	maxIndex := 0
+	for _, link := range links {
+		maxIndex = max(maxIndex, link.Attrs().Index)
+	}
+	return maxIndex + 1
+}
+
+// ResolveIndex re-resolves the interface index of the network interface l from
+// its current name l.Attrs().Name, updating l.Attrs().Index. The network
+// interface is looked up in the network namespace referenced by
+// l.Attrs().Namespace; if unset, in the current network namespace.
+//
+// [NewTransient] determines the interface index only once when creating a new
+// network interface. Use ResolveIndex when code under test has replaced a
+// network interface with a new one of the same name, such as by deleting and
+// recreating it, or after updating l.Attrs().Name to follow an external rename.
+// Otherwise, operations using the link's stale interface index would either
+// fail or, even worse, act on an unrelated network interface. As the deferred
+// cleanup scheduled by NewTransient works on the same link, it then removes the
+// replacement network interface.
+func ResolveIndex(l netlink.Link) {
+	GinkgoHelper()
+
+	Expect(l).NotTo(BeNil(), "need a non-nil link description")
+	nlh, err := NewHandle(l)
+	Expect(err).NotTo(HaveOccurred())
+	defer nlh.Close()
+	lnk, err := nlh.LinkByName(l.Attrs().Name)
+	Expect(err).NotTo(HaveOccurred(),
+		"cannot resolve index of network interface %q", l.Attrs().Name)
+	l.Attrs().Index = lnk.Attrs().Index
+}
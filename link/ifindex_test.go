@@ -0,0 +1,108 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package link
+
+import (
+	"os"
+	"time"
+
+	"github.com/thediveo/notwork/netns"
+	"github.com/vishvananda/netlink"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gleak"
+	. "github.com/thediveo/fdooze"
+	. "github.com/thediveo/success"
+)
+
+var _ = Describe("interface indices", func() {
+
+	BeforeEach(func() {
+		if os.Getuid() != 0 {
+			Skip("needs root")
+		}
+
+		goodfds := Filedescriptors()
+		goodgos := Goroutines()
+		DeferCleanup(func() {
+			Eventually(Goroutines).Within(2 * time.Second).ProbeEvery(100 * time.Millisecond).
+				ShouldNot(HaveLeaked(goodgos))
+			Expect(Filedescriptors()).NotTo(HaveLeakedFds(goodfds))
+		})
+	})
+
+	It("predicts the next interface index", func() {
+		netnsfd := netns.NewTransient()
+		// a fresh network namespace only has "lo" with index 1.
+		Expect(NextIfIndex(netnsfd)).To(Equal(2))
+
+		vethA := NewTransient(&netlink.Veth{
+			LinkAttrs: netlink.LinkAttrs{
+				Namespace: netlink.NsFd(netnsfd),
+			},
+			PeerNamespace: netlink.NsFd(netnsfd),
+		}, "veth-")
+		Expect(vethA.Attrs().Index).To(BeNumerically(">=", 2))
+		Expect(NextIfIndex(netnsfd)).To(BeNumerically(">", vethA.Attrs().Index))
+	})
+
+	It("creates a network interface with a pinned interface index", func() {
+		netnsfd := netns.NewTransient()
+
+		vethA := NewTransient(&netlink.Veth{
+			LinkAttrs: netlink.LinkAttrs{
+				Namespace: netlink.NsFd(netnsfd),
+			},
+			PeerNamespace: netlink.NsFd(netnsfd),
+		}, "veth-", WithIfIndex(42))
+		Expect(vethA.Attrs().Index).To(Equal(42))
+		Expect(Successful(netns.NewNetlinkHandle(netnsfd).LinkByIndex(42))).To(
+			HaveField("Attrs().Name", vethA.Attrs().Name))
+	})
+
+	It("re-resolves a stale interface index", func() {
+		netnsfd := netns.NewTransient()
+
+		veth := NewTransient(&netlink.Veth{
+			LinkAttrs: netlink.LinkAttrs{
+				Namespace: netlink.NsFd(netnsfd),
+			},
+			PeerNamespace: netlink.NsFd(netnsfd),
+		}, "veth-")
+		staleIndex := veth.Attrs().Index
+
+		By("replacing the network interface with a new one of the same name")
+		nlh := netns.NewNetlinkHandle(netnsfd)
+		Expect(nlh.LinkDel(veth)).To(Succeed())
+		Expect(nlh.LinkAdd(&netlink.Veth{
+			LinkAttrs: netlink.LinkAttrs{Name: veth.Attrs().Name},
+			PeerName:  veth.(*netlink.Veth).PeerName,
+		})).To(Succeed())
+
+		ResolveIndex(veth)
+		Expect(veth.Attrs().Index).NotTo(Equal(staleIndex))
+		Expect(Successful(nlh.LinkByIndex(veth.Attrs().Index))).To(
+			HaveField("Attrs().Name", veth.Attrs().Name))
+	})
+
+	It("fails to resolve the index of a missing network interface", func() {
+		defer netns.EnterTransient()()
+		Expect(InterceptGomegaFailure(func() {
+			ResolveIndex(&netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Name: "foobar"}})
+		})).To(MatchError(ContainSubstring("cannot resolve index of network interface \"foobar\"")))
+	})
+
+})
@@ -0,0 +1,46 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package link
+
+import (
+	"net"
+
+	"github.com/vishvananda/netlink"
+)
+
+// IsHardware returns true if the specified link is a “hardware” network
+// interface, otherwise false.
+//
+// A network interface is considered to be hardware if the kernel doesn't report
+// any specific link kind for it, so that the netlink package falls back to the
+// generic type “device”. Notably, this includes netdevsim ports. The loopback
+// network interface “lo” is also of type “device”, but IsHardware doesn't
+// consider it to be hardware.
+func IsHardware(l netlink.Link) bool {
+	if l == nil {
+		return false
+	}
+	return l.Type() == "device" && l.Attrs().Flags&net.FlagLoopback == 0
+}
+
+// IsVirtual returns true if the specified link is a virtual network interface,
+// such as a VETH, MACVLAN, or the loopback network interface “lo”. IsVirtual is
+// the opposite of [IsHardware], except for a nil link, which is neither.
+func IsVirtual(l netlink.Link) bool {
+	if l == nil {
+		return false
+	}
+	return !IsHardware(l)
+}
@@ -0,0 +1,43 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package link
+
+import (
+	"net"
+
+	"github.com/vishvananda/netlink"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("hardware and virtual network interfaces", func() {
+
+	DescribeTable("classifying links",
+		func(l netlink.Link, hw, virt bool) {
+			Expect(IsHardware(l)).To(Equal(hw))
+			Expect(IsVirtual(l)).To(Equal(virt))
+		},
+		Entry("nil", nil, false, false),
+		Entry("device", &netlink.Device{}, true, false),
+		Entry("loopback", &netlink.Device{
+			LinkAttrs: netlink.LinkAttrs{Flags: net.FlagLoopback | net.FlagUp},
+		}, false, true),
+		Entry("VETH", &netlink.Veth{}, false, true),
+		Entry("MACVLAN", &netlink.Macvlan{}, false, true),
+		Entry("dummy", &netlink.Dummy{}, false, true),
+	)
+
+})
@@ -17,12 +17,15 @@ package link
 import (
 	"errors"
 	"fmt"
-	"math/rand"
 	"os"
 	"reflect"
+	"runtime"
+	"strings"
 	"time"
 
 	"github.com/jinzhu/copier"
+	"github.com/thediveo/notwork/internal/base62"
+	"github.com/thediveo/notwork/internal/wait"
 	"github.com/vishvananda/netlink"
 	"github.com/vishvananda/netns"
 	"golang.org/x/sys/unix"
@@ -77,6 +80,11 @@ type Opt func(*Link) error
 // in a different (“destination”) network namespace than the caller's current
 // network namespace.
 //
+// The passed-in [netlink.LinkAttrs.Namespace] can either be a [netlink.NsFd] or
+// a [netlink.NsPid]. In case of a PID, NewTransient resolves the network
+// namespace of the process only once, so that the network interface gets
+// created and later removed in the same network namespace.
+//
 // However, please note that the current network namespace can still play a
 // role, such as when creating a MACVLAN network interface: then, the MACVLAN's
 // parent network interface reference (in form of an interface index) must be in
@@ -84,49 +92,141 @@ type Opt func(*Link) error
 // must be specified. Alternatively, a wrapped [Link] can be passed as the
 // [netlink.Link] that specifies the “link” network namespace to use.
 //
+// If a wrapped [Link] with [Link.Addrs] is passed in (such as when using the
+// [WithAddr] option), then NewTransient assigns these addresses to the newly
+// created network interface in its (destination) network namespace. The
+// addresses automatically go away together with the transient network
+// interface.
+//
+// If a wrapped [Link] with [Link.AttrsFns] is passed in (such as when using
+// the [WithAttrs] option), then NewTransient applies these functions to the
+// attributes of the deep-copied link description before creating the network
+// interface.
+//
+// If a wrapped [Link] with [Link.SetupFns] is passed in, then NewTransient
+// calls these functions after creating the network interface and assigning any
+// addresses, passing the newly created link. The link's
+// [netlink.LinkAttrs.Namespace] then is either nil for the current network
+// namespace, or a [netlink.NsFd]. This allows configuring network interfaces
+// beyond what can be specified when creating them.
+//
+// If a wrapped [Link] with [Link.NameLen] is passed in (such as when using the
+// [WithMaxNameLen] option), then NewTransient creates random names of this
+// length instead of the maximum length.
+//
+// If a wrapped [Link] with [Link.FixedNames] is passed in, then NewTransient
+// uses the passed-in name, as well as the VETH peer name, instead of random
+// names. Creation then fails if a name is already in use.
+//
+// If a wrapped [Link] with [Link.OperState] is passed in (such as when using
+// the [WithExpectedInitialState] option), then NewTransient fails the current
+// test if the newly created network interface isn't in this operational state.
+//
+// If a wrapped [Link] with [Link.NoIndexFixup] is passed in (such as when
+// using the [SkipIndexFixup] option), then NewTransient doesn't re-fetch the
+// interface index of the newly created network interface, unless it needs to
+// check the initial operational state anyway.
+//
+// If a wrapped [Link] with [Link.NoCleanup] is passed in (such as when using
+// the [WithoutCleanup] option), then NewTransient doesn't schedule the newly
+// created network interface for removal; the caller then is responsible for
+// removing it.
+//
+// Use [TryNewTransient] instead in order to check for specific failures
+// without failing the current test.
+//
 // # Important
 //
-// Do not move a link to a different network namespace, as this interferes with
+// Do not move a link to a different network namespace by other means than
+// [MoveToNamespace] or [MoveToNamespacePID], as this otherwise interferes with
 // the automated cleanup.
 func NewTransient(link netlink.Link, prefix string, opts ...Opt) netlink.Link {
 	GinkgoHelper()
 
-	Expect(link).NotTo(BeNil(), "need a non-nil link description")
-	if _, ok := link.Attrs().Namespace.(netlink.NsFd); link.Attrs().Namespace != nil && !ok {
-		fail("link.Attrs().Namespace reference must be nil or a netlink.NsFd")
+	defer threadingCheck("link.NewTransient")()
+	link, err := TryNewTransient(link, prefix, opts...)
+	if err != nil {
+		fail(err.Error())
+	}
+	return link
+}
+
+// TryNewTransient works like [NewTransient], but returns an error instead of
+// failing the current test. The returned error wraps the original netlink or
+// syscall error, where applicable, so callers can check for specific errors
+// using [errors.Is], such as [unix.EINVAL]. On success, TryNewTransient
+// schedules the newly created network interface for removal, unless told
+// otherwise, the same as NewTransient.
+//
+// [unix.EINVAL]: https://pkg.go.dev/golang.org/x/sys/unix#EINVAL
+func TryNewTransient(link netlink.Link, prefix string, opts ...Opt) (netlink.Link, error) {
+	GinkgoHelper()
+
+	if link == nil {
+		return nil, errors.New("need a non-nil link description")
+	}
+	// Catch overly long prefixes early on, and tell whoever called us, as
+	// it most probably is a (sub) package using us.
+	if len(prefix) > maxNifnameLen-minRandomLen {
+		return nil, fmt.Errorf("network interface name prefix %q passed by %s is %d characters long, but max. %d characters allowed",
+			prefix, callerName(), len(prefix), maxNifnameLen-minRandomLen)
+	}
+	switch link.Attrs().Namespace.(type) {
+	case nil, netlink.NsFd, netlink.NsPid:
+	default:
+		return nil, errors.New("link.Attrs().Namespace reference must be nil, a netlink.NsFd, or a netlink.NsPid")
 	}
 
 	// Process configuration options, if any...
 	link = EnsureWrap(link)
 	for _, opt := range opts {
-		Expect(opt(link.(*Link))).To(Succeed())
+		if err := opt(link.(*Link)); err != nil {
+			return nil, fmt.Errorf("invalid option, reason: %w", err)
+		}
 	}
 
 	// Callers might pass in a wrapped.Link in order to transport network
 	// namespace information, or they might not (especially external API
 	// callers). So unwrap when necessary, keeping the piggy-backed link
-	// namespace reference, if any.
+	// namespace reference, if any, as well as any addresses to assign.
+	addrs := link.(*Link).Addrs
+	noCleanup := link.(*Link).NoCleanup
+	attrsFns := link.(*Link).AttrsFns
+	fixedNames := link.(*Link).FixedNames
+	operState := link.(*Link).OperState
+	nameLen := link.(*Link).NameLen
+	noIndexFixup := link.(*Link).NoIndexFixup
+	setupFns := link.(*Link).SetupFns
+	if nameLen == 0 {
+		nameLen = maxNifnameLen
+	} else if err := checkNifnameLen(prefix, nameLen); err != nil {
+		return nil, err
+	}
 	link, linkNamespace := Unwrap(link)
 	// Create a deep copy of the (unwrapped) link description.
-	newlink := reflect.New(reflect.ValueOf(link).Elem().Type()).Interface().(netlink.Link)
-	Expect(copier.CopyWithOption(newlink, link, copier.Option{DeepCopy: true, IgnoreEmpty: true})).
-		To(Succeed())
-	link = newlink
+	link, err := tryDeepCopy(link)
+	if err != nil {
+		return nil, err
+	}
+	for _, attrsFn := range attrsFns {
+		attrsFn(link.Attrs())
+	}
 
 	// The caller might pass us an additional "link" network namespace, to use
 	// Linux kernel terminology. This "link" network namespace is not to be
 	// confused with netlink.LinkAttrs.Namespace, but instead specifies the
 	// network namespace in which to start creation from in order to correctly
 	// resolve parent/master link ifindex references.
-	var linknetnsh *netlink.Handle // ...only needed inside NewTransient
+	var linknetnsh *netlink.Handle // ...only needed inside TryNewTransient
 	if linkNamespace != nil {
 		linknetnsfd, ok := linkNamespace.(netlink.NsFd)
 		if !ok {
-			fail("wrapped namespace.LinkNamespace must be nil or a netlink.NsFd")
+			return nil, errors.New("wrapped namespace.LinkNamespace must be nil or a netlink.NsFd")
 		}
-		var err error
 		linknetnsh, err = netlink.NewHandleAt(netns.NsHandle(linknetnsfd))
-		Expect(err).NotTo(HaveOccurred(), "cannot create NETLINK handle for link network namespace")
+		if err != nil {
+			return nil, fmt.Errorf("cannot create NETLINK handle for link network namespace, reason: %w", err)
+		}
 		defer linknetnsh.Close() // only needed momentarily
 	}
 
@@ -137,7 +237,6 @@ func NewTransient(link netlink.Link, prefix string, opts ...Opt) netlink.Link {
 	// the current network namespace, so we need to take care to get the netlink
 	// handle in the correct network namespace.
 	var netnsh *netlink.Handle // ...that should be needed till the end.
-	var err error
 	if link.Attrs().Namespace == nil {
 		// Avoid promoting a potential circular dependency, so we get the
 		// reference to the current network namespace by hand instead of using
@@ -145,14 +244,36 @@ func NewTransient(link netlink.Link, prefix string, opts ...Opt) netlink.Link {
 		// netns.Current arranges for a DeferCleanup that we don't want to be
 		// done yet.
 		netnsfd, err := unix.Open("/proc/thread-self/ns/net", unix.O_RDONLY, 0)
+		if err != nil {
+			return nil, fmt.Errorf("cannot determine current network namespace from procfs, reason: %w", err)
+		}
+		defer unix.Close(netnsfd)
+		netnsh, err = netlink.NewHandleAt(netns.NsHandle(netnsfd))
+		if err != nil {
+			return nil, fmt.Errorf("cannot create NETLINK handle for network namespace, reason: %w", err)
+		}
+	} else if nspid, ok := link.Attrs().Namespace.(netlink.NsPid); ok {
+		// Resolve the PID-referenced network namespace only once, so that we
+		// create the link and later remove it in the same network namespace,
+		// even if the process terminates in the meantime. When done, we
+		// restore the original PID reference.
+		netnsfd, err := unix.Open(fmt.Sprintf("/proc/%d/ns/net", nspid), unix.O_RDONLY|unix.O_CLOEXEC, 0)
+		if err != nil {
+			return nil, fmt.Errorf("cannot reference network namespace of process with PID %d, reason: %w", nspid, err)
+		}
 		defer unix.Close(netnsfd)
-		Expect(err).NotTo(HaveOccurred(), "cannot determine current network namespace from procfs")
+		link.Attrs().Namespace = netlink.NsFd(netnsfd)
+		defer func() { link.Attrs().Namespace = nspid }()
 		netnsh, err = netlink.NewHandleAt(netns.NsHandle(netnsfd))
-		Expect(err).NotTo(HaveOccurred(), "cannot create NETLINK handle for network namespace")
+		if err != nil {
+			return nil, fmt.Errorf("cannot create NETLINK handle, reason: %w", err)
+		}
 	} else {
-		// Type assertion is guarded by BeAssignableToTypeOf assertion above.
+		// Type assertion is guarded by the type switch above.
 		netnsh, err = netlink.NewHandleAt(netns.NsHandle(link.Attrs().Namespace.(netlink.NsFd)))
-		Expect(err).NotTo(HaveOccurred(), "cannot create NETLINK handle")
+		if err != nil {
+			return nil, fmt.Errorf("cannot create NETLINK handle, reason: %w", err)
+		}
 	}
 
 	defer func() {
@@ -164,14 +285,16 @@ func NewTransient(link netlink.Link, prefix string, opts ...Opt) netlink.Link {
 	}()
 
 	for attempt := 1; attempt <= 10; attempt++ {
-		// Roll the dice to create a (new) random interface name...
-		ifname := base62Nifname(prefix)
-		link.Attrs().Name = ifname
-		// If this is going to be a VETH peer-to-peer link, then also roll the
-		// dice to create a random peer interface name...
-		if veth, ok := link.(*netlink.Veth); ok {
-			peername := base62Nifname(prefix)
-			veth.PeerName = peername
+		if !fixedNames {
+			// Roll the dice to create a (new) random interface name...
+			ifname := prefix + base62.Random(nameLen-len(prefix))
+			link.Attrs().Name = ifname
+			// If this is going to be a VETH peer-to-peer link, then also roll
+			// the dice to create a random peer interface name...
+			if veth, ok := link.(*netlink.Veth); ok {
+				peername := prefix + base62.Random(nameLen-len(prefix))
+				veth.PeerName = peername
+			}
 		}
 		// Try to create the link and let's see what happens...
 		var err error
@@ -184,47 +307,122 @@ func NewTransient(link netlink.Link, prefix string, opts ...Opt) netlink.Link {
 			// did we run just run into an accidentally duplicate random name,
 			// or into a general error instead?
 			if errors.Is(err, os.ErrExist) {
+				if fixedNames {
+					return nil, fmt.Errorf("cannot create a transient network interface of type %q, name %q already in use, reason: %w",
+						link.Type(), link.Attrs().Name, err)
+				}
 				continue
 			}
-			fail(fmt.Sprintf("cannot create a transient network interface of type %q, reason: %v", link.Type(), err))
+			return nil, fmt.Errorf("cannot create a transient network interface of type %q, reason: %w", link.Type(), err)
 		}
 		// Phew, this worked.
 		By(fmt.Sprintf("creating a transient network interface %q", link.Attrs().Name))
 		// Work around a bug in vishvananda/netlink where the Index attribute
 		// isn't updated correctly or even wrongly when
-		// netlink.LinkAttrs.Namespace has been set.
-		targetLink, err := netnsh.LinkByName(link.Attrs().Name)
-		Expect(err).NotTo(HaveOccurred(), "cannot determine network interface index after creation")
-		Expect(targetLink).NotTo(BeNil(), "cannot determine network interface index after creation")
-		link.Attrs().Index = targetLink.Attrs().Index
-		// Note that in case of VETH pairs we only need to remove one end in
-		// order to also remove the other end automatically. No dangling
-		// virtual wires.
-		{
-			netnsh := netnsh // the deferred cleanup closure must capture the handle value copy.
-			DeferCleanup(func() {
-				defer func() {
-					netnsh.Close() // finally release the netlink handle
-				}()
-				By(fmt.Sprintf("removing transient network interface %q", link.Attrs().Name))
-				Expect(netnsh.LinkDel(link)).To(Succeed(), "cannot remove transient network interface %q", link.Attrs().Name)
-			})
+		// netlink.LinkAttrs.Namespace has been set; unless told otherwise.
+		var targetLink netlink.Link
+		if !noIndexFixup || operState != nil {
+			targetLink, err = netnsh.LinkByName(link.Attrs().Name)
+			if err != nil {
+				return nil, fmt.Errorf("cannot determine network interface index after creation, reason: %w", err)
+			}
+			link.Attrs().Index = targetLink.Attrs().Index
+		}
+		nlh := netnsh
+		if !noCleanup {
+			// Note that in case of VETH pairs we only need to remove one end
+			// in order to also remove the other end automatically. No
+			// dangling virtual wires.
+			{
+				// the deferred cleanup closure must capture the handle value
+				// copy; we track it so that it can be updated when moving the
+				// link into a different network namespace.
+				tracked := &transient{nlh: netnsh}
+				transients.Store(link, tracked)
+				DeferCleanup(func() {
+					defer func() {
+						transients.Delete(link)
+						tracked.nlh.Close() // finally release the netlink handle
+					}()
+					By(fmt.Sprintf("removing transient network interface %q", link.Attrs().Name))
+					Expect(tracked.nlh.LinkDel(link)).To(Succeed(), "cannot remove transient network interface %q", link.Attrs().Name)
+					for _, fn := range tracked.onCleanup {
+						fn()
+					}
+				})
+			}
+			// tell the deferred handler (this is NOT the DeferCleanup
+			// handler) to not close the netlink handle as it is still needed
+			// later by the deferred cleanup handler.
+			netnsh = nil
+		}
+		// Only now that the transient network interface is safely scheduled
+		// for removal (unless told otherwise), set any alias, as the Linux
+		// kernel ignores aliases when creating network interfaces.
+		if alias := link.Attrs().Alias; alias != "" {
+			if err := nlh.LinkSetAlias(link, alias); err != nil {
+				return nil, fmt.Errorf("cannot set alias of network interface %q, reason: %w",
+					link.Attrs().Name, err)
+			}
+		}
+		// Then assign any addresses.
+		for _, addr := range addrs {
+			if err := nlh.AddrAdd(link, addr); err != nil {
+				return nil, fmt.Errorf("cannot assign address %s to network interface %q, reason: %w",
+					addr, link.Attrs().Name, err)
+			}
+		}
+		// Then run any additional setup.
+		for _, setupFn := range setupFns {
+			if err := setupFn(link); err != nil {
+				return nil, fmt.Errorf("cannot set up network interface %q, reason: %w",
+					link.Attrs().Name, err)
+			}
+		}
+		// Finally check the initial operational state, if told so.
+		if operState != nil && targetLink.Attrs().OperState != *operState {
+			return nil, fmt.Errorf("transient network interface %q has initial operational state %q, but expected %q",
+				link.Attrs().Name, targetLink.Attrs().OperState, *operState)
 		}
-		// tell the deferred handler (this is NOT the DeferCleanup handler)
-		// to not close the netlink handle as it is still needed later by
-		// the deferred cleanup handler.
-		netnsh = nil
-		return link
+		return link, nil
 	}
-	fail(fmt.Sprintf("too many failed attempts to create a transient network interface of type %q", link.Type()))
-	return nil // not reachable
+	return nil, fmt.Errorf("too many failed attempts to create a transient network interface of type %q", link.Type())
 }
 
-// EnsureUp brings the specified network interface up and waits for it to become
-// operationally “UP” or “UNKNOWN”. The maximum wait duration can be optionally
-// specified; it defaults to 2s.
+// deepCopy returns a deep copy of the passed (unwrapped) link description.
+func deepCopy(link netlink.Link) netlink.Link {
+	GinkgoHelper()
+
+	newlink, err := tryDeepCopy(link)
+	Expect(err).NotTo(HaveOccurred())
+	return newlink
+}
+
+// tryDeepCopy returns a deep copy of the passed (unwrapped) link description,
+// or an error.
+func tryDeepCopy(link netlink.Link) (netlink.Link, error) {
+	newlink := reflect.New(reflect.ValueOf(link).Elem().Type()).Interface().(netlink.Link)
+	if err := copier.CopyWithOption(newlink, link, copier.Option{DeepCopy: true, IgnoreEmpty: true}); err != nil {
+		return nil, fmt.Errorf("cannot copy link description, reason: %w", err)
+	}
+	return newlink, nil
+}
+
+// DefaultUpTimeout is the maximum wait duration used by [EnsureUp],
+// [EnsureDown], and the other waiting functions of this package when no
+// explicit duration has been specified. Test suites running on slow or
+// emulated environments can increase it once, such as in BeforeSuite.
+var DefaultUpTimeout = 2 * time.Second
+
+// EnsureUp brings the specified network interface up and waits for it to
+// become operationally “UP” or “UNKNOWN”. The maximum wait duration can
+// be optionally specified; it defaults to [DefaultUpTimeout]. If the link's
+// [netlink.LinkAttrs.Namespace] references a network namespace, such as in
+// form of a [netlink.NsFd], EnsureUp works in that network namespace instead
+// of the current one.
 func EnsureUp(link netlink.Link, within ...time.Duration) {
 	GinkgoHelper()
+	defer threadingCheck("link.EnsureUp")()
 	ensureUp(Default, link, false, within...)
 }
 
@@ -234,21 +432,16 @@ func ensureUp(g Gomega, link netlink.Link, skipup bool, within ...time.Duration)
 
 	g.Expect(link).NotTo(BeNil(), "need a non-nil link description")
 
-	var atmost time.Duration
-	switch len(within) {
-	case 0:
-		atmost = 2 * time.Second
-	case 1:
-		atmost = within[0]
-	default:
-		panic("only a single optional maximum wait duration allowed")
-	}
+	atmost := wait.Duration(within, DefaultUpTimeout)
 
+	nlh, err := NewHandle(link)
+	g.Expect(err).NotTo(HaveOccurred())
+	defer nlh.Close()
 	if !skipup {
-		g.Expect(netlink.LinkSetUp(link)).To(Succeed())
+		g.Expect(nlh.LinkSetUp(link)).To(Succeed())
 	}
 	g.Eventually(func() bool {
-		lnk, err := netlink.LinkByIndex(link.Attrs().Index)
+		lnk, err := nlh.LinkByIndex(link.Attrs().Index)
 		if err != nil {
 			StopTrying("link cannot come up").Wrap(err).Now()
 		}
@@ -263,6 +456,47 @@ func ensureUp(g Gomega, link netlink.Link, skipup bool, within ...time.Duration)
 		Should(BeTrue())
 }
 
+// EnsureDown brings the specified network interface down and waits for it to
+// become operationally “DOWN” or “LOWERLAYERDOWN”. The maximum wait
+// duration can be optionally specified; it defaults to [DefaultUpTimeout].
+// Similar to [EnsureUp], EnsureDown works in the network namespace referenced
+// by the link's [netlink.LinkAttrs.Namespace], if set.
+func EnsureDown(link netlink.Link, within ...time.Duration) {
+	GinkgoHelper()
+	defer threadingCheck("link.EnsureDown")()
+	ensureDown(Default, link, false, within...)
+}
+
+// ensureDown takes an additional Gomega in order to allow unit testing it.
+func ensureDown(g Gomega, link netlink.Link, skipdown bool, within ...time.Duration) {
+	GinkgoHelper()
+
+	g.Expect(link).NotTo(BeNil(), "need a non-nil link description")
+
+	atmost := wait.Duration(within, DefaultUpTimeout)
+
+	nlh, err := NewHandle(link)
+	g.Expect(err).NotTo(HaveOccurred())
+	defer nlh.Close()
+	if !skipdown {
+		g.Expect(nlh.LinkSetDown(link)).To(Succeed())
+	}
+	g.Eventually(func() bool {
+		lnk, err := nlh.LinkByIndex(link.Attrs().Index)
+		if err != nil {
+			StopTrying("link cannot go down").Wrap(err).Now()
+		}
+		switch lnk.Attrs().OperState {
+		case netlink.LinkOperState(netlink.OperDown):
+			return true
+		case netlink.LinkOperState(netlink.OperLowerLayerDown):
+			return true
+		}
+		return false
+	}).Within(atmost).ProbeEvery(20 * time.Millisecond).
+		Should(BeTrue())
+}
+
 // RandomNifname returns a network interface name consisting of the specified
 // prefix and a random string, and of the maximum length allowed for network
 // interface names. The random string part consists of only digits as well as
@@ -272,15 +506,39 @@ func RandomNifname(prefix string) string {
 	return base62Nifname(prefix)
 }
 
+// RandomNifnameWith returns a network interface name consisting of the
+// specified prefix and a random string, with a total length of exactly n
+// characters. The length must not exceed the maximum length allowed for network
+// interface names and must leave room for at least four random characters
+// after the prefix. The random string part consists of only digits as well as
+// lowercase and uppercase ASCII letters.
+func RandomNifnameWith(prefix string, n int) string {
+	GinkgoHelper()
+	if err := checkNifnameLen(prefix, n); err != nil {
+		fail(err.Error())
+	}
+	return prefix + base62.Random(n-len(prefix))
+}
+
+// checkNifnameLen returns an error if random network interface names of length
+// n cannot be created for the specified prefix.
+func checkNifnameLen(prefix string, n int) error {
+	if n > maxNifnameLen {
+		return fmt.Errorf("network interface name length %d exceeds max. %d characters", n, maxNifnameLen)
+	}
+	if n < len(prefix)+minRandomLen {
+		return fmt.Errorf("network interface name length %d too short for prefix %q, needs at least %d characters",
+			n, prefix, len(prefix)+minRandomLen)
+	}
+	return nil
+}
+
 // Maximum allowed length for Linux network interface names.
 const maxNifnameLen = 15
 
 // Minimum of random base63 characters required.
 const minRandomLen = 4
 
-// The set of characters to create a random string from.
-const base62chars = "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
-
 // base62Nifname returns a random network interface name consisting of the
 // specified prefix and a random string, and of the maximum length allowed for
 // network interface names. The random string part consists of only digits as
@@ -289,12 +547,26 @@ func base62Nifname(prefix string) string {
 	GinkgoHelper()
 	if len(prefix) > maxNifnameLen-minRandomLen {
 		fail(fmt.Sprintf("cannot create random network interface name, because prefix %q is longer than %d characters",
-			prefix, maxNifnameLen-4))
+			prefix, maxNifnameLen-minRandomLen))
 	}
-	name := make([]byte, maxNifnameLen)
-	copy(name, prefix)
-	for idx := len(prefix); idx < maxNifnameLen; idx++ {
-		name[idx] = base62chars[rand.Intn(len(base62chars))]
+	return prefix + base62.Random(maxNifnameLen-len(prefix))
+}
+
+// callerName returns the name of the first function on the call stack outside
+// this link package, or "unknown caller" if there is none.
+func callerName() string {
+	pcs := make([]uintptr, 32)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, thisPackage+".") {
+			return frame.Function
+		}
+		if !more {
+			return "unknown caller"
+		}
 	}
-	return string(name)
 }
+
+// thisPackage is the import path of this link package.
+var thisPackage = reflect.TypeOf(Link{}).PkgPath()
@@ -15,10 +15,13 @@
 package link
 
 import (
+	"net"
 	"os"
+	"path/filepath"
 	"runtime"
 	"time"
 
+	"github.com/thediveo/notwork/mntns"
 	"github.com/thediveo/notwork/netns"
 	"github.com/vishvananda/netlink"
 	"golang.org/x/sys/unix"
@@ -59,6 +62,19 @@ var _ = Describe("creates transient network interfaces", func() {
 			Expect(nifname).NotTo(ContainSubstring("\x00"))
 		})
 
+		It("creates a random name with a prefix and a specific length", func() {
+			const prefix = "prefix-"
+			nifname := RandomNifnameWith(prefix, 11)
+			Expect(nifname).To(HaveLen(11))
+			Expect(nifname).To(HavePrefix(prefix))
+
+			oldfail := fail
+			defer func() { fail = oldfail }()
+			fail = func(message string, callerSkip ...int) { panic(message) }
+			Expect(func() { _ = RandomNifnameWith(prefix, 16) }).To(PanicWith(ContainSubstring("exceeds max. 15 characters")))
+			Expect(func() { _ = RandomNifnameWith(prefix, 10) }).To(PanicWith(ContainSubstring("needs at least 11 characters")))
+		})
+
 		It("respects length restrictions, failing for overlong names", func() {
 			oldfail := fail
 			var msg string
@@ -128,6 +144,139 @@ var _ = Describe("creates transient network interfaces", func() {
 			Expect(netnsdl.Attrs().Name).To(Equal(dl.Attrs().Name))
 		})
 
+		It("assigns addresses after creation in the correct network namespace", func() {
+			netnsfd := netns.NewTransient()
+			vethA := NewTransient(&netlink.Veth{
+				LinkAttrs: netlink.LinkAttrs{
+					Namespace: netlink.NsFd(netnsfd),
+				},
+				PeerNamespace: netlink.NsFd(netnsfd),
+			}, "veth-", WithAddr("10.0.0.1/24"), WithAddr("fd00::1/64"))
+
+			nlh := netns.NewNetlinkHandle(netnsfd)
+			Expect(Successful(nlh.AddrList(vethA, netlink.FAMILY_ALL))).To(ContainElements(
+				HaveField("IPNet.String()", "10.0.0.1/24"),
+				HaveField("IPNet.String()", "fd00::1/64")))
+		})
+
+		Context("creation without cleanup", Ordered, func() {
+
+			var veth netlink.Link
+
+			It("creates a network interface without scheduling its removal", func() {
+				veth = NewTransient(&netlink.Veth{}, "veth-", WithoutCleanup())
+				Expect(netlink.LinkByName(veth.Attrs().Name)).Error().NotTo(HaveOccurred())
+			})
+
+			It("still has the network interface", func() {
+				Expect(veth).NotTo(BeNil())
+				Expect(netlink.LinkByName(veth.Attrs().Name)).Error().NotTo(HaveOccurred())
+				Expect(netlink.LinkDel(veth)).To(Succeed())
+			})
+
+		})
+
+		It("creates a network interface in the initial network namespace", func() {
+			var veth netlink.Link
+			netns.Execute(netns.NewTransient(), func() {
+				veth = NewTransient(&netlink.Veth{}, "veth-", InInitialNamespace())
+				Expect(netlink.LinkByName(veth.Attrs().Name)).Error().To(HaveOccurred())
+			})
+			Expect(netlink.LinkByName(veth.Attrs().Name)).Error().NotTo(HaveOccurred())
+		})
+
+		It("creates network interfaces with multiple queues showing up in sysfs", func() {
+			defer netns.EnterTransient()()
+			veth := NewTransient(&netlink.Veth{}, "veth-", WithNumQueues(3))
+
+			mntnsfd, _ := mntns.NewTransient()
+			var rx, tx []string
+			mntns.Execute(mntnsfd, func() {
+				mntns.MountSysfsRO()
+				rx = Successful(filepath.Glob("/sys/class/net/" + veth.Attrs().Name + "/queues/rx-*"))
+				tx = Successful(filepath.Glob("/sys/class/net/" + veth.Attrs().Name + "/queues/tx-*"))
+			})
+			Expect(rx).To(HaveLen(3))
+			Expect(tx).To(HaveLen(3))
+		})
+
+		It("creates a network interface with modified link attributes", func() {
+			defer netns.EnterTransient()()
+			templ := &netlink.Veth{}
+			veth := NewTransient(templ, "veth-", WithAttrs(func(attrs *netlink.LinkAttrs) {
+				attrs.Alias = "foobar"
+				attrs.Group = 42
+				attrs.TxQLen = 666
+				attrs.MTU = 1280
+			}))
+			Expect(templ.Attrs().Alias).To(BeEmpty())
+			Expect(Successful(netlink.LinkByIndex(veth.Attrs().Index)).Attrs()).To(And(
+				HaveField("Alias", "foobar"),
+				HaveField("Group", uint32(42)),
+				HaveField("TxQLen", 666),
+				HaveField("MTU", 1280)))
+		})
+
+		It("creates a network interface in the network namespace of a process", func() {
+			// Lacking another process we simply use our own, yet from a
+			// different network namespace...
+			var veth netlink.Link
+			netns.Execute(netns.NewTransient(), func() {
+				veth = NewTransient(&netlink.Veth{
+					LinkAttrs: netlink.LinkAttrs{
+						Namespace: netlink.NsPid(os.Getpid()),
+					},
+				}, "veth-")
+				Expect(netlink.LinkByName(veth.Attrs().Name)).Error().To(HaveOccurred())
+			})
+			Expect(veth.Attrs().Namespace).To(Equal(netlink.NsPid(os.Getpid())))
+			Expect(Successful(netlink.LinkByName(veth.Attrs().Name)).Attrs().Index).To(
+				Equal(veth.Attrs().Index))
+			WaitForFlag(veth, net.FlagUp, false)
+		})
+
+		It("creates a network interface with a fixed name, failing when the name is in use", func() {
+			defer netns.EnterTransient()()
+			veth := NewTransient(&Link{
+				Link:       &netlink.Veth{LinkAttrs: netlink.LinkAttrs{Name: "dupond"}, PeerName: "dupont"},
+				FixedNames: true,
+			}, "veth-")
+			Expect(veth.Attrs().Name).To(Equal("dupond"))
+			Expect(netlink.LinkByName("dupont")).Error().NotTo(HaveOccurred())
+
+			oldfail := fail
+			var msg string
+			fail = func(message string, callerSkip ...int) {
+				msg = message
+				panic("canary")
+			}
+			Expect(func() {
+				_ = NewTransient(&Link{
+					Link:       &netlink.Veth{LinkAttrs: netlink.LinkAttrs{Name: "dupond"}, PeerName: "foobar"},
+					FixedNames: true,
+				}, "veth-")
+			}).To(PanicWith("canary"))
+			fail = oldfail
+			Expect(msg).To(ContainSubstring(`name "dupond" already in use`))
+		})
+
+		It("checks the expected initial operational state", func() {
+			defer netns.EnterTransient()()
+			_ = NewTransient(&netlink.Veth{}, "veth-", WithExpectedInitialState(netlink.OperDown))
+
+			oldfail := fail
+			var msg string
+			fail = func(message string, callerSkip ...int) {
+				msg = message
+				panic("canary")
+			}
+			Expect(func() {
+				_ = NewTransient(&netlink.Veth{}, "veth-", WithExpectedInitialState(netlink.OperUp))
+			}).To(PanicWith("canary"))
+			fail = oldfail
+			Expect(msg).To(MatchRegexp(`transient network interface "veth-.*" has initial operational state "down", but expected "up"`))
+		})
+
 		It("rejects invalid network namespace references", func() {
 			templ := &netlink.Dummy{
 				LinkAttrs: netlink.LinkAttrs{
@@ -144,7 +293,7 @@ var _ = Describe("creates transient network interfaces", func() {
 				_ = NewTransient(templ, dummyPrefix)
 			}).To(PanicWith("canary"))
 			fail = oldfail
-			Expect(msg).To(Equal("link.Attrs().Namespace reference must be nil or a netlink.NsFd"))
+			Expect(msg).To(Equal("link.Attrs().Namespace reference must be nil, a netlink.NsFd, or a netlink.NsPid"))
 		})
 
 	})
@@ -156,6 +305,22 @@ var _ = Describe("creates transient network interfaces", func() {
 		Expect(dl1.Attrs().Name).NotTo(Equal(dl2.Attrs().Name))
 	})
 
+	It("rejects overlong prefixes upfront", func() {
+		oldfail := fail
+		var msg string
+		fail = func(message string, callerSkip ...int) {
+			msg = message
+			panic("canary")
+		}
+		Expect(func() {
+			_ = NewTransient(&netlink.Veth{}, "a-very-long-prefix-")
+		}).To(PanicWith("canary"))
+		fail = oldfail
+		Expect(msg).To(MatchRegexp(
+			`^network interface name prefix "a-very-long-prefix-" passed by .+ is 19 characters long, but max. 11 characters allowed$`))
+		Expect(msg).NotTo(ContainSubstring("notwork/link.NewTransient"))
+	})
+
 	It("fails the spec on failure", func() {
 		oldfail := fail
 		var msg string
@@ -170,6 +335,73 @@ var _ = Describe("creates transient network interfaces", func() {
 		Expect(msg).To(MatchRegexp(`cannot create a transient network interface .*, reason: invalid argument`))
 	})
 
+	It("creates network interfaces with shorter names", func() {
+		defer netns.EnterTransient()()
+		veth := NewTransient(&netlink.Veth{}, "veth-", WithMaxNameLen(11))
+		Expect(veth.Attrs().Name).To(HaveLen(11))
+		Expect(veth.(*netlink.Veth).PeerName).To(HaveLen(11))
+		Expect(netlink.LinkByName(veth.Attrs().Name)).Error().NotTo(HaveOccurred())
+
+		Expect(TryNewTransient(&netlink.Veth{}, "veth-", WithMaxNameLen(8))).Error().To(
+			MatchError(ContainSubstring("too short for prefix")))
+	})
+
+	It("creates network interfaces with and without index fixup", func() {
+		defer netns.EnterTransient()()
+		// Without a different destination network namespace, the index
+		// reported after creation is correct even without the fixup.
+		veth := NewTransient(&netlink.Veth{}, "veth-", SkipIndexFixup())
+		Expect(veth.Attrs().Index).To(Equal(
+			Successful(netlink.LinkByName(veth.Attrs().Name)).Attrs().Index))
+
+		// With a different destination network namespace, the fixup by
+		// default ensures the index is correct.
+		netnsfd := netns.NewTransient()
+		veth = NewTransient(&netlink.Veth{
+			LinkAttrs: netlink.LinkAttrs{
+				Namespace: netlink.NsFd(netnsfd),
+			},
+		}, "veth-")
+		Expect(veth.Attrs().Index).To(Equal(
+			Successful(netns.NewNetlinkHandle(netnsfd).LinkByName(veth.Attrs().Name)).Attrs().Index))
+	})
+
+	It("runs additional setup after creating a network interface", func() {
+		defer netns.EnterTransient()()
+
+		var setup netlink.Link
+		veth := NewTransient(&Link{
+			Link: &netlink.Veth{},
+			SetupFns: []func(netlink.Link) error{
+				func(l netlink.Link) error { setup = l; return nil },
+			},
+		}, "veth-")
+		Expect(setup).To(BeIdenticalTo(veth))
+
+		Expect(TryNewTransient(&Link{
+			Link: &netlink.Veth{},
+			SetupFns: []func(netlink.Link) error{
+				func(netlink.Link) error { return unix.EPERM },
+			},
+		}, "veth-")).Error().To(MatchError(unix.EPERM))
+	})
+
+	It("returns errors wrapping the original errors", func() {
+		Expect(TryNewTransient(nil, "ohno-")).Error().To(MatchError(ContainSubstring("non-nil link description")))
+
+		_, err := TryNewTransient(&netlink.Macvlan{ /* no parent */ }, "ohno-")
+		Expect(err).To(MatchError(unix.EINVAL))
+		Expect(err).To(MatchError(ContainSubstring("cannot create a transient network interface")))
+
+		defer netns.EnterTransient()()
+		veth := Successful(TryNewTransient(&netlink.Veth{}, "veth-"))
+		_, err = TryNewTransient(&Link{
+			Link:       &netlink.Veth{LinkAttrs: netlink.LinkAttrs{Name: veth.Attrs().Name}, PeerName: "foobar"},
+			FixedNames: true,
+		}, "veth-")
+		Expect(err).To(MatchError(unix.EEXIST))
+	})
+
 	It("removes a transient network interface in a different network namespace", func() {
 		By("creating a new network namespace")
 		runtime.LockOSThread()
@@ -244,6 +476,25 @@ var _ = Describe("creates transient network interfaces", func() {
 			Expect(r).To(ContainSubstring("Timed out after 2."))
 		})
 
+		It("uses the package-wide default timeout", func() {
+			defer netns.EnterTransient()()
+			veth := NewTransient(&netlink.Veth{}, "tst-")
+
+			olddefault := DefaultUpTimeout
+			defer func() { DefaultUpTimeout = olddefault }()
+			DefaultUpTimeout = 100 * time.Millisecond
+
+			var r any
+			func() {
+				defer func() { r = recover() }()
+				g := NewGomega(func(message string, callerSkip ...int) {
+					panic(message)
+				})
+				ensureUp(g, veth, true)
+			}()
+			Expect(r).To(ContainSubstring("Timed out after 0.1"))
+		})
+
 		It("waits for operationally up/unknown (not down)", func() {
 			dmy := NewTransient(&netlink.Dummy{}, "tst-")
 			mcvlan := NewTransient(&netlink.Macvlan{
@@ -267,6 +518,109 @@ var _ = Describe("creates transient network interfaces", func() {
 
 	})
 
+	When("ensuring network interfaces in other network namespaces are up", func() {
+
+		It("brings a dummy up in its network namespace", func() {
+			netnsfd := netns.NewTransient()
+			dmy := NewTransient(&netlink.Dummy{
+				LinkAttrs: netlink.LinkAttrs{
+					Namespace: netlink.NsFd(netnsfd),
+				},
+			}, "tst-")
+			EnsureUp(dmy)
+			nlh := netns.NewNetlinkHandle(netnsfd)
+			Expect(Successful(nlh.LinkByIndex(dmy.Attrs().Index)).Attrs().OperState).To(
+				Equal(netlink.LinkOperState(netlink.OperUnknown)))
+		})
+
+		It("brings a VETH pair up and down in its network namespace", func() {
+			netnsfd := netns.NewTransient()
+			veth := NewTransient(&netlink.Veth{
+				LinkAttrs: netlink.LinkAttrs{
+					Namespace: netlink.NsFd(netnsfd),
+				},
+				PeerNamespace: netlink.NsFd(netnsfd),
+			}, "tst-")
+			nlh := netns.NewNetlinkHandle(netnsfd)
+			peer := Successful(nlh.LinkByName(veth.(*netlink.Veth).PeerName))
+			peer.Attrs().Namespace = netlink.NsFd(netnsfd)
+
+			Expect(nlh.LinkSetUp(peer)).To(Succeed())
+			EnsureUp(veth)
+			EnsureUp(peer)
+			Expect(Successful(nlh.LinkByIndex(veth.Attrs().Index)).Attrs().OperState).To(
+				Equal(netlink.LinkOperState(netlink.OperUp)))
+
+			EnsureDown(veth)
+			Expect(Successful(nlh.LinkByIndex(veth.Attrs().Index)).Attrs().OperState).To(
+				Equal(netlink.LinkOperState(netlink.OperDown)))
+		})
+
+	})
+
+	When("ensuring that network interfaces are operationally down", func() {
+
+		It("expects the passed link to be non-nil", func() {
+			var r any
+			func() {
+				defer func() { r = recover() }()
+				g := NewGomega(func(message string, callerSkip ...int) {
+					panic(message)
+				})
+				ensureDown(g, nil, false)
+			}()
+			Expect(r).To(ContainSubstring("non-nil link description"))
+		})
+
+		It("doesn't accept multiple optional durations", func() {
+			var r any
+			func() {
+				defer func() { r = recover() }()
+				EnsureDown(&netlink.Dummy{}, time.Millisecond, time.Millisecond)
+			}()
+			Expect(r).To(ContainSubstring("single optional maximum wait duration"))
+		})
+
+		It("stops when there is no chance left", func() {
+			var r any
+			func() {
+				defer func() { r = recover() }()
+				g := NewGomega(func(message string, callerSkip ...int) {
+					panic(message)
+				})
+				ensureDown(g, &netlink.Dummy{}, true)
+			}()
+			Expect(r).To(ContainSubstring("link cannot go down: invalid argument"))
+		})
+
+		It("times out waiting for the interface to become operationally down", func() {
+			dmy := NewTransient(&netlink.Dummy{}, "tst-")
+			EnsureUp(dmy)
+
+			var r any
+			func() {
+				defer func() { r = recover() }()
+				g := NewGomega(func(message string, callerSkip ...int) {
+					panic(message)
+				})
+				ensureDown(g, dmy, true, 100*time.Millisecond)
+			}()
+			Expect(r).To(ContainSubstring("Timed out after 0."))
+		})
+
+		It("brings an interface down and waits for it to become operationally down", func() {
+			dmy := NewTransient(&netlink.Dummy{}, "tst-")
+			EnsureUp(dmy)
+			Expect(Successful(netlink.LinkByIndex(dmy.Attrs().Index)).Attrs().OperState).To(
+				Equal(netlink.LinkOperState(netlink.OperUnknown)))
+
+			EnsureDown(dmy)
+			Expect(Successful(netlink.LinkByIndex(dmy.Attrs().Index)).Attrs().OperState).To(
+				Equal(netlink.LinkOperState(netlink.OperDown)))
+		})
+
+	})
+
 	When("current, link, and destination network namespaces all differ", func() {
 
 		It("creates correctly in a different destination network namespace", func() {
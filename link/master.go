@@ -0,0 +1,38 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package link
+
+import (
+	"github.com/vishvananda/netlink"
+
+	. "github.com/onsi/ginkgo/v2" //lint:ignore ST1001 rule does not apply
+	. "github.com/onsi/gomega"    //lint:ignore ST1001 rule does not apply
+)
+
+// Unenslave detaches the network interface l from its master, such as a
+// bridge, bond, or VRF, in the network namespace referenced by
+// l.Attrs().Namespace; if unset, in the current network namespace. Unenslave
+// also resets l.Attrs().MasterIndex.
+func Unenslave(l netlink.Link) {
+	GinkgoHelper()
+
+	Expect(l).NotTo(BeNil(), "need a non-nil link description")
+	nlh, err := NewHandle(l)
+	Expect(err).NotTo(HaveOccurred())
+	defer nlh.Close()
+	Expect(nlh.LinkSetNoMaster(l)).To(Succeed(),
+		"cannot unenslave network interface %q", l.Attrs().Name)
+	l.Attrs().MasterIndex = 0
+}
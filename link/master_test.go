@@ -0,0 +1,77 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package link
+
+import (
+	"os"
+	"time"
+
+	"github.com/thediveo/notwork/netns"
+	"github.com/vishvananda/netlink"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gleak"
+	. "github.com/thediveo/fdooze"
+	. "github.com/thediveo/success"
+)
+
+var _ = Describe("unenslaving", func() {
+
+	BeforeEach(func() {
+		if os.Getuid() != 0 {
+			Skip("needs root")
+		}
+
+		goodfds := Filedescriptors()
+		goodgos := Goroutines()
+		DeferCleanup(func() {
+			Eventually(Goroutines).Within(2 * time.Second).ProbeEvery(100 * time.Millisecond).
+				ShouldNot(HaveLeaked(goodgos))
+			Expect(Filedescriptors()).NotTo(HaveLeakedFds(goodfds))
+		})
+	})
+
+	It("detaches a port from its bridge in a different network namespace", func() {
+		netnsfd := netns.NewTransient()
+		br := NewTransient(&netlink.Bridge{
+			LinkAttrs: netlink.LinkAttrs{Namespace: netlink.NsFd(netnsfd)},
+		}, "br-")
+		veth := NewTransient(&netlink.Veth{
+			LinkAttrs: netlink.LinkAttrs{
+				Namespace: netlink.NsFd(netnsfd),
+			},
+			PeerNamespace: netlink.NsFd(netnsfd),
+		}, "port-")
+		nlh := netns.NewNetlinkHandle(netnsfd)
+		Expect(nlh.LinkSetMasterByIndex(veth, br.Attrs().Index)).To(Succeed())
+		Expect(Successful(nlh.LinkByIndex(veth.Attrs().Index))).To(
+			HaveField("Attrs().MasterIndex", br.Attrs().Index))
+
+		veth.Attrs().MasterIndex = br.Attrs().Index
+		Unenslave(veth)
+		Expect(veth.Attrs().MasterIndex).To(BeZero())
+		Expect(Successful(nlh.LinkByIndex(veth.Attrs().Index))).To(
+			HaveField("Attrs().MasterIndex", 0))
+	})
+
+	It("fails for a missing network interface", func() {
+		defer netns.EnterTransient()()
+		Expect(InterceptGomegaFailure(func() {
+			Unenslave(&netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Index: 4242, Name: "foo"}})
+		})).To(MatchError(ContainSubstring("cannot unenslave network interface \"foo\"")))
+	})
+
+})
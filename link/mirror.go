@@ -0,0 +1,89 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package link
+
+import (
+	"fmt"
+
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+
+	. "github.com/onsi/ginkgo/v2" //lint:ignore ST1001 rule does not apply
+	. "github.com/onsi/gomega"    //lint:ignore ST1001 rule does not apply
+)
+
+// MirrorTo mirrors all traffic ingressing the network interface src to the
+// network interface dst, so that the mirrored traffic egresses dst. MirrorTo
+// installs an ingress qdisc on src with a match-all U32 filter and a “mirred”
+// action, in the network namespace referenced by src.Attrs().Namespace; if
+// unset, in the current network namespace. The dst network interface must be
+// in the same network namespace as src.
+//
+// MirrorTo schedules a DeferCleanup to remove the ingress qdisc again, together
+// with its filter. Please note that MirrorTo fails if src already has an
+// ingress qdisc.
+func MirrorTo(src, dst netlink.Link) {
+	GinkgoHelper()
+
+	Expect(src).NotTo(BeNil(), "need a non-nil source link description")
+	Expect(dst).NotTo(BeNil(), "need a non-nil destination link description")
+
+	// pin the network namespace now, not when the deferred cleanup runs.
+	nlh, err := layerHandle(src)
+	Expect(err).NotTo(HaveOccurred())
+	ingress := &netlink.Ingress{
+		QdiscAttrs: netlink.QdiscAttrs{
+			LinkIndex: src.Attrs().Index,
+			Handle:    netlink.MakeHandle(0xffff, 0),
+			Parent:    netlink.HANDLE_INGRESS,
+		},
+	}
+	if err := nlh.QdiscAdd(ingress); err != nil {
+		nlh.Close()
+		Expect(err).NotTo(HaveOccurred(),
+			"cannot add ingress qdisc to network interface %q", src.Attrs().Name)
+	}
+	DeferCleanup(func() {
+		defer nlh.Close()
+		// when the source network interface has already gone, so has its
+		// ingress qdisc.
+		if _, err := nlh.LinkByIndex(src.Attrs().Index); err != nil {
+			return
+		}
+		By(fmt.Sprintf("removing mirroring from network interface %q", src.Attrs().Name))
+		Expect(nlh.QdiscDel(ingress)).To(Succeed(),
+			"cannot remove ingress qdisc from network interface %q", src.Attrs().Name)
+	})
+
+	// A U32 filter without selector matches all packets.
+	Expect(nlh.FilterAdd(&netlink.U32{
+		FilterAttrs: netlink.FilterAttrs{
+			LinkIndex: src.Attrs().Index,
+			Parent:    ingress.Handle,
+			Priority:  1,
+			Protocol:  unix.ETH_P_ALL,
+		},
+		Actions: []netlink.Action{
+			&netlink.MirredAction{
+				ActionAttrs: netlink.ActionAttrs{
+					Action: netlink.TC_ACT_PIPE,
+				},
+				MirredAction: netlink.TCA_EGRESS_MIRROR,
+				Ifindex:      dst.Attrs().Index,
+			},
+		},
+	})).To(Succeed(), "cannot mirror network interface %q to %q",
+		src.Attrs().Name, dst.Attrs().Name)
+}
@@ -0,0 +1,114 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package link
+
+import (
+	"net"
+	"os"
+	"time"
+
+	"github.com/thediveo/notwork/netns"
+	"github.com/vishvananda/netlink"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gleak"
+	. "github.com/thediveo/fdooze"
+	. "github.com/thediveo/success"
+)
+
+var _ = Describe("mirroring", func() {
+
+	BeforeEach(func() {
+		if os.Getuid() != 0 {
+			Skip("needs root")
+		}
+
+		goodfds := Filedescriptors()
+		goodgos := Goroutines()
+		DeferCleanup(func() {
+			Eventually(Goroutines).Within(2 * time.Second).ProbeEvery(100 * time.Millisecond).
+				ShouldNot(HaveLeaked(goodgos))
+			Expect(Filedescriptors()).NotTo(HaveLeakedFds(goodfds))
+		})
+	})
+
+	It("mirrors ingress traffic to a different network interface", func() {
+		netnsfd := netns.NewTransient()
+		// keep IPv6 from chatting on the wires.
+		netns.SetSysctl(netnsfd, "net.ipv6.conf.default.disable_ipv6", "1")
+		nlh := netns.NewNetlinkHandle(netnsfd)
+		newVeth := func() (netlink.Link, netlink.Link) {
+			GinkgoHelper()
+			veth := NewTransient(&netlink.Veth{
+				LinkAttrs: netlink.LinkAttrs{
+					Namespace: netlink.NsFd(netnsfd),
+				},
+				PeerNamespace: netlink.NsFd(netnsfd),
+			}, "veth-")
+			peer := Successful(nlh.LinkByName(veth.(*netlink.Veth).PeerName))
+			peer.Attrs().Namespace = netlink.NsFd(netnsfd)
+			Expect(nlh.LinkSetUp(veth)).To(Succeed())
+			Expect(nlh.LinkSetUp(peer)).To(Succeed())
+			return veth, peer
+		}
+		src, srcPeer := newVeth()
+		dst, dstPeer := newVeth()
+		Expect(nlh.AddrAdd(srcPeer, Successful(netlink.ParseAddr("10.0.0.1/24")))).To(Succeed())
+
+		MirrorTo(src, dst)
+		Expect(Successful(nlh.QdiscList(src))).To(ContainElement(
+			HaveField("Type()", "ingress")))
+
+		rxPackets := func() uint64 {
+			return Successful(nlh.LinkByIndex(dstPeer.Attrs().Index)).Attrs().Statistics.RxPackets
+		}
+		before := rxPackets()
+		netns.Execute(netnsfd, func() {
+			conn := Successful(net.Dial("udp", "10.0.0.2:4242"))
+			defer conn.Close()
+			_, _ = conn.Write([]byte("mirror, mirror on the wall"))
+		})
+		Eventually(rxPackets).Should(BeNumerically(">", before))
+	})
+
+	It("removes mirroring from the network namespace it was set up in", func() {
+		func() {
+			defer netns.EnterTransient()()
+			nlh := Successful(netlink.NewHandle())
+			DeferCleanup(nlh.Close)
+			src := NewTransient(&netlink.Veth{}, "veth-")
+			dst := NewTransient(&netlink.Veth{}, "veth-")
+			// the network interfaces are still around when this runs, but
+			// the mirroring's cleanup must have already happened.
+			DeferCleanup(func() {
+				Expect(Successful(nlh.QdiscList(src))).NotTo(ContainElement(
+					HaveField("Type()", "ingress")))
+			})
+			MirrorTo(src, dst)
+			Expect(Successful(nlh.QdiscList(src))).To(ContainElement(
+				HaveField("Type()", "ingress")))
+		}()
+	})
+
+	It("fails for a missing network interface", func() {
+		defer netns.EnterTransient()()
+		Expect(InterceptGomegaFailure(func() {
+			MirrorTo(&netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Index: 4242, Name: "foo"}},
+				&netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Index: 4243, Name: "bar"}})
+		})).To(MatchError(ContainSubstring("cannot add ingress qdisc to network interface \"foo\"")))
+	})
+
+})
@@ -0,0 +1,136 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package link
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netns"
+	"golang.org/x/sys/unix"
+
+	. "github.com/onsi/ginkgo/v2" //lint:ignore ST1001 rule does not apply
+	. "github.com/onsi/gomega"    //lint:ignore ST1001 rule does not apply
+)
+
+// transient tracks the netlink handle for the network namespace a transient
+// link created by NewTransient currently is in, so that its scheduled removal
+// still finds it after moving it into a different network namespace. It
+// additionally tracks the callbacks registered using OnCleanup.
+type transient struct {
+	nlh       *netlink.Handle
+	onCleanup []func()
+}
+
+// transients maps the netlink.Link objects returned by NewTransient to their
+// tracking information, as long as they are scheduled for removal.
+var transients sync.Map
+
+// MoveToNamespace moves the network interface l into the network namespace
+// referenced by netnsfd and updates l's index and network namespace reference
+// accordingly. The caller must keep netnsfd open for as long as l is in use.
+//
+// If l has been created by [NewTransient], then MoveToNamespace also updates
+// the scheduled removal of l, so that it gets removed from its new network
+// namespace.
+func MoveToNamespace(l netlink.Link, netnsfd int) {
+	GinkgoHelper()
+
+	Expect(l).NotTo(BeNil(), "need a non-nil link description")
+	newnlh, err := netlink.NewHandleAt(netns.NsHandle(netnsfd))
+	Expect(err).NotTo(HaveOccurred(), "cannot create NETLINK handle for destination network namespace")
+	var tracked *transient
+	if t, ok := transients.Load(l); ok {
+		tracked = t.(*transient)
+	}
+	var nlh *netlink.Handle
+	if tracked != nil {
+		nlh = tracked.nlh
+	} else {
+		nlh, err = NewHandle(l)
+		if err != nil {
+			newnlh.Close()
+			Expect(err).NotTo(HaveOccurred())
+		}
+		defer nlh.Close()
+	}
+	if err := nlh.LinkSetNsFd(l, netnsfd); err != nil {
+		newnlh.Close()
+		Expect(err).NotTo(HaveOccurred(),
+			"cannot move network interface %q into destination network namespace", l.Attrs().Name)
+	}
+	moved, err := newnlh.LinkByName(l.Attrs().Name)
+	if err != nil {
+		newnlh.Close()
+		Expect(err).NotTo(HaveOccurred(),
+			"network interface %q went missing in destination network namespace", l.Attrs().Name)
+	}
+	l.Attrs().Index = moved.Attrs().Index
+	l.Attrs().Namespace = netlink.NsFd(netnsfd)
+	if tracked != nil {
+		tracked.nlh.Close()
+		tracked.nlh = newnlh
+		return
+	}
+	newnlh.Close()
+}
+
+// MoveToNamespacePID moves the network interface l into the network namespace
+// of the process with the specified PID, such as a container's process, and
+// updates l's index and network namespace reference accordingly. See also
+// [MoveToNamespace].
+//
+// MoveToNamespacePID schedules a DeferCleanup to close its reference to the
+// network namespace of the process.
+func MoveToNamespacePID(l netlink.Link, pid int) {
+	GinkgoHelper()
+
+	netnsfd, err := unix.Open(fmt.Sprintf("/proc/%d/ns/net", pid), unix.O_RDONLY|unix.O_CLOEXEC, 0)
+	Expect(err).NotTo(HaveOccurred(), "cannot reference network namespace of process with PID %d", pid)
+	DeferCleanup(func() {
+		_ = unix.Close(netnsfd)
+	})
+	MoveToNamespace(l, netnsfd)
+}
+
+// TrackAcrossNamespaces returns a resolver function for tracking the network
+// interface l across network namespaces, such as when the code under test
+// moves network interfaces around. As interface indices change when moving
+// network interfaces, the resolver instead looks up the network interface by
+// its name, which stays the same. The resolver returns a fresh link
+// description of the network interface in the network namespace referenced by
+// netnsfd, with its [netlink.LinkAttrs.Namespace] referencing this network
+// namespace. The resolver fails the current test if there is no such network
+// interface in the specified network namespace.
+func TrackAcrossNamespaces(l netlink.Link) func(netnsfd int) netlink.Link {
+	GinkgoHelper()
+
+	Expect(l).NotTo(BeNil(), "need a non-nil link description")
+	name := l.Attrs().Name
+	Expect(name).NotTo(BeEmpty(), "need a named network interface")
+	return func(netnsfd int) netlink.Link {
+		GinkgoHelper()
+
+		nlh, err := netlink.NewHandleAt(netns.NsHandle(netnsfd))
+		Expect(err).NotTo(HaveOccurred(), "cannot create NETLINK handle for network namespace")
+		defer nlh.Close()
+		l, err := nlh.LinkByName(name)
+		Expect(err).NotTo(HaveOccurred(),
+			"cannot find network interface %q in network namespace", name)
+		l.Attrs().Namespace = netlink.NsFd(netnsfd)
+		return l
+	}
+}
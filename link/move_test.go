@@ -0,0 +1,117 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package link
+
+import (
+	"os"
+	"time"
+
+	"github.com/thediveo/notwork/netns"
+	"github.com/vishvananda/netlink"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gleak"
+	. "github.com/thediveo/fdooze"
+	. "github.com/thediveo/success"
+)
+
+var _ = Describe("moving network interfaces", func() {
+
+	BeforeEach(func() {
+		if os.Getuid() != 0 {
+			Skip("needs root")
+		}
+
+		goodfds := Filedescriptors()
+		goodgos := Goroutines()
+		DeferCleanup(func() {
+			Eventually(Goroutines).Within(2 * time.Second).ProbeEvery(100 * time.Millisecond).
+				ShouldNot(HaveLeaked(goodgos))
+			Expect(Filedescriptors()).NotTo(HaveLeakedFds(goodfds))
+		})
+	})
+
+	It("moves a transient network interface by PID and still cleans it up", func() {
+		var veth netlink.Link
+		DeferCleanup(func() {
+			Expect(netlink.LinkByName(veth.Attrs().Name)).Error().To(HaveOccurred(),
+				"moved network interface wasn't removed")
+		})
+		netnsfd := netns.NewTransient()
+		veth = NewTransient(&netlink.Veth{
+			LinkAttrs: netlink.LinkAttrs{
+				Namespace: netlink.NsFd(netnsfd),
+			},
+		}, "veth-")
+		Expect(netlink.LinkByName(veth.Attrs().Name)).Error().To(HaveOccurred())
+
+		MoveToNamespacePID(veth, os.Getpid())
+		l := Successful(netlink.LinkByName(veth.Attrs().Name))
+		Expect(veth.Attrs().Index).To(Equal(l.Attrs().Index))
+		Expect(veth.Attrs().Namespace).NotTo(Equal(netlink.NsFd(netnsfd)))
+		nlh := netns.NewNetlinkHandle(netnsfd)
+		Expect(nlh.LinkByName(veth.Attrs().Name)).Error().To(HaveOccurred())
+	})
+
+	It("moves an untracked network interface", func() {
+		netnsfd := netns.NewTransient()
+		destnetnsfd := netns.NewTransient()
+		veth := NewTransient(&netlink.Veth{
+			LinkAttrs: netlink.LinkAttrs{
+				Namespace: netlink.NsFd(netnsfd),
+			},
+		}, "veth-", WithoutCleanup())
+
+		MoveToNamespace(veth, destnetnsfd)
+		Expect(veth.Attrs().Namespace).To(Equal(netlink.NsFd(destnetnsfd)))
+		nlh := netns.NewNetlinkHandle(destnetnsfd)
+		Expect(Successful(nlh.LinkByName(veth.Attrs().Name)).Attrs().Index).To(
+			Equal(veth.Attrs().Index))
+	})
+
+	It("rejects an invalid PID", func() {
+		Expect(InterceptGomegaFailure(func() {
+			MoveToNamespacePID(&netlink.Veth{}, -1)
+		})).To(MatchError(ContainSubstring("cannot reference network namespace of process with PID -1")))
+	})
+
+	It("tracks a network interface across network namespaces", func() {
+		netnsfd := netns.NewTransient()
+		destnetnsfd := netns.NewTransient()
+		veth := NewTransient(&netlink.Veth{
+			LinkAttrs: netlink.LinkAttrs{
+				Namespace: netlink.NsFd(netnsfd),
+			},
+			PeerNamespace: netlink.NsFd(netnsfd),
+		}, "veth-", WithoutCleanup())
+
+		resolve := TrackAcrossNamespaces(veth)
+		Expect(resolve(netnsfd).Attrs().Index).To(Equal(veth.Attrs().Index))
+
+		// move the network interface behind our back, as the code under test
+		// would do.
+		Expect(netns.NewNetlinkHandle(netnsfd).LinkSetNsFd(veth, destnetnsfd)).To(Succeed())
+		moved := resolve(destnetnsfd)
+		Expect(moved.Attrs().Name).To(Equal(veth.Attrs().Name))
+		Expect(moved.Attrs().Namespace).To(Equal(netlink.NsFd(destnetnsfd)))
+		Expect(netns.NewNetlinkHandle(destnetnsfd).LinkByIndex(moved.Attrs().Index)).Error().NotTo(HaveOccurred())
+
+		Expect(InterceptGomegaFailure(func() {
+			_ = resolve(netnsfd)
+		})).To(MatchError(ContainSubstring("cannot find network interface")))
+	})
+
+})
@@ -0,0 +1,52 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package link
+
+import (
+	"time"
+
+	"github.com/thediveo/notwork/internal/wait"
+	"github.com/vishvananda/netlink"
+
+	. "github.com/onsi/ginkgo/v2" //lint:ignore ST1001 rule does not apply
+	. "github.com/onsi/gomega"    //lint:ignore ST1001 rule does not apply
+)
+
+// WaitMTU waits for the MTU of the network interface l to become the specified
+// mtu, polling the network interface in its network namespace as referenced by
+// l.Attrs().Namespace. The maximum wait duration can be optionally specified;
+// it defaults to [DefaultUpTimeout].
+//
+// WaitMTU especially helps with asserting MTU propagation from a parent network
+// interface to its children, such as MACVLANs, after changing the parent's MTU.
+func WaitMTU(l netlink.Link, mtu int, within ...time.Duration) {
+	GinkgoHelper()
+
+	Expect(l).NotTo(BeNil(), "need a non-nil link description")
+
+	atmost := wait.Duration(within, DefaultUpTimeout)
+
+	nlh, err := NewHandle(l)
+	Expect(err).NotTo(HaveOccurred())
+	defer nlh.Close()
+	Eventually(func() int {
+		lnk, err := nlh.LinkByIndex(l.Attrs().Index)
+		if err != nil {
+			StopTrying("network interface went missing").Wrap(err).Now()
+		}
+		return lnk.Attrs().MTU
+	}).Within(atmost).ProbeEvery(20*time.Millisecond).
+		Should(Equal(mtu), "network interface %q never reached MTU %d", l.Attrs().Name, mtu)
+}
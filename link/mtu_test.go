@@ -0,0 +1,86 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package link
+
+import (
+	"os"
+	"time"
+
+	"github.com/thediveo/notwork/netns"
+	"github.com/vishvananda/netlink"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gleak"
+	. "github.com/thediveo/fdooze"
+)
+
+var _ = Describe("waiting for network interface MTUs", func() {
+
+	BeforeEach(func() {
+		if os.Getuid() != 0 {
+			Skip("needs root")
+		}
+
+		goodfds := Filedescriptors()
+		goodgos := Goroutines()
+		DeferCleanup(func() {
+			Eventually(Goroutines).Within(2 * time.Second).ProbeEvery(100 * time.Millisecond).
+				ShouldNot(HaveLeaked(goodgos))
+			Expect(Filedescriptors()).NotTo(HaveLeakedFds(goodfds))
+		})
+	})
+
+	It("doesn't accept multiple optional durations", func() {
+		Expect(func() {
+			WaitMTU(&netlink.Dummy{}, 1280, time.Millisecond, time.Millisecond)
+		}).To(PanicWith(ContainSubstring("single optional maximum wait duration")))
+	})
+
+	It("waits for a MTU propagated from a parent in a different network namespace", func() {
+		netnsfd := netns.NewTransient()
+		parent := NewTransient(&netlink.Veth{
+			LinkAttrs: netlink.LinkAttrs{
+				Namespace: netlink.NsFd(netnsfd),
+			},
+			PeerNamespace: netlink.NsFd(netnsfd),
+		}, "veth-")
+		child := NewTransient(&netlink.Macvlan{
+			LinkAttrs: netlink.LinkAttrs{
+				ParentIndex: parent.Attrs().Index,
+				Namespace:   netlink.NsFd(netnsfd),
+			},
+			Mode: netlink.MACVLAN_MODE_BRIDGE,
+		}, "mcvl-", WithLinkNamespace(netnsfd))
+		nlh := netns.NewNetlinkHandle(netnsfd)
+
+		WaitMTU(child, 1500)
+		Expect(nlh.LinkSetMTU(parent, 1280)).To(Succeed())
+		WaitMTU(child, 1280)
+
+		Expect(InterceptGomegaFailure(func() {
+			WaitMTU(child, 1500, 50*time.Millisecond)
+		})).To(MatchError(ContainSubstring("never reached MTU 1500")))
+	})
+
+	It("stops when the network interface is missing", func() {
+		Expect(InterceptGomegaFailure(func() {
+			WaitMTU(&netlink.Dummy{
+				LinkAttrs: netlink.LinkAttrs{Index: 666666},
+			}, 1500)
+		})).To(MatchError(ContainSubstring("network interface went missing")))
+	})
+
+})
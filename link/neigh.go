@@ -0,0 +1,40 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package link
+
+import (
+	"github.com/vishvananda/netlink"
+
+	. "github.com/onsi/ginkgo/v2" //lint:ignore ST1001 rule does not apply
+	. "github.com/onsi/gomega"    //lint:ignore ST1001 rule does not apply
+)
+
+// Neighbors returns the neighbor table entries (ARP or NDP) of the network
+// interface l for the specified address family, such as [netlink.FAMILY_V4],
+// [netlink.FAMILY_V6], or [netlink.FAMILY_ALL]. Neighbors lists the entries in
+// the network namespace referenced by l.Attrs().Namespace; if unset, in the
+// current network namespace.
+func Neighbors(l netlink.Link, family int) []netlink.Neigh {
+	GinkgoHelper()
+
+	Expect(l).NotTo(BeNil(), "need a non-nil link description")
+	nlh, err := NewHandle(l)
+	Expect(err).NotTo(HaveOccurred())
+	defer nlh.Close()
+	neighs, err := nlh.NeighList(l.Attrs().Index, family)
+	Expect(err).NotTo(HaveOccurred(),
+		"cannot list neighbors of network interface %q", l.Attrs().Name)
+	return neighs
+}
@@ -0,0 +1,73 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package link
+
+import (
+	"net"
+	"os"
+	"time"
+
+	"github.com/thediveo/notwork/netns"
+	"github.com/vishvananda/netlink"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gleak"
+	. "github.com/thediveo/fdooze"
+)
+
+var _ = Describe("neighbors", func() {
+
+	BeforeEach(func() {
+		if os.Getuid() != 0 {
+			Skip("needs root")
+		}
+
+		goodfds := Filedescriptors()
+		goodgos := Goroutines()
+		DeferCleanup(func() {
+			Eventually(Goroutines).Within(2 * time.Second).ProbeEvery(100 * time.Millisecond).
+				ShouldNot(HaveLeaked(goodgos))
+			Expect(Filedescriptors()).NotTo(HaveLeakedFds(goodfds))
+		})
+	})
+
+	It("lists neighbors in a different network namespace", func() {
+		netnsfd := netns.NewTransient()
+		veth := NewTransient(&netlink.Veth{
+			LinkAttrs: netlink.LinkAttrs{
+				Namespace: netlink.NsFd(netnsfd),
+			},
+			PeerNamespace: netlink.NsFd(netnsfd),
+		}, "veth-")
+		Expect(Neighbors(veth, netlink.FAMILY_V4)).To(BeEmpty())
+
+		nlh := netns.NewNetlinkHandle(netnsfd)
+		mac := net.HardwareAddr{0x02, 0x00, 0x00, 0x00, 0x00, 0x42}
+		Expect(nlh.NeighAdd(&netlink.Neigh{
+			LinkIndex:    veth.Attrs().Index,
+			Family:       netlink.FAMILY_V4,
+			State:        netlink.NUD_PERMANENT,
+			IP:           net.ParseIP("10.0.0.42"),
+			HardwareAddr: mac,
+		})).To(Succeed())
+
+		Expect(Neighbors(veth, netlink.FAMILY_V4)).To(ConsistOf(And(
+			HaveField("IP", WithTransform(net.IP.String, Equal("10.0.0.42"))),
+			HaveField("HardwareAddr", mac))))
+		Expect(Neighbors(veth, netlink.FAMILY_V6)).To(BeEmpty())
+	})
+
+})
@@ -0,0 +1,107 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package link
+
+import (
+	"fmt"
+	"runtime"
+
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netns"
+	"golang.org/x/sys/unix"
+)
+
+// inNetns runs fn on the calling Go routine with its OS-level thread switched
+// into the network namespace referenced by netnsfd, switching back afterwards
+// and returning fn's error, if any.
+//
+// Please note that we cannot use the netns package here, as its tests use this
+// package, so we would end up with an import cycle.
+func inNetns(netnsfd int, fn func() error) error {
+	runtime.LockOSThread()
+	orignetnsfd, err := unix.Open("/proc/thread-self/ns/net", unix.O_RDONLY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		runtime.UnlockOSThread()
+		return fmt.Errorf("cannot determine current network namespace from procfs, reason: %w", err)
+	}
+	defer unix.Close(orignetnsfd)
+	if err := unix.Setns(netnsfd, unix.CLONE_NEWNET); err != nil {
+		runtime.UnlockOSThread()
+		return fmt.Errorf("cannot switch into network namespace, reason: %w", err)
+	}
+	fnerr := fn()
+	if err := unix.Setns(orignetnsfd, unix.CLONE_NEWNET); err != nil {
+		// no unlock, as we need to throw away the tainted OS-level thread.
+		return fmt.Errorf("cannot switch back into original network namespace, reason: %w", err)
+	}
+	runtime.UnlockOSThread()
+	return fnerr
+}
+
+// inLinkNetns runs fn on the calling Go routine with its OS-level thread
+// switched into the network namespace of the specified link, as referenced by
+// its Attrs().Namespace in form of either a [netlink.NsFd] or [netlink.NsPid];
+// if unset, fn runs in the current network namespace.
+func inLinkNetns(l netlink.Link, fn func() error) error {
+	switch ref := l.Attrs().Namespace.(type) {
+	case nil:
+		return fn()
+	case netlink.NsFd:
+		return inNetns(int(ref), fn)
+	case netlink.NsPid:
+		netnsh, err := netns.GetFromPid(int(ref))
+		if err != nil {
+			return fmt.Errorf("cannot reference network namespace of process with PID %d, reason: %w", ref, err)
+		}
+		defer netnsh.Close()
+		return inNetns(int(netnsh), fn)
+	default:
+		return fmt.Errorf("link.Attrs().Namespace reference must be nil, a netlink.NsFd, or a netlink.NsPid")
+	}
+}
+
+// NewHandle returns a netlink handle for the network namespace of the specified
+// link, as referenced by its Attrs().Namespace in form of either a
+// [netlink.NsFd] or [netlink.NsPid]; if unset, the handle works in the current
+// network namespace. NewHandle returns an error for any other kind of network
+// namespace reference. The caller is responsible for closing the returned
+// handle.
+func NewHandle(l netlink.Link) (*netlink.Handle, error) {
+	switch ref := l.Attrs().Namespace.(type) {
+	case nil:
+		// The zero handle value works like the netlink package-level
+		// functions, that is, in the current network namespace.
+		return &netlink.Handle{}, nil
+	case netlink.NsFd:
+		nlh, err := netlink.NewHandleAt(netns.NsHandle(ref))
+		if err != nil {
+			return nil, fmt.Errorf("cannot create NETLINK handle for network namespace, reason: %w", err)
+		}
+		return nlh, nil
+	case netlink.NsPid:
+		netnsh, err := netns.GetFromPid(int(ref))
+		if err != nil {
+			return nil, fmt.Errorf("cannot reference network namespace of process with PID %d, reason: %w", ref, err)
+		}
+		defer netnsh.Close()
+		nlh, err := netlink.NewHandleAt(netnsh)
+		if err != nil {
+			return nil, fmt.Errorf("cannot create NETLINK handle for network namespace, reason: %w", err)
+		}
+		return nlh, nil
+	default:
+		return nil, fmt.Errorf("link.Attrs().Namespace reference must be nil, a netlink.NsFd, or a netlink.NsPid")
+	}
+}
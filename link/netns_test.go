@@ -0,0 +1,45 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package link
+
+import (
+	"os"
+
+	"github.com/vishvananda/netlink"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/thediveo/success"
+)
+
+var _ = Describe("netlink handles", func() {
+
+	It("rejects unsupported network namespace references", func() {
+		Expect(NewHandle(&netlink.Device{LinkAttrs: netlink.LinkAttrs{Namespace: "foo"}})).Error().To(
+			MatchError(ContainSubstring("must be nil, a netlink.NsFd, or a netlink.NsPid")))
+	})
+
+	It("returns handles for unset and PID-referenced network namespaces", func() {
+		if os.Getuid() != 0 {
+			Skip("needs root")
+		}
+		for _, ref := range []any{nil, netlink.NsPid(os.Getpid())} {
+			nlh := Successful(NewHandle(&netlink.Device{LinkAttrs: netlink.LinkAttrs{Namespace: ref}}))
+			Expect(nlh.LinkByName("lo")).Error().NotTo(HaveOccurred())
+			nlh.Close()
+		}
+	})
+
+})
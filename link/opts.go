@@ -14,7 +14,13 @@
 
 package link
 
-import "github.com/vishvananda/netlink"
+import (
+	"errors"
+	"fmt"
+
+	"github.com/thediveo/notwork/internal/initialns"
+	"github.com/vishvananda/netlink"
+)
 
 // InNamespace configures a link (network interface) to be created in the
 // network namespace referenced by fdref, instead of creating it in the current
@@ -26,6 +32,25 @@ func InNamespace(fdref int) Opt {
 	}
 }
 
+// InInitialNamespace configures a link (network interface) to be created in the
+// initial network namespace of this process, regardless of the current network
+// namespace. The initial network namespace is captured when the link package
+// gets initialized.
+//
+// This supports creating parent network interfaces “in the host” while the
+// child network interfaces go elsewhere, without having to juggle network
+// namespaces.
+func InInitialNamespace() Opt {
+	return func(l *Link) error {
+		netnsfd := initialns.Fd()
+		if netnsfd < 0 {
+			return errors.New("initial network namespace unknown")
+		}
+		l.Attrs().Namespace = netlink.NsFd(netnsfd)
+		return nil
+	}
+}
+
 // WithLinkNamespace specifies the “reference” or “link” network namespace other
 // than the current network namespace when creating a new network interface.
 func WithLinkNamespace(fdref int) Opt {
@@ -34,3 +59,150 @@ func WithLinkNamespace(fdref int) Opt {
 		return nil
 	}
 }
+
+// WithIfIndex configures a link (network interface) to be created with the
+// specified interface index, instead of letting the Linux kernel choose the
+// next available index. Creation fails if the specified index is already in
+// use in the destination network namespace.
+//
+// See also [NextIfIndex].
+func WithIfIndex(index int) Opt {
+	return func(l *Link) error {
+		if index <= 0 {
+			return fmt.Errorf("invalid interface index %d", index)
+		}
+		l.Attrs().Index = index
+		return nil
+	}
+}
+
+// WithNumQueues configures a link (network interface) to be created with the
+// specified number of RX as well as TX queues each. These queues then show up
+// in sysfs as “queues/rx-*” and “queues/tx-*” of the network interface; please
+// note that sysfs needs to be mounted in the network namespace of the network
+// interface, see also the mntns package.
+//
+// Not all types of network interfaces support multiple queues.
+func WithNumQueues(n int) Opt {
+	return func(l *Link) error {
+		if n <= 0 {
+			return fmt.Errorf("invalid number of queues %d", n)
+		}
+		l.Attrs().NumRxQueues = n
+		l.Attrs().NumTxQueues = n
+		return nil
+	}
+}
+
+// WithAddr configures an IPv4 or IPv6 address in CIDR notation (such as
+// “10.0.0.1/24” or “fd00::1/64”) to be assigned to a link (network interface)
+// right after it has been created.
+func WithAddr(cidr string) Opt {
+	return func(l *Link) error {
+		addr, err := netlink.ParseAddr(cidr)
+		if err != nil {
+			return fmt.Errorf("invalid address %q, reason: %w", cidr, err)
+		}
+		l.Addrs = append(l.Addrs, addr)
+		return nil
+	}
+}
+
+// WithoutCleanup configures a link (network interface) to not be scheduled for
+// automatic removal after it has been created.
+//
+// WARNING: the newly created network interface then becomes the sole
+// responsibility of the caller. Unless the caller (or the code under test)
+// removes the network interface, it will leak and outlive the test, unless it
+// has been created in a transient network namespace that goes away. This
+// option is intended for the rare tests that verify the deletion logic of
+// production code, where the automatic cleanup would otherwise race to remove
+// the network interface first.
+func WithoutCleanup() Opt {
+	return func(l *Link) error {
+		l.NoCleanup = true
+		return nil
+	}
+}
+
+// WithAttrs configures a function that gets to modify the attributes of a link
+// (network interface) right before it gets created, such as its alias, group,
+// TX queue length, or MTU. This is an escape hatch for the long tail of
+// attributes not warranting dedicated options. The function is applied to the
+// deep copy of the link description that [NewTransient] creates, never
+// modifying the original link description.
+//
+// The function must neither change the name, as NewTransient always assigns a
+// random name, nor the network namespace; use [InNamespace] instead.
+func WithAttrs(fn func(*netlink.LinkAttrs)) Opt {
+	return func(l *Link) error {
+		if fn == nil {
+			return errors.New("nil link attributes function")
+		}
+		l.AttrsFns = append(l.AttrsFns, fn)
+		return nil
+	}
+}
+
+// WithExpectedInitialState configures the operational state a link (network
+// interface) is expected to be in right after it has been created, such as
+// [netlink.OperDown] for a dummy network interface. [NewTransient] fails the
+// current test if the newly created network interface turns out to be in a
+// different operational state. This catches kernel and driver surprises early
+// and documents assumptions about the initial operational state.
+func WithExpectedInitialState(state netlink.LinkOperState) Opt {
+	return func(l *Link) error {
+		l.OperState = &state
+		return nil
+	}
+}
+
+// WithMaxNameLen configures a link (network interface) to be created with a
+// random name of exactly n characters, instead of the maximum length of 15
+// characters. This allows testing code that is sensitive to the length of
+// network interface names, such as when truncating them. The length must leave
+// room for at least four random characters after the name prefix.
+func WithMaxNameLen(n int) Opt {
+	return func(l *Link) error {
+		if n <= 0 || n > maxNifnameLen {
+			return fmt.Errorf("invalid network interface name length %d", n)
+		}
+		l.NameLen = n
+		return nil
+	}
+}
+
+// SkipIndexFixup configures a link (network interface) to be created without
+// re-fetching its interface index by name afterwards. [NewTransient] normally
+// re-fetches the index in order to work around vishvananda/netlink not
+// correctly updating the index when creating a network interface in a
+// different network namespace. Callers who know that they are not affected can
+// skip the workaround in order to save a NETLINK round-trip.
+//
+// Please note that [WithExpectedInitialState] still requires re-fetching the
+// newly created network interface, and thus its index.
+func SkipIndexFixup() Opt {
+	return func(l *Link) error {
+		l.NoIndexFixup = true
+		return nil
+	}
+}
+
+// maxMTU is the largest MTU accepted by [WithMTU], as even jumbo and super
+// jumbo frames don't come any larger.
+const maxMTU = 65535
+
+// WithMTU configures a link (network interface) to be created with the
+// specified MTU, such as for testing path MTU discovery and fragmentation
+// handling. The MTU must be positive and not exceed 65535; please note that
+// the Linux kernel might enforce a narrower range, depending on the type of
+// network interface.
+func WithMTU(mtu int) Opt {
+	return func(l *Link) error {
+		if mtu <= 0 || mtu > maxMTU {
+			return fmt.Errorf("invalid MTU %d, must be in 1..%d", mtu, maxMTU)
+		}
+		l.Attrs().MTU = mtu
+		return nil
+	}
+}
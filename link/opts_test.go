@@ -15,6 +15,7 @@
 package link
 
 import (
+	"github.com/thediveo/notwork/internal/initialns"
 	"github.com/vishvananda/netlink"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -30,11 +31,99 @@ var _ = Describe("link configuration options", func() {
 		for _, opt := range []Opt{
 			WithLinkNamespace(42),
 			InNamespace(666),
+			WithIfIndex(123),
+			WithoutCleanup(),
 		} {
 			Expect(opt(lnk)).To(Succeed())
 		}
 		Expect(lnk.LinkNamespace).To(Equal(netlink.NsFd(42)))
 		Expect(lnk.Attrs().Namespace).To(Equal(netlink.NsFd(666)))
+		Expect(lnk.Attrs().Index).To(Equal(123))
+		Expect(lnk.NoCleanup).To(BeTrue())
+	})
+
+	It("configures addresses", func() {
+		lnk := &Link{
+			Link: &netlink.GenericLink{},
+		}
+		Expect(WithAddr("10.0.0.1/24")(lnk)).To(Succeed())
+		Expect(WithAddr("fd00::1/64")(lnk)).To(Succeed())
+		Expect(lnk.Addrs).To(ConsistOf(
+			HaveField("IPNet.String()", "10.0.0.1/24"),
+			HaveField("IPNet.String()", "fd00::1/64")))
+		Expect(WithAddr("10.0.0.666/24")(lnk)).To(MatchError(ContainSubstring("invalid address")))
+	})
+
+	It("configures the initial network namespace", func() {
+		lnk := &Link{
+			Link: &netlink.GenericLink{},
+		}
+		Expect(InInitialNamespace()(lnk)).To(Succeed())
+		Expect(lnk.Attrs().Namespace).To(Equal(netlink.NsFd(initialns.Fd())))
+	})
+
+	It("configures queues", func() {
+		lnk := &Link{
+			Link: &netlink.GenericLink{},
+		}
+		Expect(WithNumQueues(4)(lnk)).To(Succeed())
+		Expect(lnk.Attrs().NumRxQueues).To(Equal(4))
+		Expect(lnk.Attrs().NumTxQueues).To(Equal(4))
+		Expect(WithNumQueues(0)(lnk)).NotTo(Succeed())
+	})
+
+	It("configures link attribute functions", func() {
+		lnk := &Link{
+			Link: &netlink.GenericLink{},
+		}
+		Expect(WithAttrs(func(attrs *netlink.LinkAttrs) { attrs.Alias = "foo" })(lnk)).To(Succeed())
+		Expect(WithAttrs(func(attrs *netlink.LinkAttrs) { attrs.MTU = 1280 })(lnk)).To(Succeed())
+		Expect(lnk.AttrsFns).To(HaveLen(2))
+		Expect(WithAttrs(nil)(lnk)).NotTo(Succeed())
+	})
+
+	It("configures the expected initial operational state", func() {
+		lnk := &Link{
+			Link: &netlink.GenericLink{},
+		}
+		Expect(lnk.OperState).To(BeNil())
+		Expect(WithExpectedInitialState(netlink.OperDown)(lnk)).To(Succeed())
+		Expect(lnk.OperState).To(HaveValue(Equal(netlink.LinkOperState(netlink.OperDown))))
+	})
+
+	It("skips the index fixup", func() {
+		lnk := &Link{
+			Link: &netlink.GenericLink{},
+		}
+		Expect(SkipIndexFixup()(lnk)).To(Succeed())
+		Expect(lnk.NoIndexFixup).To(BeTrue())
+	})
+
+	It("configures the name length", func() {
+		lnk := &Link{
+			Link: &netlink.GenericLink{},
+		}
+		Expect(WithMaxNameLen(11)(lnk)).To(Succeed())
+		Expect(lnk.NameLen).To(Equal(11))
+		Expect(WithMaxNameLen(0)(lnk)).NotTo(Succeed())
+		Expect(WithMaxNameLen(16)(lnk)).NotTo(Succeed())
+	})
+
+	It("configures the MTU", func() {
+		lnk := &Link{
+			Link: &netlink.GenericLink{},
+		}
+		Expect(WithMTU(9000)(lnk)).To(Succeed())
+		Expect(lnk.Attrs().MTU).To(Equal(9000))
+		Expect(WithMTU(0)(lnk)).NotTo(Succeed())
+		Expect(WithMTU(65536)(lnk)).NotTo(Succeed())
+	})
+
+	It("rejects invalid interface indices", func() {
+		lnk := &Link{
+			Link: &netlink.GenericLink{},
+		}
+		Expect(WithIfIndex(0)(lnk)).NotTo(Succeed())
 	})
 
 })
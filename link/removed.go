@@ -0,0 +1,60 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package link
+
+import (
+	"errors"
+
+	"github.com/vishvananda/netlink"
+
+	. "github.com/onsi/ginkgo/v2" //lint:ignore ST1001 rule does not apply
+	. "github.com/onsi/gomega"    //lint:ignore ST1001 rule does not apply
+)
+
+// ExpectRemoved expects the network interface l to not exist anymore, such as
+// after its scope ended and its scheduled removal has taken place, so it can
+// be used inside a later Ginkgo leaf node. If l still is scheduled for removal,
+// ExpectRemoved checks the network namespace l is tracked in, taking moves
+// using [MoveToNamespace] into account. Otherwise, ExpectRemoved checks the
+// network namespace referenced by l's [netlink.LinkAttrs.Namespace], or the
+// current network namespace if unset. If the referenced network namespace is
+// gone, then the network interface is gone too.
+//
+// As the kernel might reuse interface indices, ExpectRemoved checks for the
+// absence of l's (random) name instead.
+func ExpectRemoved(l netlink.Link) {
+	GinkgoHelper()
+
+	Expect(l).NotTo(BeNil(), "need a non-nil link description")
+	var nlh *netlink.Handle
+	if t, ok := transients.Load(l); ok {
+		nlh = t.(*transient).nlh
+	} else {
+		var err error
+		nlh, err = NewHandle(l)
+		if err != nil {
+			return // network namespace is gone, and so is the network interface.
+		}
+		defer nlh.Close()
+	}
+	_, err := nlh.LinkByName(l.Attrs().Name)
+	var notFoundErr netlink.LinkNotFoundError
+	if errors.As(err, &notFoundErr) {
+		return
+	}
+	Expect(err).NotTo(HaveOccurred(),
+		"cannot determine absence of network interface %q", l.Attrs().Name)
+	fail("network interface " + l.Attrs().Name + " has not been removed")
+}
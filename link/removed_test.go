@@ -0,0 +1,97 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package link
+
+import (
+	"os"
+	"time"
+
+	"github.com/thediveo/notwork/netns"
+	"github.com/vishvananda/netlink"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gleak"
+	. "github.com/thediveo/fdooze"
+)
+
+var _ = Describe("expecting removed network interfaces", Ordered, func() {
+
+	var netnsfd int
+
+	BeforeAll(func() {
+		if os.Getuid() != 0 {
+			Skip("needs root")
+		}
+		netnsfd = netns.NewTransient()
+	})
+
+	BeforeEach(func() {
+		goodfds := Filedescriptors()
+		goodgos := Goroutines()
+		DeferCleanup(func() {
+			Eventually(Goroutines).Within(2 * time.Second).ProbeEvery(100 * time.Millisecond).
+				ShouldNot(HaveLeaked(goodgos))
+			Expect(Filedescriptors()).NotTo(HaveLeakedFds(goodfds))
+		})
+	})
+
+	var veth netlink.Link
+
+	It("creates a transient network interface", func() {
+		veth = NewTransient(&netlink.Veth{
+			LinkAttrs: netlink.LinkAttrs{
+				Namespace: netlink.NsFd(netnsfd),
+			},
+			PeerNamespace: netlink.NsFd(netnsfd),
+		}, "veth-")
+
+		oldfail := fail
+		defer func() { fail = oldfail }()
+		fail = func(message string, callerSkip ...int) { panic(message) }
+		Expect(func() { ExpectRemoved(veth) }).To(PanicWith(ContainSubstring("has not been removed")))
+	})
+
+	It("has removed the transient network interface", func() {
+		ExpectRemoved(veth)
+	})
+
+	It("checks network interfaces not scheduled for removal", func() {
+		veth := NewTransient(&netlink.Veth{
+			LinkAttrs: netlink.LinkAttrs{
+				Namespace: netlink.NsFd(netnsfd),
+			},
+			PeerNamespace: netlink.NsFd(netnsfd),
+		}, "veth-", WithoutCleanup())
+
+		oldfail := fail
+		defer func() { fail = oldfail }()
+		fail = func(message string, callerSkip ...int) { panic(message) }
+		Expect(func() { ExpectRemoved(veth) }).To(PanicWith(ContainSubstring("has not been removed")))
+
+		Expect(netns.NewNetlinkHandle(netnsfd).LinkDel(veth)).To(Succeed())
+		ExpectRemoved(veth)
+	})
+
+	It("considers network interfaces in gone network namespaces to be removed", func() {
+		ExpectRemoved(&netlink.Veth{
+			LinkAttrs: netlink.LinkAttrs{
+				Name:      "veth-gone",
+				Namespace: netlink.NsFd(-1),
+			},
+		})
+	})
+
+})
@@ -0,0 +1,118 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package link
+
+import (
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+
+	. "github.com/onsi/ginkgo/v2" //lint:ignore ST1001 rule does not apply
+	. "github.com/onsi/gomega"    //lint:ignore ST1001 rule does not apply
+)
+
+// AddRoute adds a route to the destination dst in CIDR notation (such as
+// “10.1.0.0/16” or “fd01::/48”) via the network interface l, in the network
+// namespace referenced by l.Attrs().Namespace; if unset, in the current
+// network namespace. The optional IPv4 or IPv6 gateway address gw must be of
+// the same IP family as dst; an empty gw instead adds a link-scope route. If l
+// has no interface index set, AddRoute looks up the index by name.
+//
+// AddRoute fails the current test when the network interface is down, as the
+// kernel doesn't install routes via network interfaces that are down. AddRoute
+// schedules a DeferCleanup to remove the route again, unless the route or
+// network interface have gone in the meantime.
+func AddRoute(l netlink.Link, dst string, gw string) {
+	GinkgoHelper()
+
+	Expect(l).NotTo(BeNil(), "need a non-nil link description")
+
+	_, dstNet, err := net.ParseCIDR(dst)
+	Expect(err).NotTo(HaveOccurred(), "invalid route destination %q", dst)
+	route := &netlink.Route{
+		Dst:   dstNet,
+		Scope: netlink.SCOPE_LINK,
+	}
+	if gw != "" {
+		gwIP := net.ParseIP(gw)
+		Expect(gwIP).NotTo(BeNil(), "invalid gateway address %q", gw)
+		Expect(gwIP.To4() != nil).To(Equal(dstNet.IP.To4() != nil),
+			"gateway %q and destination %q must be of the same IP family", gw, dst)
+		route.Gw = gwIP
+		route.Scope = netlink.SCOPE_UNIVERSE
+	}
+
+	// resolve an unset network namespace reference immediately, as the
+	// deferred cleanup might run when the caller has already switched back
+	// into a different network namespace.
+	nlh, err := layerHandle(l)
+	Expect(err).NotTo(HaveOccurred())
+	DeferCleanup(func() {
+		nlh.Close()
+	})
+	var nif netlink.Link
+	if l.Attrs().Index == 0 {
+		nif, err = nlh.LinkByName(l.Attrs().Name)
+		Expect(err).NotTo(HaveOccurred(),
+			"cannot determine index of network interface %q", l.Attrs().Name)
+		l.Attrs().Index = nif.Attrs().Index
+	} else {
+		nif, err = nlh.LinkByIndex(l.Attrs().Index)
+		Expect(err).NotTo(HaveOccurred(),
+			"cannot find network interface %q", l.Attrs().Name)
+	}
+	Expect(nif.Attrs().Flags&net.FlagUp).NotTo(BeZero(),
+		"network interface %q is down, cannot add route to %s", l.Attrs().Name, dst)
+
+	route.LinkIndex = l.Attrs().Index
+	Expect(nlh.RouteAdd(route)).To(Succeed(),
+		"cannot add route %s via network interface %q", routeDescription(route), l.Attrs().Name)
+	DeferCleanup(func() {
+		// when the network interface has already gone, so have its routes.
+		if _, err := nlh.LinkByIndex(l.Attrs().Index); err != nil {
+			return
+		}
+		By(fmt.Sprintf("removing route %s via network interface %q", routeDescription(route), l.Attrs().Name))
+		if err := nlh.RouteDel(route); err != nil && !errors.Is(err, unix.ESRCH) {
+			Expect(err).NotTo(HaveOccurred(),
+				"cannot remove route %s via network interface %q", routeDescription(route), l.Attrs().Name)
+		}
+	})
+}
+
+// AddDefaultRoute adds an IPv4 or IPv6 default route via the network interface
+// l and the gateway address gw, depending on the IP family of gw. An empty gw
+// adds a link-scope IPv4 default route. Please see [AddRoute] for details.
+func AddDefaultRoute(l netlink.Link, gw string) {
+	GinkgoHelper()
+
+	dst := "0.0.0.0/0"
+	if gwIP := net.ParseIP(gw); gwIP != nil && gwIP.To4() == nil {
+		dst = "::/0"
+	}
+	AddRoute(l, dst, gw)
+}
+
+// routeDescription returns a short textual description of the route's
+// destination and gateway, if any.
+func routeDescription(route *netlink.Route) string {
+	if route.Gw == nil {
+		return route.Dst.String()
+	}
+	return route.Dst.String() + " via " + route.Gw.String()
+}
@@ -0,0 +1,106 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package link
+
+import (
+	"net"
+	"os"
+	"time"
+
+	"github.com/thediveo/notwork/netns"
+	"github.com/vishvananda/netlink"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gleak"
+	. "github.com/thediveo/fdooze"
+	. "github.com/thediveo/success"
+)
+
+var _ = Describe("adding routes", func() {
+
+	BeforeEach(func() {
+		if os.Getuid() != 0 {
+			Skip("needs root")
+		}
+		goodfds := Filedescriptors()
+		goodgos := Goroutines()
+		DeferCleanup(func() {
+			Eventually(Goroutines).Within(2 * time.Second).ProbeEvery(250 * time.Millisecond).
+				ShouldNot(HaveLeaked(goodgos))
+			Expect(Filedescriptors()).NotTo(HaveLeakedFds(goodfds))
+		})
+	})
+
+	It("adds and removes default and link-scope routes", func() {
+		netnsfd := netns.NewTransient()
+		nlh := netns.NewNetlinkHandle(netnsfd)
+
+		By("adding routes in a separate scope", func() {
+			veth := NewTransient(&netlink.Veth{
+				LinkAttrs:     netlink.LinkAttrs{Namespace: netlink.NsFd(netnsfd)},
+				PeerName:      "peer",
+				PeerNamespace: netlink.NsFd(netnsfd),
+			}, "veth-", WithAddr("10.1.0.1/24"), WithAddr("fd01::1/64"))
+			Expect(nlh.LinkSetUp(Successful(nlh.LinkByName(veth.(*netlink.Veth).PeerName)))).To(Succeed())
+			EnsureUp(veth)
+			// As DeferCleanup runs the cleanup functions in reverse order of
+			// their registration, this check runs after the routes have been
+			// removed, but before the network interface gets removed.
+			DeferCleanup(func() {
+				Expect(nlh.RouteList(veth, netlink.FAMILY_ALL)).NotTo(ContainElement(
+					Or(HaveField("Gw", Not(BeNil())), HaveField("Dst.String()", "10.2.0.0/16"))))
+			})
+
+			AddDefaultRoute(veth, "10.1.0.254")
+			AddDefaultRoute(veth, "fd01::fe")
+			AddRoute(veth, "10.2.0.0/16", "")
+
+			routes := Successful(nlh.RouteList(veth, netlink.FAMILY_ALL))
+			Expect(routes).To(ContainElement(And(
+				HaveField("Dst.String()", "0.0.0.0/0"),
+				HaveField("Gw", Equal(net.ParseIP("10.1.0.254").To4())))))
+			Expect(routes).To(ContainElement(And(
+				HaveField("Dst.String()", "::/0"),
+				HaveField("Gw", Equal(net.ParseIP("fd01::fe"))))))
+			Expect(routes).To(ContainElement(And(
+				HaveField("Dst.String()", "10.2.0.0/16"),
+				HaveField("Scope", netlink.SCOPE_LINK))))
+		})
+	})
+
+	It("rejects adding routes via network interfaces that are down", func() {
+		defer netns.EnterTransient()()
+
+		veth := NewTransient(&netlink.Veth{PeerName: "peer"}, "veth-")
+		Expect(InterceptGomegaFailure(func() {
+			AddDefaultRoute(veth, "")
+		})).To(MatchError(ContainSubstring("is down, cannot add route")))
+	})
+
+	It("rejects invalid destinations and gateways", func() {
+		veth := &netlink.Veth{LinkAttrs: netlink.LinkAttrs{Name: "foo"}}
+		Expect(InterceptGomegaFailure(func() {
+			AddRoute(veth, "10.0.0.0", "")
+		})).To(MatchError(ContainSubstring("invalid route destination")))
+		Expect(InterceptGomegaFailure(func() {
+			AddRoute(veth, "10.0.0.0/8", "foo")
+		})).To(MatchError(ContainSubstring("invalid gateway address")))
+		Expect(InterceptGomegaFailure(func() {
+			AddRoute(veth, "10.0.0.0/8", "fd01::fe")
+		})).To(MatchError(ContainSubstring("must be of the same IP family")))
+	})
+
+})
@@ -0,0 +1,163 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package link
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netns"
+	"golang.org/x/sys/unix"
+
+	. "github.com/onsi/ginkgo/v2" //lint:ignore ST1001 rule does not apply
+	. "github.com/onsi/gomega"    //lint:ignore ST1001 rule does not apply
+)
+
+// StackBuilder declares a stack of transient network interfaces, with each
+// layer (except for the bottom-most one) stacked on top of the layer below it.
+// Use [Stack] to start declaring a new stack.
+type StackBuilder struct {
+	layers []stackLayer
+}
+
+// stackLayer describes a single layer of a stack of network interfaces, in the
+// form of the arguments to [NewTransient].
+type stackLayer struct {
+	template netlink.Link
+	prefix   string
+	opts     []Opt
+}
+
+// Stack returns a new [StackBuilder] for fluently declaring a stack of
+// transient network interfaces from the bottom up, such as a VLAN on top of a
+// MACVLAN on top of a dummy network interface:
+//
+//	links, cleanup := link.Stack().
+//		Layer(&netlink.Dummy{}, dummy.DummyPrefix).
+//		Layer(&netlink.Macvlan{Mode: netlink.MACVLAN_MODE_BRIDGE}, macvlan.MacvlanPrefix).
+//		Layer(&netlink.Vlan{VlanId: 42}, "vlan-").
+//		Create()
+//
+// As this package cannot use the convenience packages, such as
+// [github.com/thediveo/notwork/dummy], without creating an import cycle, the
+// layers are declared in terms of link descriptions (templates), prefixes, and
+// options as understood by [NewTransient].
+func Stack() *StackBuilder {
+	return &StackBuilder{}
+}
+
+// Layer declares another layer on top of the layers declared so far, returning
+// the same builder for chaining. When creating the stack, the link description
+// is taken as a template as well as its parent index then automatically set to
+// reference the network interface of the layer below, if any.
+func (s *StackBuilder) Layer(template netlink.Link, prefix string, opts ...Opt) *StackBuilder {
+	s.layers = append(s.layers, stackLayer{
+		template: template,
+		prefix:   prefix,
+		opts:     opts,
+	})
+	return s
+}
+
+// Create creates the declared stack of transient network interfaces from the
+// bottom up, returning the links with the bottom-most layer first. The parent
+// references of the layers are resolved in the current network namespace.
+//
+// Instead of scheduling the removal of each layer individually, Create
+// schedules a single removal of the whole stack, removing the layers from the
+// top down. The returned cleanup function removes the stack immediately; it can
+// be called multiple times and also when the stack has already been scheduled
+// for removal.
+func (s *StackBuilder) Create() (links []netlink.Link, cleanup func()) {
+	GinkgoHelper()
+
+	Expect(s.layers).NotTo(BeEmpty(), "stack of network interfaces needs at least one layer")
+
+	var handles []*netlink.Handle
+	cleanup = sync.OnceFunc(func() {
+		defer func() {
+			for _, nlh := range handles {
+				nlh.Close()
+			}
+		}()
+		for idx := len(links) - 1; idx >= 0; idx-- {
+			l := links[idx]
+			By(fmt.Sprintf("removing transient network interface %q of stack layer %d",
+				l.Attrs().Name, idx))
+			Expect(removeLayer(handles[idx], l)).To(Succeed(),
+				"cannot remove transient network interface %q", l.Attrs().Name)
+		}
+	})
+	DeferCleanup(func() { cleanup() })
+
+	for idx, layer := range s.layers {
+		opts := append(slices.Clone(layer.opts), WithoutCleanup())
+		if idx > 0 {
+			parentIndex := links[idx-1].Attrs().Index
+			opts = append(opts, WithAttrs(func(attrs *netlink.LinkAttrs) {
+				attrs.ParentIndex = parentIndex
+			}))
+		}
+		l := NewTransient(layer.template, layer.prefix, opts...)
+		nlh, err := layerHandle(l)
+		if err != nil {
+			_ = inLinkNetns(l, func() error { return netlink.LinkDel(l) })
+			fail(fmt.Sprintf("cannot reference network namespace of stack layer %d, reason: %s",
+				idx, err.Error()))
+		}
+		links = append(links, l)
+		handles = append(handles, nlh)
+	}
+	return links, cleanup
+}
+
+// removeLayer removes the network interface of a stack layer, tolerating
+// network interfaces that have already been removed otherwise, such as by the
+// code under test.
+func removeLayer(nlh *netlink.Handle, l netlink.Link) error {
+	if _, err := nlh.LinkByIndex(l.Attrs().Index); err != nil {
+		var notFoundErr netlink.LinkNotFoundError
+		if errors.As(err, &notFoundErr) {
+			return nil
+		}
+		return err
+	}
+	return nlh.LinkDel(l)
+}
+
+// layerHandle returns a netlink handle for the network namespace of the
+// specified link, as referenced by its Attrs().Namespace. In contrast to
+// [NewHandle], an unset network namespace reference gets resolved immediately
+// to the current network namespace, so that the returned handle keeps working
+// in this network namespace even after the caller has switched into another
+// network namespace.
+func layerHandle(l netlink.Link) (*netlink.Handle, error) {
+	if l.Attrs().Namespace != nil {
+		return NewHandle(l)
+	}
+	netnsfd, err := unix.Open("/proc/thread-self/ns/net", unix.O_RDONLY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, fmt.Errorf("cannot determine current network namespace from procfs, reason: %w", err)
+	}
+	defer unix.Close(netnsfd)
+	nlh, err := netlink.NewHandleAt(netns.NsHandle(netnsfd))
+	if err != nil {
+		return nil, fmt.Errorf("cannot create NETLINK handle for network namespace, reason: %w", err)
+	}
+	return nlh, nil
+}
@@ -0,0 +1,87 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package link
+
+import (
+	"os"
+	"time"
+
+	"github.com/thediveo/notwork/netns"
+	"github.com/vishvananda/netlink"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gleak"
+	. "github.com/thediveo/fdooze"
+	. "github.com/thediveo/success"
+)
+
+var _ = Describe("stacking network interfaces", func() {
+
+	BeforeEach(func() {
+		if os.Getuid() != 0 {
+			Skip("needs root")
+		}
+
+		goodfds := Filedescriptors()
+		goodgos := Goroutines()
+		DeferCleanup(func() {
+			Eventually(Goroutines).Within(2 * time.Second).ProbeEvery(100 * time.Millisecond).
+				ShouldNot(HaveLeaked(goodgos))
+			Expect(Filedescriptors()).NotTo(HaveLeakedFds(goodfds))
+		})
+	})
+
+	It("rejects an empty stack", func() {
+		Expect(InterceptGomegaFailure(func() {
+			_, _ = Stack().Create()
+		})).To(MatchError(ContainSubstring("needs at least one layer")))
+	})
+
+	It("creates and removes a stack of network interfaces", func() {
+		defer netns.EnterTransient()()
+
+		links, cleanup := Stack().
+			Layer(&netlink.Veth{}, "veth-").
+			Layer(&netlink.Macvlan{Mode: netlink.MACVLAN_MODE_BRIDGE}, "mcvl-").
+			Layer(&netlink.Macvlan{Mode: netlink.MACVLAN_MODE_BRIDGE}, "mcvl-").
+			Create()
+		Expect(links).To(HaveLen(3))
+		Expect(links[0].Type()).To(Equal("veth"))
+		Expect(links[1].Type()).To(Equal("macvlan"))
+		Expect(Successful(netlink.LinkByIndex(links[1].Attrs().Index)).Attrs().ParentIndex).To(
+			Equal(links[0].Attrs().Index))
+		// please note that the Linux kernel stacks a MACVLAN on top of a MACVLAN
+		// directly on the lower MACVLAN's parent.
+		Expect(links[2].Type()).To(Equal("macvlan"))
+
+		cleanup()
+		for _, l := range links {
+			Expect(netlink.LinkByName(l.Attrs().Name)).Error().To(HaveOccurred())
+		}
+		cleanup()
+	})
+
+	It("tolerates layers removed otherwise", func() {
+		defer netns.EnterTransient()()
+
+		links, _ := Stack().
+			Layer(&netlink.Veth{}, "veth-").
+			Layer(&netlink.Macvlan{Mode: netlink.MACVLAN_MODE_BRIDGE}, "mcvl-").
+			Create()
+		Expect(netlink.LinkDel(links[1])).To(Succeed())
+	})
+
+})
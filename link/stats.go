@@ -0,0 +1,48 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package link
+
+import (
+	"reflect"
+
+	"github.com/vishvananda/netlink"
+
+	. "github.com/onsi/ginkgo/v2" //lint:ignore ST1001 rule does not apply
+	. "github.com/onsi/gomega"    //lint:ignore ST1001 rule does not apply
+)
+
+// StatsDelta returns the field-by-field differences between two snapshots of
+// network interface statistics, where after is expected to be the later
+// snapshot. The statistics snapshots are typically taken from
+// [netlink.LinkAttrs.Statistics] of freshly queried link details.
+//
+//	before := *Successful(netlink.LinkByIndex(idx)).Attrs().Statistics
+//	// ...generate some traffic...
+//	after := *Successful(netlink.LinkByIndex(idx)).Attrs().Statistics
+//	Expect(link.StatsDelta(&before, &after)).To(notwork.HaveReceivedAtLeast(1000))
+func StatsDelta(before, after *netlink.LinkStatistics) netlink.LinkStatistics {
+	GinkgoHelper()
+
+	Expect(before).NotTo(BeNil(), "need non-nil before statistics")
+	Expect(after).NotTo(BeNil(), "need non-nil after statistics")
+	var delta netlink.LinkStatistics
+	deltav := reflect.ValueOf(&delta).Elem()
+	beforev := reflect.ValueOf(before).Elem()
+	afterv := reflect.ValueOf(after).Elem()
+	for idx := 0; idx < deltav.NumField(); idx++ {
+		deltav.Field(idx).SetUint(afterv.Field(idx).Uint() - beforev.Field(idx).Uint())
+	}
+	return delta
+}
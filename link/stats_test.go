@@ -0,0 +1,42 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package link
+
+import (
+	"github.com/vishvananda/netlink"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("link statistics", func() {
+
+	It("rejects nil statistics", func() {
+		Expect(InterceptGomegaFailure(func() {
+			_ = StatsDelta(nil, &netlink.LinkStatistics{})
+		})).To(MatchError(ContainSubstring("need non-nil before statistics")))
+		Expect(InterceptGomegaFailure(func() {
+			_ = StatsDelta(&netlink.LinkStatistics{}, nil)
+		})).To(MatchError(ContainSubstring("need non-nil after statistics")))
+	})
+
+	It("calculates deltas", func() {
+		Expect(StatsDelta(
+			&netlink.LinkStatistics{RxBytes: 100, TxPackets: 1, TxCompressed: 42},
+			&netlink.LinkStatistics{RxBytes: 1100, TxPackets: 3, TxCompressed: 42},
+		)).To(Equal(netlink.LinkStatistics{RxBytes: 1000, TxPackets: 2}))
+	})
+
+})
@@ -0,0 +1,53 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package link
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/vishvananda/netlink"
+
+	. "github.com/onsi/ginkgo/v2" //lint:ignore ST1001 rule does not apply
+	. "github.com/onsi/gomega"    //lint:ignore ST1001 rule does not apply
+)
+
+// SysfsPath returns the “/sys/class/net/<name>” path of the network interface
+// l, resolved through procfsroot, such as returned by mntns.NewTransient. An
+// empty procfsroot refers to the current mount namespace.
+//
+// Please note that a sysfs instance doesn't adapt its “/sys/class/net” view
+// to the network namespace of the accessing thread, but instead sticks to the
+// network namespace of the thread that mounted the sysfs instance. Thus,
+// SysfsPath checks that the sysfs instance's view in fact matches the network
+// interface by comparing its interface index, failing the current test
+// otherwise. In case of failure, make sure to mount the sysfs instance (using
+// mntns.MountSysfsRO) while being in the network namespace of l.
+func SysfsPath(l netlink.Link, procfsroot string) string {
+	GinkgoHelper()
+
+	Expect(l).NotTo(BeNil(), "need a non-nil link description")
+	path := filepath.Join("/", procfsroot, "sys/class/net", l.Attrs().Name)
+	ifindex, err := os.ReadFile(filepath.Join(path, "ifindex"))
+	Expect(err).NotTo(HaveOccurred(),
+		"network interface %q not found in sysfs, is sysfs mounted from the correct network namespace?",
+		l.Attrs().Name)
+	Expect(strconv.Atoi(strings.TrimSpace(string(ifindex)))).To(Equal(l.Attrs().Index),
+		"sysfs shows a different network interface %q, is sysfs mounted from the correct network namespace?",
+		l.Attrs().Name)
+	return path
+}
@@ -0,0 +1,80 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package link
+
+import (
+	"os"
+	"strings"
+	"time"
+
+	"github.com/thediveo/notwork/mntns"
+	"github.com/thediveo/notwork/netns"
+	"github.com/vishvananda/netlink"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gleak"
+	. "github.com/thediveo/fdooze"
+	. "github.com/thediveo/success"
+)
+
+var _ = Describe("sysfs paths of network interfaces", func() {
+
+	BeforeEach(func() {
+		if os.Getuid() != 0 {
+			Skip("needs root")
+		}
+		goodfds := Filedescriptors()
+		goodgos := Goroutines()
+		DeferCleanup(func() {
+			Eventually(Goroutines).Within(2 * time.Second).ProbeEvery(250 * time.Millisecond).
+				ShouldNot(HaveLeaked(goodgos))
+			Expect(Filedescriptors()).NotTo(HaveLeakedFds(goodfds))
+		})
+	})
+
+	It("returns the sysfs path of a network interface", func() {
+		defer netns.EnterTransient()()
+		veth := NewTransient(&netlink.Veth{PeerName: "peer"}, "veth-", WithMTU(1400))
+
+		mntnsfd, procfsroot := mntns.NewTransient()
+		mntns.Execute(mntnsfd, func() {
+			mntns.MountSysfsRO()
+		})
+
+		path := SysfsPath(veth, procfsroot)
+		Expect(path).To(Equal(procfsroot + "/sys/class/net/" + veth.Attrs().Name))
+		Expect(strings.TrimSpace(string(Successful(os.ReadFile(path + "/mtu"))))).To(Equal("1400"))
+	})
+
+	It("rejects a sysfs from a different network namespace", func() {
+		netnsfd := netns.NewTransient()
+		veth := NewTransient(&netlink.Veth{
+			LinkAttrs: netlink.LinkAttrs{Namespace: netlink.NsFd(netnsfd)},
+			PeerName:  "peer",
+		}, "veth-")
+
+		defer netns.EnterTransient()()
+		mntnsfd, procfsroot := mntns.NewTransient()
+		mntns.Execute(mntnsfd, func() {
+			mntns.MountSysfsRO()
+		})
+
+		Expect(InterceptGomegaFailure(func() {
+			_ = SysfsPath(veth, procfsroot)
+		})).To(MatchError(ContainSubstring("is sysfs mounted from the correct network namespace?")))
+	})
+
+})
@@ -0,0 +1,129 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package link
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+
+	. "github.com/onsi/ginkgo/v2" //lint:ignore ST1001 rule does not apply
+	. "github.com/onsi/gomega"    //lint:ignore ST1001 rule does not apply
+)
+
+// AttachTCBPF attaches the already loaded TC BPF program referenced by progFd
+// to either the ingress or egress of the specified network interface, in
+// “direct action” mode. AttachTCBPF first adds a clsact qdisc to the network
+// interface, unless it already has one, and then adds a BPF filter with the
+// next available priority. All this happens in the network namespace referenced
+// by l.Attrs().Namespace; if unset, in the current network namespace.
+//
+// AttachTCBPF schedules a DeferCleanup to remove the BPF filter again, as well
+// as the clsact qdisc if AttachTCBPF added it. The caller remains responsible
+// for the program fd.
+func AttachTCBPF(l netlink.Link, progFd int, ingress bool) {
+	GinkgoHelper()
+
+	Expect(l).NotTo(BeNil(), "need a non-nil link description")
+
+	// resolve an unset network namespace reference immediately, as the
+	// deferred cleanup might run when the caller has already switched back
+	// into a different network namespace.
+	nlh, err := layerHandle(l)
+	Expect(err).NotTo(HaveOccurred())
+	clsact := &netlink.Clsact{
+		QdiscAttrs: netlink.QdiscAttrs{
+			LinkIndex: l.Attrs().Index,
+			Handle:    netlink.MakeHandle(0xffff, 0),
+			Parent:    netlink.HANDLE_CLSACT,
+		},
+	}
+	addedQdisc := true
+	if err := nlh.QdiscAdd(clsact); err != nil {
+		if !errors.Is(err, os.ErrExist) {
+			nlh.Close()
+			Expect(err).NotTo(HaveOccurred(),
+				"cannot add clsact qdisc to network interface %q", l.Attrs().Name)
+		}
+		addedQdisc = false
+	}
+
+	var parent uint32 = netlink.HANDLE_MIN_EGRESS
+	direction := "egress"
+	if ingress {
+		parent = netlink.HANDLE_MIN_INGRESS
+		direction = "ingress"
+	}
+	filter := &netlink.BpfFilter{
+		FilterAttrs: netlink.FilterAttrs{
+			LinkIndex: l.Attrs().Index,
+			Parent:    parent,
+			Handle:    netlink.MakeHandle(0, 1),
+			Protocol:  unix.ETH_P_ALL,
+		},
+		Fd:           progFd,
+		Name:         "notwork",
+		DirectAction: true,
+	}
+	filter.Priority, err = nextFilterPriority(nlh, l, parent)
+	if err == nil {
+		err = nlh.FilterAdd(filter)
+	}
+	if err != nil {
+		if addedQdisc {
+			_ = nlh.QdiscDel(clsact)
+		}
+		nlh.Close()
+		Expect(err).NotTo(HaveOccurred(),
+			"cannot attach BPF program to %s of network interface %q", direction, l.Attrs().Name)
+	}
+
+	DeferCleanup(func() {
+		defer nlh.Close()
+		// when the network interface has already gone, so has its clsact
+		// qdisc including the BPF filter.
+		if _, err := nlh.LinkByIndex(l.Attrs().Index); err != nil {
+			return
+		}
+		By(fmt.Sprintf("detaching BPF program from %s of network interface %q",
+			direction, l.Attrs().Name))
+		if addedQdisc {
+			// removing the clsact qdisc also removes all its filters.
+			Expect(nlh.QdiscDel(clsact)).To(Succeed(),
+				"cannot remove clsact qdisc from network interface %q", l.Attrs().Name)
+			return
+		}
+		Expect(nlh.FilterDel(filter)).To(Succeed(),
+			"cannot remove BPF filter from network interface %q", l.Attrs().Name)
+	})
+}
+
+// nextFilterPriority returns the next available (lowest) filter priority at
+// the specified parent of the network interface l; that is, one more than the
+// highest priority in use.
+func nextFilterPriority(nlh *netlink.Handle, l netlink.Link, parent uint32) (uint16, error) {
+	filters, err := nlh.FilterList(l, parent)
+	if err != nil {
+		return 0, fmt.Errorf("cannot list filters, reason: %w", err)
+	}
+	var prio uint16
+	for _, filter := range filters {
+		prio = max(prio, filter.Attrs().Priority)
+	}
+	return prio + 1, nil
+}
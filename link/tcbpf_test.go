@@ -0,0 +1,160 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package link
+
+import (
+	"os"
+	"time"
+	"unsafe"
+
+	"github.com/thediveo/notwork/netns"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gleak"
+	. "github.com/thediveo/fdooze"
+	. "github.com/thediveo/success"
+)
+
+// loadTCBPF loads a trivial TC BPF program that lets all packets pass,
+// returning the program fd. It schedules a DeferCleanup to close the fd.
+func loadTCBPF() int {
+	GinkgoHelper()
+
+	insns := []uint64{
+		0x00000000000000b7, // mov r0, 0 (TC_ACT_OK)
+		0x0000000000000095, // exit
+	}
+	license := []byte("GPL\x00")
+	attr := netlink.BPFAttr{
+		ProgType: uint32(netlink.BPF_PROG_TYPE_SCHED_CLS),
+		InsnCnt:  uint32(len(insns)),
+		Insns:    uintptr(unsafe.Pointer(&insns[0])),
+		License:  uintptr(unsafe.Pointer(&license[0])),
+	}
+	fd, _, errno := unix.Syscall(unix.SYS_BPF,
+		5, /* BPF_PROG_LOAD */
+		uintptr(unsafe.Pointer(&attr)), unsafe.Sizeof(attr))
+	if errno != 0 {
+		Skip("cannot load TC BPF program, reason: " + errno.Error())
+	}
+	DeferCleanup(func() {
+		_ = unix.Close(int(fd))
+	})
+	return int(fd)
+}
+
+var _ = Describe("attaching TC BPF programs", func() {
+
+	BeforeEach(func() {
+		if os.Getuid() != 0 {
+			Skip("needs root")
+		}
+
+		goodfds := Filedescriptors()
+		goodgos := Goroutines()
+		DeferCleanup(func() {
+			Eventually(Goroutines).Within(2 * time.Second).ProbeEvery(100 * time.Millisecond).
+				ShouldNot(HaveLeaked(goodgos))
+			Expect(Filedescriptors()).NotTo(HaveLeakedFds(goodfds))
+		})
+	})
+
+	It("attaches at ingress and egress and cleans up", func() {
+		progfd := loadTCBPF()
+		netnsfd := netns.NewTransient()
+		nlh := netns.NewNetlinkHandle(netnsfd)
+		veth := NewTransient(&netlink.Veth{
+			LinkAttrs: netlink.LinkAttrs{
+				Namespace: netlink.NsFd(netnsfd),
+			},
+			PeerNamespace: netlink.NsFd(netnsfd),
+		}, "veth-")
+
+		By("attaching in a separate scope", func() {
+			DeferCleanup(func() {
+				Expect(Successful(nlh.QdiscList(veth))).NotTo(ContainElement(
+					HaveField("Type()", "clsact")))
+			})
+			AttachTCBPF(veth, progfd, true)
+			AttachTCBPF(veth, progfd, false)
+			AttachTCBPF(veth, progfd, false)
+			Expect(Successful(nlh.QdiscList(veth))).To(ContainElement(
+				HaveField("Type()", "clsact")))
+			Expect(Successful(nlh.FilterList(veth, netlink.HANDLE_MIN_INGRESS))).To(ConsistOf(
+				And(HaveField("Type()", "bpf"), HaveField("DirectAction", true))))
+			Expect(Successful(nlh.FilterList(veth, netlink.HANDLE_MIN_EGRESS))).To(ConsistOf(
+				HaveField("Attrs().Priority", uint16(1)),
+				HaveField("Attrs().Priority", uint16(2))))
+		})
+	})
+
+	It("keeps an existing clsact qdisc", func() {
+		progfd := loadTCBPF()
+		netnsfd := netns.NewTransient()
+		nlh := netns.NewNetlinkHandle(netnsfd)
+		veth := NewTransient(&netlink.Veth{
+			LinkAttrs: netlink.LinkAttrs{
+				Namespace: netlink.NsFd(netnsfd),
+			},
+			PeerNamespace: netlink.NsFd(netnsfd),
+		}, "veth-")
+		Expect(nlh.QdiscAdd(&netlink.Clsact{
+			QdiscAttrs: netlink.QdiscAttrs{
+				LinkIndex: veth.Attrs().Index,
+				Handle:    netlink.MakeHandle(0xffff, 0),
+				Parent:    netlink.HANDLE_CLSACT,
+			},
+		})).To(Succeed())
+
+		By("attaching in a separate scope", func() {
+			DeferCleanup(func() {
+				Expect(Successful(nlh.QdiscList(veth))).To(ContainElement(
+					HaveField("Type()", "clsact")))
+				Expect(Successful(nlh.FilterList(veth, netlink.HANDLE_MIN_INGRESS))).To(BeEmpty())
+			})
+			AttachTCBPF(veth, progfd, true)
+			Expect(Successful(nlh.FilterList(veth, netlink.HANDLE_MIN_INGRESS))).To(HaveLen(1))
+		})
+	})
+
+	It("cleans up in the original network namespace", func() {
+		progfd := loadTCBPF()
+		netnsfd := netns.NewTransient()
+		nlh := netns.NewNetlinkHandle(netnsfd)
+		var veth netlink.Link
+		DeferCleanup(func() {
+			Expect(Successful(nlh.QdiscList(veth))).NotTo(ContainElement(
+				HaveField("Type()", "clsact")))
+		})
+		netns.Execute(netnsfd, func() {
+			veth = NewTransient(&netlink.Veth{}, "veth-")
+			AttachTCBPF(veth, progfd, false)
+		})
+	})
+
+	It("fails for an invalid program fd", func() {
+		defer netns.EnterTransient()()
+		veth := NewTransient(&netlink.Veth{}, "veth-")
+		Expect(InterceptGomegaFailure(func() {
+			AttachTCBPF(veth, -1, true)
+		})).To(MatchError(ContainSubstring("cannot attach BPF program to ingress")))
+		Expect(Successful(netlink.QdiscList(veth))).NotTo(ContainElement(
+			HaveField("Type()", "clsact")))
+	})
+
+})
@@ -0,0 +1,82 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package link
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"github.com/thediveo/notwork/internal/wait"
+
+	. "github.com/onsi/ginkgo/v2" //lint:ignore ST1001 rule does not apply
+	. "github.com/onsi/gomega"    //lint:ignore ST1001 rule does not apply
+)
+
+// DialTCP attempts to connect via TCP from the network namespace referenced by
+// fromNetnsfd to the specified address in “host:port” notation, returning nil
+// if the TCP handshake succeeded, otherwise the last error encountered.
+// DialTCP immediately closes a successfully established connection again.
+//
+// DialTCP keeps retrying until it either succeeds or the maximum wait duration
+// has passed, which can be optionally specified and defaults to
+// [DefaultUpTimeout]. This takes care of network interfaces and routes still
+// settling.
+func DialTCP(fromNetnsfd int, addr string, within ...time.Duration) error {
+	GinkgoHelper()
+
+	atmost := wait.Duration(within, DefaultUpTimeout)
+
+	deadline := time.Now().Add(atmost)
+	for {
+		err := inNetns(fromNetnsfd, func() error {
+			conn, err := net.DialTimeout("tcp", addr, max(time.Until(deadline), time.Millisecond))
+			if err != nil {
+				return err
+			}
+			return conn.Close()
+		})
+		if err == nil || time.Now().Add(20*time.Millisecond).After(deadline) {
+			return err
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+// ListenTCP creates a TCP listener in the network namespace referenced by
+// inNetnsfd on the specified address in “host:port” notation, returning the
+// listener as well as a function to close the listener. Specifying port 0
+// picks a random available port; use Addr() of the returned listener to find
+// out the port actually used.
+//
+// ListenTCP schedules a DeferCleanup to close the listener, so calling the
+// returned close function is only necessary in order to close the listener
+// earlier; it is safe to call it multiple times.
+//
+// Please note that the kernel completes TCP handshakes for the listener even
+// if nobody accepts the connections, so this is sufficient for [DialTCP] to
+// succeed.
+func ListenTCP(inNetnsfd int, addr string) (net.Listener, func()) {
+	GinkgoHelper()
+
+	var l net.Listener
+	Expect(inNetns(inNetnsfd, func() (err error) {
+		l, err = net.Listen("tcp", addr)
+		return
+	})).To(Succeed(), "cannot listen on %s", addr)
+	closer := sync.OnceFunc(func() { _ = l.Close() })
+	DeferCleanup(closer)
+	return l, closer
+}
@@ -0,0 +1,86 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package link
+
+import (
+	"net"
+	"os"
+	"time"
+
+	"github.com/thediveo/notwork/netns"
+	"github.com/vishvananda/netlink"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gleak"
+	. "github.com/thediveo/fdooze"
+	. "github.com/thediveo/success"
+)
+
+var _ = Describe("TCP connectivity", func() {
+
+	BeforeEach(func() {
+		if os.Getuid() != 0 {
+			Skip("needs root")
+		}
+
+		goodfds := Filedescriptors()
+		goodgos := Goroutines()
+		DeferCleanup(func() {
+			Eventually(Goroutines).Within(2 * time.Second).ProbeEvery(100 * time.Millisecond).
+				ShouldNot(HaveLeaked(goodgos))
+			Expect(Filedescriptors()).NotTo(HaveLeakedFds(goodfds))
+		})
+	})
+
+	It("doesn't accept multiple optional durations", func() {
+		Expect(func() {
+			_ = DialTCP(0, "127.0.0.1:1", time.Millisecond, time.Millisecond)
+		}).To(PanicWith(ContainSubstring("single optional maximum wait duration")))
+	})
+
+	It("connects within a network namespace", func() {
+		netnsfd := netns.NewTransient()
+		netns.LoUpIn(netnsfd)
+
+		l, closer := ListenTCP(netnsfd, "127.0.0.1:0")
+		Expect(DialTCP(netnsfd, l.Addr().String())).To(Succeed())
+
+		closer()
+		Expect(closer).NotTo(Panic())
+		Expect(DialTCP(netnsfd, l.Addr().String(), 100*time.Millisecond)).NotTo(Succeed())
+	})
+
+	It("connects across network namespaces via a router", func() {
+		leftfd := netns.NewTransient()
+		rightfd := netns.NewTransient()
+		_, _, _, _ = netns.Router(leftfd, rightfd)
+
+		rightIP := Successful(netlink.ParseAddr(netns.RightAddr)).IP
+		l, _ := ListenTCP(rightfd, net.JoinHostPort(rightIP.String(), "0"))
+		Expect(DialTCP(leftfd, l.Addr().String())).To(Succeed())
+
+		By("not connecting from an unconnected network namespace")
+		Expect(DialTCP(netns.NewTransient(), l.Addr().String(), 100*time.Millisecond)).NotTo(Succeed())
+	})
+
+	It("fails in an invalid network namespace", func() {
+		Expect(DialTCP(-1, "127.0.0.1:1", 10*time.Millisecond)).To(
+			MatchError(ContainSubstring("cannot switch into network namespace")))
+		Expect(InterceptGomegaFailure(func() { _, _ = ListenTCP(-1, "127.0.0.1:0") })).To(
+			MatchError(ContainSubstring("cannot listen on 127.0.0.1:0")))
+	})
+
+})
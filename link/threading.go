@@ -0,0 +1,92 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package link
+
+import (
+	"fmt"
+
+	"github.com/thediveo/notwork/internal/initialns"
+	"golang.org/x/sys/unix"
+
+	. "github.com/onsi/ginkgo/v2" //lint:ignore ST1001 rule does not apply
+)
+
+// StrictThreading enables best-effort checks that detect when [NewTransient] or
+// [EnsureUp] get called from a Go routine that isn't locked to its OS-level
+// thread, while network namespaces are involved. Such Go routines might get
+// migrated by the Go scheduler to a different OS-level thread attached to a
+// different network namespace at any time, leading to subtle and intermittent
+// failures.
+//
+// When enabled, NewTransient and EnsureUp fail the current test if the calling
+// Go routine got migrated to a different OS-level thread during the operation,
+// unless both threads are attached to the initial network namespace of the
+// process; and also if the thread's network namespace changed in the meantime.
+// As the checks can only detect actual migrations, they are a debugging aid,
+// not a guarantee.
+var StrictThreading = false
+
+// threadContext identifies the OS-level thread of the calling Go routine,
+// together with the network namespace the thread is attached to.
+type threadContext struct {
+	tid      int
+	netnsIno uint64
+}
+
+// currentThreadContext returns the context of the current OS-level thread; it
+// is a variable in order to allow tests to simulate Go routine migrations.
+var currentThreadContext = func() threadContext {
+	var netnsStat unix.Stat_t
+	_ = unix.Stat("/proc/thread-self/ns/net", &netnsStat)
+	return threadContext{tid: unix.Gettid(), netnsIno: netnsStat.Ino}
+}
+
+// threadingCheck captures the current thread context when [StrictThreading] is
+// enabled and returns a function that later checks that the calling Go routine
+// hasn't been migrated in a harmful way in the meantime. When StrictThreading is
+// disabled, the returned function does nothing.
+func threadingCheck(op string) func() {
+	if !StrictThreading {
+		return func() {}
+	}
+	entry := currentThreadContext()
+	return func() {
+		GinkgoHelper()
+
+		now := currentThreadContext()
+		if now.tid == entry.tid && now.netnsIno == entry.netnsIno {
+			return
+		}
+		if now.netnsIno == entry.netnsIno && entry.netnsIno == initialNetnsIno() {
+			return // migrated, but harmlessly so.
+		}
+		fail(fmt.Sprintf("%s called from a Go routine not locked to its OS-level thread: "+
+			"started on thread %d in network namespace net:[%d], "+
+			"but ended on thread %d in network namespace net:[%d]; "+
+			"please use runtime.LockOSThread when switching network namespaces",
+			op, entry.tid, entry.netnsIno, now.tid, now.netnsIno))
+	}
+}
+
+// initialNetnsIno returns the inode number of the initial network namespace of
+// this process, or 0 if unknown.
+func initialNetnsIno() uint64 {
+	var netnsStat unix.Stat_t
+	netnsfd := initialns.Fd()
+	if netnsfd < 0 || unix.Fstat(netnsfd, &netnsStat) != nil {
+		return 0
+	}
+	return netnsStat.Ino
+}
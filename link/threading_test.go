@@ -0,0 +1,105 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package link
+
+import (
+	"os"
+	"time"
+
+	"github.com/thediveo/notwork/netns"
+	"github.com/vishvananda/netlink"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gleak"
+	. "github.com/thediveo/fdooze"
+)
+
+var _ = Describe("strict threading", func() {
+
+	BeforeEach(func() {
+		if os.Getuid() != 0 {
+			Skip("needs root")
+		}
+
+		goodfds := Filedescriptors()
+		goodgos := Goroutines()
+		DeferCleanup(func() {
+			Eventually(Goroutines).Within(2 * time.Second).ProbeEvery(100 * time.Millisecond).
+				ShouldNot(HaveLeaked(goodgos))
+			Expect(Filedescriptors()).NotTo(HaveLeakedFds(goodfds))
+		})
+
+		oldStrict := StrictThreading
+		oldCurrent := currentThreadContext
+		DeferCleanup(func() {
+			StrictThreading = oldStrict
+			currentThreadContext = oldCurrent
+		})
+	})
+
+	// simulateMigration returns thread contexts that alternate between two
+	// different OS-level threads, optionally in different network namespaces.
+	simulateMigration := func(fromNetnsIno, toNetnsIno uint64) {
+		calls := 0
+		currentThreadContext = func() threadContext {
+			calls++
+			if calls%2 == 1 {
+				return threadContext{tid: 1, netnsIno: fromNetnsIno}
+			}
+			return threadContext{tid: 2, netnsIno: toNetnsIno}
+		}
+	}
+
+	It("doesn't check unless enabled", func() {
+		defer netns.EnterTransient()()
+		simulateMigration(1, 2)
+		Expect(func() { _ = NewTransient(&netlink.Veth{}, "veth-") }).NotTo(Panic())
+	})
+
+	It("accepts harmless migrations in the initial network namespace", func() {
+		defer netns.EnterTransient()()
+		StrictThreading = true
+		simulateMigration(initialNetnsIno(), initialNetnsIno())
+		Expect(func() { _ = NewTransient(&netlink.Veth{}, "veth-") }).NotTo(Panic())
+	})
+
+	It("detects migrations when network namespaces are involved", func() {
+		defer netns.EnterTransient()()
+		StrictThreading = true
+		simulateMigration(netns.CurrentIno(), initialNetnsIno())
+
+		oldfail := fail
+		defer func() { fail = oldfail }()
+		var msg string
+		fail = func(message string, callerSkip ...int) {
+			msg = message
+			panic("canary")
+		}
+		Expect(func() { _ = NewTransient(&netlink.Veth{}, "veth-") }).To(PanicWith("canary"))
+		Expect(msg).To(ContainSubstring("link.NewTransient called from a Go routine not locked to its OS-level thread"))
+	})
+
+	It("passes on a locked Go routine", func() {
+		defer netns.EnterTransient()()
+		StrictThreading = true
+		veth := NewTransient(&netlink.Veth{}, "veth-").(*netlink.Veth)
+		peer, err := netlink.LinkByName(veth.PeerName)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(netlink.LinkSetUp(peer)).To(Succeed())
+		EnsureUp(veth)
+	})
+
+})
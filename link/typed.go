@@ -0,0 +1,45 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package link
+
+import (
+	"fmt"
+
+	"github.com/vishvananda/netlink"
+
+	. "github.com/onsi/ginkgo/v2" //lint:ignore ST1001 rule does not apply
+)
+
+// NewTransientTyped works like [NewTransient], but additionally returns the
+// newly created link in form of its concrete type, such as *[netlink.Veth], so
+// that callers can access kind-specific fields without type assertions of
+// their own.
+//
+//	veth, l := link.NewTransientTyped(&netlink.Veth{}, "veth-")
+//	peername := veth.PeerName
+//
+// The template must be of a concrete link type, not a wrapped [Link]; use
+// options instead to pass additional configuration.
+func NewTransientTyped[T netlink.Link](template T, prefix string, opts ...Opt) (T, netlink.Link) {
+	GinkgoHelper()
+
+	l := NewTransient(template, prefix, opts...)
+	typed, ok := l.(T)
+	if !ok {
+		fail(fmt.Sprintf("transient network interface %q is of type %T, but expected %T",
+			l.Attrs().Name, l, template))
+	}
+	return typed, l
+}
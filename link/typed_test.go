@@ -0,0 +1,69 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package link
+
+import (
+	"os"
+	"time"
+
+	"github.com/thediveo/notwork/netns"
+	"github.com/vishvananda/netlink"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gleak"
+	. "github.com/thediveo/fdooze"
+	. "github.com/thediveo/success"
+)
+
+var _ = Describe("typed transient network interfaces", func() {
+
+	BeforeEach(func() {
+		if os.Getuid() != 0 {
+			Skip("needs root")
+		}
+
+		goodfds := Filedescriptors()
+		goodgos := Goroutines()
+		DeferCleanup(func() {
+			Eventually(Goroutines).Within(2 * time.Second).ProbeEvery(100 * time.Millisecond).
+				ShouldNot(HaveLeaked(goodgos))
+			Expect(Filedescriptors()).NotTo(HaveLeakedFds(goodfds))
+		})
+	})
+
+	It("returns the concrete type", func() {
+		defer netns.EnterTransient()()
+
+		veth, l := NewTransientTyped(&netlink.Veth{}, "veth-", WithAddr("10.0.0.1/24"))
+		Expect(veth).To(BeIdenticalTo(l))
+		Expect(veth.PeerName).To(HavePrefix("veth-"))
+		Expect(netlink.LinkByName(veth.PeerName)).Error().NotTo(HaveOccurred())
+		Expect(Successful(netlink.AddrList(l, netlink.FAMILY_V4))).To(
+			ContainElement(HaveField("IPNet.String()", "10.0.0.1/24")))
+	})
+
+	It("rejects wrapped templates", func() {
+		defer netns.EnterTransient()()
+
+		oldfail := fail
+		defer func() { fail = oldfail }()
+		fail = func(message string, callerSkip ...int) { panic(message) }
+		Expect(func() {
+			_, _ = NewTransientTyped(&Link{Link: &netlink.Veth{}}, "veth-")
+		}).To(PanicWith(ContainSubstring("is of type *netlink.Veth, but expected *link.Link")))
+	})
+
+})
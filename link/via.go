@@ -0,0 +1,82 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package link
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/vishvananda/netlink"
+
+	. "github.com/onsi/ginkgo/v2" //lint:ignore ST1001 rule does not apply
+	. "github.com/onsi/gomega"    //lint:ignore ST1001 rule does not apply
+)
+
+// NewTransientVia creates a transient network interface of the specified type
+// using the caller-provided netlink handle h, with a name beginning with the
+// given prefix and a random string, similar to [NewTransient]. The network
+// interface gets created in the network namespace of h, so the template must
+// not reference any network namespace itself.
+//
+// NewTransientVia uses the same handle h in the scheduled removal of the
+// transient network interface, so the caller must keep h open until after
+// the Ginkgo deferred cleanup has run; for instance, by scheduling h's closing
+// using DeferCleanup before calling NewTransientVia. This allows test suites
+// already holding a netlink handle to avoid the overhead of NewTransient
+// opening its own netlink handles.
+//
+// In contrast to NewTransient, NewTransientVia doesn't support configuration
+// options and doesn't track the transient network interface for moving it into
+// a different network namespace using [MoveToNamespace].
+func NewTransientVia(h *netlink.Handle, template netlink.Link, prefix string) netlink.Link {
+	GinkgoHelper()
+
+	Expect(h).NotTo(BeNil(), "need a non-nil netlink handle")
+	Expect(template).NotTo(BeNil(), "need a non-nil link description")
+	if len(prefix) > maxNifnameLen-minRandomLen {
+		fail(fmt.Sprintf("network interface name prefix %q passed by %s is %d characters long, but max. %d characters allowed",
+			prefix, callerName(), len(prefix), maxNifnameLen-minRandomLen))
+	}
+	template, _ = Unwrap(template)
+	if template.Attrs().Namespace != nil {
+		fail("link.Attrs().Namespace reference must be nil, as the netlink handle determines the network namespace")
+	}
+	link := deepCopy(template)
+
+	for attempt := 1; attempt <= 10; attempt++ {
+		link.Attrs().Name = base62Nifname(prefix)
+		if veth, ok := link.(*netlink.Veth); ok {
+			veth.PeerName = base62Nifname(prefix)
+		}
+		if err := h.LinkAdd(link); err != nil {
+			if errors.Is(err, os.ErrExist) {
+				continue
+			}
+			fail(fmt.Sprintf("cannot create a transient network interface of type %q, reason: %v", link.Type(), err))
+		}
+		By(fmt.Sprintf("creating a transient network interface %q", link.Attrs().Name))
+		DeferCleanup(func() {
+			By(fmt.Sprintf("removing transient network interface %q", link.Attrs().Name))
+			Expect(h.LinkDel(link)).To(Succeed(), "cannot remove transient network interface %q", link.Attrs().Name)
+		})
+		created, err := h.LinkByName(link.Attrs().Name)
+		Expect(err).NotTo(HaveOccurred(), "cannot determine network interface index after creation")
+		link.Attrs().Index = created.Attrs().Index
+		return link
+	}
+	fail(fmt.Sprintf("too many failed attempts to create a transient network interface of type %q", link.Type()))
+	return nil // not reachable
+}
@@ -0,0 +1,80 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package link
+
+import (
+	"os"
+	"time"
+
+	"github.com/thediveo/notwork/netns"
+	"github.com/vishvananda/netlink"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gleak"
+	. "github.com/thediveo/fdooze"
+	. "github.com/thediveo/success"
+)
+
+var _ = Describe("creating network interfaces via caller-provided netlink handles", func() {
+
+	BeforeEach(func() {
+		if os.Getuid() != 0 {
+			Skip("needs root")
+		}
+
+		goodfds := Filedescriptors()
+		goodgos := Goroutines()
+		DeferCleanup(func() {
+			Eventually(Goroutines).Within(2 * time.Second).ProbeEvery(100 * time.Millisecond).
+				ShouldNot(HaveLeaked(goodgos))
+			Expect(Filedescriptors()).NotTo(HaveLeakedFds(goodfds))
+		})
+	})
+
+	It("creates a transient network interface in the handle's network namespace", func() {
+		netnsfd := netns.NewTransient()
+		nlh := netns.NewNetlinkHandle(netnsfd)
+		var veth netlink.Link
+		DeferCleanup(func() {
+			Expect(nlh.LinkByName(veth.Attrs().Name)).Error().To(HaveOccurred(),
+				"transient network interface wasn't removed")
+		})
+
+		template := &netlink.Veth{}
+		veth = NewTransientVia(nlh, template, "veth-")
+		Expect(template.Attrs().Name).To(BeEmpty())
+		Expect(veth.Attrs().Name).To(HavePrefix("veth-"))
+		Expect(veth.(*netlink.Veth).PeerName).To(HavePrefix("veth-"))
+		l := Successful(nlh.LinkByName(veth.Attrs().Name))
+		Expect(veth.Attrs().Index).To(Equal(l.Attrs().Index))
+		Expect(netlink.LinkByName(veth.Attrs().Name)).Error().To(HaveOccurred())
+	})
+
+	It("rejects templates with network namespace references", func() {
+		oldfail := fail
+		defer func() { fail = oldfail }()
+		fail = func(message string, callerSkip ...int) { panic(message) }
+
+		netnsfd := netns.NewTransient()
+		nlh := netns.NewNetlinkHandle(netnsfd)
+		Expect(func() {
+			_ = NewTransientVia(nlh, &netlink.Veth{
+				LinkAttrs: netlink.LinkAttrs{Namespace: netlink.NsFd(netnsfd)},
+			}, "veth-")
+		}).To(PanicWith(ContainSubstring("must be nil")))
+	})
+
+})
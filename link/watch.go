@@ -0,0 +1,175 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package link
+
+import (
+	"errors"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netlink/nl"
+	"golang.org/x/sys/unix"
+
+	. "github.com/onsi/ginkgo/v2" //lint:ignore ST1001 rule does not apply
+	. "github.com/onsi/gomega"    //lint:ignore ST1001 rule does not apply
+)
+
+// watchPollInterval is the maximum time the watcher Go routine waits for link
+// updates before checking whether it has been cancelled.
+const watchPollInterval = 100 * time.Millisecond
+
+// NewTransientWatched creates a transient network interface like
+// [NewTransient], but additionally returns a channel receiving the link
+// updates for the newly created network interface, as well as a function to
+// cancel watching. NewTransientWatched subscribes to link updates in the
+// destination network namespace before creating the network interface, so the
+// initial RTM_NEWLINK update of the creation is guaranteed to be received.
+//
+// The returned channel is unbuffered, so updates are delivered only while the
+// caller receives from it. The channel gets closed after watching has been
+// cancelled. The returned cancel function is safe to be called multiple times;
+// NewTransientWatched additionally schedules a DeferCleanup to cancel watching,
+// so calling the cancel function is optional.
+func NewTransientWatched(template netlink.Link, prefix string, opts ...Opt) (netlink.Link, <-chan netlink.LinkUpdate, func()) {
+	GinkgoHelper()
+
+	Expect(template).NotTo(BeNil(), "need a non-nil link description")
+
+	// Apply the options first, as they might change the destination network
+	// namespace we need to subscribe in.
+	template = EnsureWrap(template)
+	for _, opt := range opts {
+		Expect(opt(template.(*Link))).To(Succeed(), "invalid option")
+	}
+
+	// The netlink socket stays attached to the network namespace it was
+	// created in, so we need to switch only while creating and binding it.
+	fd := -1
+	err := inLinkNetns(template, func() error {
+		sock, err := unix.Socket(unix.AF_NETLINK, unix.SOCK_RAW|unix.SOCK_CLOEXEC, unix.NETLINK_ROUTE)
+		if err != nil {
+			return err
+		}
+		if err := unix.Bind(sock, &unix.SockaddrNetlink{
+			Family: unix.AF_NETLINK,
+			Groups: unix.RTMGRP_LINK,
+		}); err != nil {
+			unix.Close(sock)
+			return err
+		}
+		fd = sock
+		return nil
+	})
+	Expect(err).NotTo(HaveOccurred(), "cannot subscribe to link updates")
+
+	done := make(chan struct{})
+	ifindexCh := make(chan int, 1)
+	updates := make(chan netlink.LinkUpdate)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		watchLinks(fd, ifindexCh, updates, done)
+	}()
+	cancel := sync.OnceFunc(func() {
+		close(done)
+		wg.Wait()
+		_ = unix.Close(fd)
+	})
+	DeferCleanup(cancel)
+
+	link := NewTransient(template, prefix)
+	ifindexCh <- link.Attrs().Index
+	return link, updates, cancel
+}
+
+// watchLinks receives link updates from the netlink socket fd and sends those
+// updates for the network interface with the index received from ifindexCh to
+// the updates channel, until done gets closed. As the index is known only
+// after creating the network interface, watchLinks queues updates until then.
+// watchLinks finally closes the updates channel.
+func watchLinks(fd int, ifindexCh <-chan int, updates chan<- netlink.LinkUpdate, done <-chan struct{}) {
+	defer close(updates)
+
+	ifindex := 0
+	var pending []netlink.LinkUpdate
+	buff := make([]byte, 65536)
+	for {
+		select {
+		case <-done:
+			return
+		case ifindex = <-ifindexCh:
+			for _, update := range pending {
+				if int(update.Index) != ifindex {
+					continue
+				}
+				select {
+				case updates <- update:
+				case <-done:
+					return
+				}
+			}
+			pending = nil
+		default:
+		}
+
+		pollfds := []unix.PollFd{{Fd: int32(fd), Events: unix.POLLIN}}
+		n, err := unix.Poll(pollfds, int(watchPollInterval.Milliseconds()))
+		if n <= 0 || err != nil {
+			continue // timeout or interrupted
+		}
+		size, _, err := unix.Recvfrom(fd, buff, unix.MSG_DONTWAIT)
+		if err != nil {
+			// Silently skip over lost updates, as well as spurious wakeups.
+			if errors.Is(err, unix.ENOBUFS) || errors.Is(err, unix.EAGAIN) || errors.Is(err, unix.EINTR) {
+				continue
+			}
+			return
+		}
+		msgs, err := syscall.ParseNetlinkMessage(buff[:size])
+		if err != nil {
+			continue
+		}
+		for _, msg := range msgs {
+			if msg.Header.Type != unix.RTM_NEWLINK && msg.Header.Type != unix.RTM_DELLINK {
+				continue
+			}
+			header := unix.NlMsghdr(msg.Header)
+			l, err := netlink.LinkDeserialize(&header, msg.Data)
+			if err != nil {
+				continue
+			}
+			update := netlink.LinkUpdate{
+				IfInfomsg: *nl.DeserializeIfInfomsg(msg.Data),
+				Header:    header,
+				Link:      l,
+			}
+			if ifindex == 0 {
+				pending = append(pending, update)
+				continue
+			}
+			if int(update.Index) != ifindex {
+				continue
+			}
+			select {
+			case updates <- update:
+			case <-done:
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package link
+
+import (
+	"net"
+	"os"
+	"time"
+
+	"github.com/thediveo/notwork/netns"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gleak"
+	. "github.com/thediveo/fdooze"
+)
+
+var _ = Describe("watching transient network interfaces", func() {
+
+	BeforeEach(func() {
+		if os.Getuid() != 0 {
+			Skip("needs root")
+		}
+
+		goodfds := Filedescriptors()
+		goodgos := Goroutines()
+		DeferCleanup(func() {
+			Eventually(Goroutines).Within(2 * time.Second).ProbeEvery(100 * time.Millisecond).
+				ShouldNot(HaveLeaked(goodgos))
+			Expect(Filedescriptors()).NotTo(HaveLeakedFds(goodfds))
+		})
+	})
+
+	It("receives the creation and later updates", func() {
+		netnsfd := netns.NewTransient()
+		// an unrelated network interface whose updates must not be reported.
+		other := NewTransient(&netlink.Veth{
+			LinkAttrs: netlink.LinkAttrs{Namespace: netlink.NsFd(netnsfd)},
+		}, "veth-")
+
+		veth, updates, cancel := NewTransientWatched(&netlink.Veth{
+			LinkAttrs: netlink.LinkAttrs{Namespace: netlink.NsFd(netnsfd)},
+		}, "veth-")
+
+		var update netlink.LinkUpdate
+		Eventually(updates).Within(2 * time.Second).Should(Receive(&update))
+		Expect(update.Header.Type).To(Equal(uint16(unix.RTM_NEWLINK)))
+		Expect(int(update.Index)).To(Equal(veth.Attrs().Index))
+		Expect(update.Link.Attrs().Name).To(Equal(veth.Attrs().Name))
+
+		nlh := netns.NewNetlinkHandle(netnsfd)
+		Expect(nlh.LinkSetUp(other)).To(Succeed())
+		Expect(nlh.LinkSetUp(veth)).To(Succeed())
+		Eventually(updates).Within(2 * time.Second).Should(Receive(SatisfyAll(
+			HaveField("Index", BeEquivalentTo(veth.Attrs().Index)),
+			HaveField("Link.Attrs().Flags", WithTransform(
+				func(flags net.Flags) bool { return flags&net.FlagUp != 0 }, BeTrue())))))
+		Consistently(updates).Within(250 * time.Millisecond).ShouldNot(Receive(
+			HaveField("Index", BeEquivalentTo(other.Attrs().Index))))
+
+		cancel()
+		Eventually(updates).Within(2 * time.Second).Should(BeClosed())
+		Expect(cancel).NotTo(Panic())
+	})
+
+	It("watches in the network namespace set by an option", func() {
+		veth, updates, _ := NewTransientWatched(&netlink.Veth{}, "veth-",
+			InNamespace(netns.NewTransient()))
+		Eventually(updates).Within(2 * time.Second).Should(Receive(SatisfyAll(
+			HaveField("Header.Type", BeEquivalentTo(unix.RTM_NEWLINK)),
+			HaveField("Index", BeEquivalentTo(veth.Attrs().Index)))))
+	})
+
+})
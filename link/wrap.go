@@ -27,7 +27,15 @@ import "github.com/vishvananda/netlink"
 // parent can be properly resolved.
 type Link struct {
 	netlink.Link
-	LinkNamespace any // nil | NsPid | NsFd ... we follow the netns reference pattern used in the netlink package
+	LinkNamespace any                        // nil | NsPid | NsFd ... we follow the netns reference pattern used in the netlink package
+	Addrs         []*netlink.Addr            // addresses to assign after creating the link
+	NoCleanup     bool                       // don't schedule automatic removal of the link
+	AttrsFns      []func(*netlink.LinkAttrs) // applied to the copied link attributes before creating the link
+	FixedNames    bool                       // use the name (and VETH peer name) as-is instead of random names
+	OperState     *netlink.LinkOperState     // expected initial operational state after creating the link, if any
+	NameLen       int                        // length of random names, if not the max. length
+	NoIndexFixup  bool                       // trust the interface index as reported after creating the link
+	SetupFns      []func(netlink.Link) error // applied after creating the link
 }
 
 var _ (netlink.Link) = (*Link)(nil)
@@ -15,17 +15,29 @@
 package macvlan
 
 import (
+	"fmt"
+	"os"
+	"time"
+
 	"github.com/thediveo/notwork/link"
+	"github.com/thediveo/notwork/netns"
 	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
 
 	. "github.com/onsi/ginkgo/v2" //lint:ignore ST1001 rule does not apply
 	. "github.com/onsi/gomega"    //lint:ignore ST1001 rule does not apply
 )
 
+var fail = Fail // allow testing Fails without terminally failing the current test.
+
 // MacvlanPrefix is the name prefix used for transient MACVLAN network
 // interfaces.
 const MacvlanPrefix = "mcvl-"
 
+// MacvtapPrefix is the name prefix used for transient MACVTAP network
+// interfaces.
+const MacvtapPrefix = "mcvt-"
+
 // Opt is a configuration option when creating a new MACVLAN network interface.
 type Opt func(*link.Link) error
 
@@ -45,8 +57,7 @@ func LocateHWParent() netlink.Link {
 	Expect(err).NotTo(HaveOccurred(), "cannot retrieve list of netdevs")
 	Expect(links).To(ContainElement(
 		And(
-			HaveField("Type()", "device"),
-			HaveField("Attrs().Name", Not(Equal("lo"))),
+			WithTransform(link.IsHardware, BeTrue()),
 			HaveField("Attrs().OperState", netlink.LinkOperState(netlink.OperUp))),
 		&parents), "could not find any hardware netdev in up state")
 	// ContainElement guarantees when in filter result mode that there were
@@ -64,6 +75,122 @@ func LocateHWParent() netlink.Link {
 func NewTransient(parent netlink.Link, opts ...Opt) netlink.Link {
 	GinkgoHelper()
 
+	return link.NewTransient(newMacvlan(parent, opts...), MacvlanPrefix)
+}
+
+// NewTransientOverUplink locates a hardware network interface in the current
+// network namespace using [LocateHWParent] and creates a new (and transient)
+// MACVLAN network interface attached to this “uplink”.
+//
+// NewTransientOverUplink guarantees that the MACVLAN network interface never
+// ends up in the current network namespace, so that any addresses, routes, et
+// cetera configured on the MACVLAN cannot pollute the host networking
+// configuration. Unless the [InNamespace] option specifies a different network
+// namespace, NewTransientOverUplink creates the MACVLAN network interface in a
+// new transient network namespace. In any case, the returned link references
+// the destination network namespace via its [netlink.LinkAttrs.Namespace]. The
+// [WithLinkNamespace] option isn't supported, as the uplink is always located
+// in the current network namespace.
+func NewTransientOverUplink(opts ...Opt) netlink.Link {
+	GinkgoHelper()
+
+	mcvlan := newMacvlan(LocateHWParent(), opts...)
+	Expect(mcvlan.LinkNamespace).To(BeNil(),
+		"link namespace not supported, as the uplink is located in the current network namespace")
+	switch ref := mcvlan.Attrs().Namespace.(type) {
+	case nil:
+		mcvlan.Attrs().Namespace = netlink.NsFd(netns.NewTransient())
+	case netlink.NsFd:
+		Expect(netns.Ino(int(ref))).NotTo(Equal(netns.CurrentIno()),
+			"MACVLAN over uplink must not be created in the current network namespace")
+	case netlink.NsPid:
+		Expect(netns.Ino(fmt.Sprintf("/proc/%d/ns/net", ref))).NotTo(Equal(netns.CurrentIno()),
+			"MACVLAN over uplink must not be created in the current network namespace")
+	default:
+		fail(fmt.Sprintf("unsupported network namespace reference %T", ref))
+	}
+	return link.NewTransient(mcvlan, MacvlanPrefix)
+}
+
+// NewTransientTap creates and returns a new (and transient) MACVTAP network
+// interface attached to the specified parent network interface, together with
+// its opened “/dev/tapN” character device, where N is the interface index of
+// the MACVTAP network interface. Frames written to the returned file get sent
+// out via the MACVTAP network interface, and frames received by the MACVTAP
+// network interface can be read from the file. Frames come without any
+// additional virtio network header, as NewTransientTap switches off the
+// kernel's default of prepending such headers. The MACVTAP network interface
+// uses [netlink.MACVLAN_MODE_BRIDGE] unless configured otherwise using
+// [WithMode].
+//
+// NewTransientTap automatically defers closing the returned file, followed by
+// proper automatic removal of the MACVTAP network interface.
+//
+// Please note that the kernel names the character device after the interface
+// index only, so MACVTAP network interfaces with the same interface index in
+// different network namespaces clash with respect to their character devices.
+func NewTransientTap(parent netlink.Link, opts ...Opt) (netlink.Link, *os.File) {
+	GinkgoHelper()
+
+	mcvtap := &link.Link{
+		Link: &netlink.Macvtap{
+			Macvlan: netlink.Macvlan{
+				LinkAttrs: netlink.LinkAttrs{
+					ParentIndex: parent.Attrs().Index,
+					// A zero TX queue length would also size the MACVTAP
+					// receive ring to zero, dropping all frames; so leave
+					// the TX queue length to the kernel's default.
+					TxQLen: -1,
+				},
+				Mode: netlink.MACVLAN_MODE_BRIDGE,
+			},
+		},
+	}
+	for _, opt := range opts {
+		Expect(opt(mcvtap)).To(Succeed())
+	}
+	l := link.NewTransient(mcvtap, MacvtapPrefix)
+	// The character device node might show up only slightly delayed, so give
+	// devtmpfs a moment.
+	devpath := fmt.Sprintf("/dev/tap%d", l.Attrs().Index)
+	var tap *os.File
+	Eventually(func() (err error) {
+		tap, err = os.OpenFile(devpath, os.O_RDWR, 0)
+		return err
+	}).Within(2*time.Second).ProbeEvery(20*time.Millisecond).
+		Should(Succeed(), "cannot open MACVTAP character device %q", devpath)
+	// Avoid calling Fd() as this would switch the file into blocking mode,
+	// thus breaking read and write deadlines.
+	sc, err := tap.SyscallConn()
+	Expect(err).NotTo(HaveOccurred())
+	var ioctlErr error
+	Expect(sc.Control(func(fd uintptr) {
+		ifr, err := unix.NewIfreq("")
+		if err != nil {
+			ioctlErr = err
+			return
+		}
+		ifr.SetUint16(unix.IFF_TAP | unix.IFF_NO_PI)
+		ioctlErr = unix.IoctlIfreq(int(fd), unix.TUNSETIFF, ifr)
+	})).To(Succeed())
+	if ioctlErr != nil {
+		_ = tap.Close()
+		Expect(ioctlErr).NotTo(HaveOccurred(), "cannot switch off virtio network headers")
+	}
+	// As DeferCleanup runs the cleanup functions in reverse order of their
+	// registration, the file gets closed before the network interface gets
+	// removed.
+	DeferCleanup(func() {
+		_ = tap.Close()
+	})
+	return l, tap
+}
+
+// newMacvlan returns a new MACVLAN link description attached to the specified
+// parent network interface, with the passed configuration options applied.
+func newMacvlan(parent netlink.Link, opts ...Opt) *link.Link {
+	GinkgoHelper()
+
 	mcvlan := &link.Link{
 		Link: &netlink.Macvlan{
 			LinkAttrs: netlink.LinkAttrs{
@@ -75,7 +202,7 @@ func NewTransient(parent netlink.Link, opts ...Opt) netlink.Link {
 	for _, opt := range opts {
 		Expect(opt(mcvlan)).To(Succeed())
 	}
-	return link.NewTransient(mcvlan, MacvlanPrefix)
+	return mcvlan
 }
 
 // CreateTransient creates and returns a new (and transient) MACVLAN network
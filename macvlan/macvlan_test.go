@@ -15,11 +15,15 @@
 package macvlan
 
 import (
+	"bytes"
+	"errors"
 	"os"
 	"time"
 
 	"github.com/thediveo/notwork/dummy"
+	"github.com/thediveo/notwork/link"
 	"github.com/thediveo/notwork/netns"
+	"github.com/thediveo/notwork/veth"
 	"github.com/vishvananda/netlink"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -59,6 +63,45 @@ var _ = Describe("provides transient MACVLAN network interfaces", Ordered, func(
 		Expect(parent).NotTo(BeNil())
 	})
 
+	It("creates a MACVLAN over an uplink in an isolated network namespace", func() {
+		mcvlan := NewTransientOverUplink(WithAddr("192.0.2.1/24"))
+		Expect(netlink.LinkByName(mcvlan.Attrs().Name)).Error().To(HaveOccurred())
+		netnsfd, ok := mcvlan.Attrs().Namespace.(netlink.NsFd)
+		Expect(ok).To(BeTrue())
+		Expect(netns.Ino(int(netnsfd))).NotTo(Equal(netns.CurrentIno()))
+		nlh := netns.NewNetlinkHandle(int(netnsfd))
+		Expect(Successful(nlh.LinkByName(mcvlan.Attrs().Name))).To(
+			HaveField("Attrs().Index", mcvlan.Attrs().Index))
+
+		destNetnsfd := netns.NewTransient()
+		mcvlan = NewTransientOverUplink(InNamespace(destNetnsfd))
+		Expect(mcvlan.Attrs().Namespace).To(Equal(netlink.NsFd(destNetnsfd)))
+	})
+
+	It("refuses to create a MACVLAN over an uplink in the current network namespace", func() {
+		Expect(InterceptGomegaFailure(func() {
+			_ = NewTransientOverUplink(InNamespace(netns.Current()))
+		})).To(MatchError(ContainSubstring("must not be created in the current network namespace")))
+		Expect(InterceptGomegaFailure(func() {
+			_ = NewTransientOverUplink(func(l *link.Link) error {
+				l.Attrs().Namespace = netlink.NsPid(os.Getpid())
+				return nil
+			})
+		})).To(MatchError(ContainSubstring("must not be created in the current network namespace")))
+	})
+
+	It("rejects unsupported network namespace references for MACVLANs over an uplink", func() {
+		oldfail := fail
+		defer func() { fail = oldfail }()
+		fail = func(message string, callerSkip ...int) { panic(message) }
+		Expect(func() {
+			_ = NewTransientOverUplink(func(l *link.Link) error {
+				l.Attrs().Namespace = "foo"
+				return nil
+			})
+		}).To(PanicWith(ContainSubstring("unsupported network namespace reference string")))
+	})
+
 	It("creates a MACVLAN with its parent in a different network namespace", func() {
 		dmyNetnsfd := netns.NewTransient()
 		dmy := dummy.NewTransient(dummy.InNamespace(dmyNetnsfd))
@@ -74,4 +117,45 @@ var _ = Describe("provides transient MACVLAN network interfaces", Ordered, func(
 			HaveField("Attrs().Index", mcvlan.Attrs().Index))
 	})
 
+	It("creates MACVTAPs and passes frames between them", func() {
+		defer netns.EnterTransient()()
+
+		parent, peer := veth.NewTransient()
+		Expect(netlink.LinkSetUp(peer)).To(Succeed())
+		link.EnsureUp(parent)
+
+		tap1, file1 := NewTransientTap(parent)
+		tap2, file2 := NewTransientTap(parent, WithMode(netlink.MACVLAN_MODE_BRIDGE))
+		Expect(tap1.Attrs().Name).To(HavePrefix(MacvtapPrefix))
+		Expect(tap1).To(BeAssignableToTypeOf(&netlink.Macvtap{}))
+		link.EnsureUp(tap1)
+		link.EnsureUp(tap2)
+
+		frame := []byte{
+			0xff, 0xff, 0xff, 0xff, 0xff, 0xff, // broadcast destination
+			0x02, 0x00, 0x00, 0x00, 0x00, 0x01, // locally administered source
+			0x88, 0xb5, // local experimental ethertype
+		}
+		frame = append(frame, []byte("Hello, MACVTAP!")...)
+
+		// As both MACVTAPs are in bridge mode on the same parent, the frame
+		// sent via the first MACVTAP gets directly delivered to the second.
+		buff := make([]byte, 2048)
+		Eventually(func() []byte {
+			_ = Successful(file1.Write(frame))
+			Expect(file2.SetReadDeadline(time.Now().Add(100 * time.Millisecond))).To(Succeed())
+			for {
+				n, err := file2.Read(buff)
+				if errors.Is(err, os.ErrDeadlineExceeded) {
+					return nil
+				}
+				Expect(err).NotTo(HaveOccurred())
+				if bytes.Equal(buff[:n], frame) {
+					return buff[:n]
+				}
+			}
+		}).Within(5 * time.Second).ProbeEvery(100 * time.Millisecond).
+			Should(Equal(frame))
+	})
+
 })
@@ -38,12 +38,34 @@ func WithLinkNamespace(fdref int) Opt {
 	}
 }
 
-// WithMode configures the MACVLAN mode.
+// WithMode configures the MACVLAN or MACVTAP mode.
 //
 // See also: [netlink.MacvlanMode].
 func WithMode(mode netlink.MacvlanMode) Opt {
 	return func(l *link.Link) error {
-		l.Link.(*netlink.Macvlan).Mode = mode
+		macvlanOf(l).Mode = mode
 		return nil
 	}
 }
+
+// WithAddr configures an IPv4 or IPv6 address in CIDR notation (such as
+// “10.0.0.1/24” or “fd00::1/64”) to be assigned to a MACVLAN network
+// interface right after creation.
+func WithAddr(cidr string) Opt {
+	return Opt(link.WithAddr(cidr))
+}
+
+// WithMTU configures a MACVLAN network interface to be created with the
+// specified MTU, which must be positive and must not exceed 65535.
+func WithMTU(mtu int) Opt {
+	return Opt(link.WithMTU(mtu))
+}
+
+// macvlanOf returns the MACVLAN-specific part of either a MACVLAN or MACVTAP
+// link description.
+func macvlanOf(l *link.Link) *netlink.Macvlan {
+	if mcvtap, ok := l.Link.(*netlink.Macvtap); ok {
+		return &mcvtap.Macvlan
+	}
+	return l.Link.(*netlink.Macvlan)
+}
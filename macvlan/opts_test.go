@@ -37,4 +37,23 @@ var _ = Describe("MACVLAN configuration options", func() {
 		Expect(l.Link).To(HaveField("Mode", netlink.MACVLAN_MODE_VEPA))
 	})
 
+	It("configures the MACVTAP mode", func() {
+		l := &link.Link{Link: &netlink.Macvtap{}}
+		Expect(WithMode(netlink.MACVLAN_MODE_PRIVATE)(l)).To(Succeed())
+		Expect(l.Link).To(HaveField("Macvlan.Mode", netlink.MACVLAN_MODE_PRIVATE))
+	})
+
+	It("configures an address", func() {
+		l := &link.Link{Link: &netlink.Macvlan{}}
+		Expect(WithAddr("10.0.0.1/24")(l)).To(Succeed())
+		Expect(l.Addrs).To(ConsistOf(HaveField("IPNet.String()", "10.0.0.1/24")))
+	})
+
+	It("configures the MTU", func() {
+		l := &link.Link{Link: &netlink.Macvlan{}}
+		Expect(WithMTU(9000)(l)).To(Succeed())
+		Expect(l.Attrs().MTU).To(Equal(9000))
+		Expect(WithMTU(0)(l)).NotTo(Succeed())
+	})
+
 })
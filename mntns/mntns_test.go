@@ -20,6 +20,7 @@ import (
 	"time"
 
 	"github.com/thediveo/notwork/netns"
+	"github.com/thediveo/notwork/veth"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -63,6 +64,22 @@ var _ = Describe("transient network namespaces", Ordered, func() {
 		Expect(r).To(ContainSubstring("current mount namespace must not be the process's original mount namespace"))
 	})
 
+	It("waits for a network interface to appear in sysfs", func() {
+		defer netns.EnterTransient()()
+		mntnsfd, procfsroot := NewTransient()
+		Execute(mntnsfd, func() {
+			MountSysfsRO()
+		})
+
+		dupond, _ := veth.NewTransient()
+		WaitSysfsLink(procfsroot, dupond.Attrs().Name)
+		Expect(filepath.Join(procfsroot, "/sys/class/net", dupond.Attrs().Name)).To(BeADirectory())
+
+		Expect(InterceptGomegaFailure(func() {
+			WaitSysfsLink(procfsroot, "nonexisting", 100*time.Millisecond)
+		})).To(MatchError(ContainSubstring("never appeared in sysfs")))
+	})
+
 	It("mounts a fresh sysfs (RO) in a transient mount namespace", func() {
 		defer netns.EnterTransient()()
 		Expect(len(Successful(os.ReadDir("/sys/class/net")))).To(BeNumerically(">", 1))
@@ -0,0 +1,48 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mntns
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/thediveo/notwork/internal/wait"
+
+	. "github.com/onsi/ginkgo/v2" //lint:ignore ST1001 rule does not apply
+	. "github.com/onsi/gomega"    //lint:ignore ST1001 rule does not apply
+)
+
+// WaitSysfsLink waits for the “/sys/class/net/<name>” directory of the network
+// interface with the specified name to appear in the sysfs instance mounted
+// inside the mount namespace referenced by procfsroot, such as returned by
+// [NewTransient]. An empty procfsroot refers to the current mount namespace.
+// The maximum wait duration can be optionally specified; it defaults to 2s.
+//
+// Please note that WaitSysfsLink cannot make a network interface appear in a
+// sysfs instance that was mounted from a different network namespace than the
+// network interface is located in; see the package documentation for details.
+func WaitSysfsLink(procfsroot, name string, within ...time.Duration) {
+	GinkgoHelper()
+
+	atmost := wait.Duration(within, 2*time.Second)
+
+	path := filepath.Join("/", procfsroot, "sys/class/net", name)
+	Eventually(func() error {
+		_, err := os.Stat(path)
+		return err
+	}).Within(atmost).ProbeEvery(20*time.Millisecond).
+		Should(Succeed(), "network interface %q never appeared in sysfs", name)
+}
@@ -43,6 +43,14 @@ associated problems around sysfs and non-adaptive network namespacing.
 This package works around the situation by creating netdevsims with multiple
 ports piecemeal-wise, picking up the newly created ports piece by piece.
 
+# NUMA Nodes
+
+This package deliberately doesn't offer to attach netdevsim devices to a
+particular NUMA node: in contrast to, say, PCI devices, the devices on the
+netdevsim bus lack a (writable) “numa_node” sysfs attribute, and the
+“new_device” pseudo file doesn't accept a NUMA node either. Tests needing a
+network interface with NUMA affinity thus need real hardware.
+
 [netdevsim]: https://docs.kernel.org/process/maintainer-netdev.html#netdevsim
 [Ginkgo]: https://github.com/onsi/ginkgo
 [Gomega]: https://github.com/onsi/gomega
@@ -0,0 +1,204 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package netdevsim
+
+import (
+	"fmt"
+
+	"github.com/mdlayher/genetlink"
+	"github.com/thediveo/notwork/netns"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+
+	mdnetlink "github.com/mdlayher/netlink"
+
+	. "github.com/onsi/ginkgo/v2" //lint:ignore ST1001 rule does not apply
+	. "github.com/onsi/gomega"    //lint:ignore ST1001 rule does not apply
+)
+
+// Features returns the ethtool features of the specified network interface,
+// mapping feature names, such as “rx-gro”, to their active state. The map
+// contains the features that are either currently active or can be changed,
+// similar to the output of “ethtool -k”. If the network interface is located
+// in a network namespace other than the current network namespace, its
+// [netlink.LinkAttrs.Namespace] must be set accordingly.
+//
+// While Features uses the ethtool netlink API and thus works with any network
+// interface, it is especially useful in combination with netdevsim network
+// interfaces as fixtures for testing feature negotiation code.
+func Features(l netlink.Link) map[string]bool {
+	GinkgoHelper()
+
+	Expect(l).NotTo(BeNil(), "link must be non-nil")
+	var msgs []genetlink.Message
+	inLinkNetns(l, func() {
+		var err error
+		msgs, err = executeEthtoolCmd(unix.ETHTOOL_MSG_FEATURES_GET, l, nil)
+		Expect(err).NotTo(HaveOccurred(),
+			"cannot get features of network interface %q", l.Attrs().Name)
+	})
+	Expect(msgs).To(HaveLen(1),
+		"cannot get features of network interface %q", l.Attrs().Name)
+	features, err := parseFeatures(msgs[0].Data)
+	Expect(err).NotTo(HaveOccurred(),
+		"cannot parse features of network interface %q", l.Attrs().Name)
+	return features
+}
+
+// SetFeature switches the named ethtool feature of the specified network
+// interface on or off, similar to “ethtool -K”. SetFeature fails the current
+// test if the feature is unknown or cannot be switched into the requested
+// state. See also [Features] with respect to network namespaces.
+func SetFeature(l netlink.Link, feature string, on bool) {
+	GinkgoHelper()
+
+	Expect(l).NotTo(BeNil(), "link must be non-nil")
+	inLinkNetns(l, func() {
+		_, err := executeEthtoolCmd(unix.ETHTOOL_MSG_FEATURES_SET, l, func(ae *mdnetlink.AttributeEncoder) {
+			ae.Nested(unix.ETHTOOL_A_FEATURES_WANTED, func(nae *mdnetlink.AttributeEncoder) error {
+				encodeFeatureBit(nae, feature, on)
+				return nil
+			})
+		})
+		Expect(err).NotTo(HaveOccurred(),
+			"cannot set feature %q of network interface %q", feature, l.Attrs().Name)
+	})
+	if active, ok := Features(l)[feature]; !ok || active != on {
+		fail(fmt.Sprintf("cannot switch feature %q of network interface %q %s",
+			feature, l.Attrs().Name, map[bool]string{false: "off", true: "on"}[on]))
+	}
+}
+
+// inLinkNetns runs fn in the network namespace of the specified network
+// interface, or in the current network namespace if the network interface
+// doesn't reference any network namespace.
+func inLinkNetns(l netlink.Link, fn func()) {
+	GinkgoHelper()
+
+	if netnsfd, ok := l.Attrs().Namespace.(netlink.NsFd); ok {
+		netns.Execute(int(netnsfd), fn)
+		return
+	}
+	fn()
+}
+
+// executeEthtoolCmd executes the specified ethtool netlink command for the
+// specified network interface, with additional attributes encoded by fn, if
+// non-nil. It must be called in the network namespace of the network
+// interface.
+func executeEthtoolCmd(cmd uint8, l netlink.Link, fn func(ae *mdnetlink.AttributeEncoder)) ([]genetlink.Message, error) {
+	conn, err := genetlink.Dial(nil)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	family, err := conn.GetFamily(unix.ETHTOOL_GENL_NAME)
+	if err != nil {
+		return nil, err
+	}
+	ae := mdnetlink.NewAttributeEncoder()
+	ae.Nested(unix.ETHTOOL_A_FEATURES_HEADER, func(nae *mdnetlink.AttributeEncoder) error {
+		nae.Uint32(unix.ETHTOOL_A_HEADER_DEV_INDEX, uint32(l.Attrs().Index))
+		return nil
+	})
+	if fn != nil {
+		fn(ae)
+	}
+	data, err := ae.Encode()
+	if err != nil {
+		return nil, err
+	}
+	return conn.Execute(genetlink.Message{
+		Header: genetlink.Header{
+			Command: cmd,
+			Version: unix.ETHTOOL_GENL_VERSION,
+		},
+		Data: data,
+	}, family.ID, mdnetlink.Request|mdnetlink.Acknowledge)
+}
+
+// encodeFeatureBit encodes a verbose ethtool bitset consisting of only the
+// named feature bit, which then also acts as the mask.
+func encodeFeatureBit(ae *mdnetlink.AttributeEncoder, feature string, on bool) {
+	ae.Nested(unix.ETHTOOL_A_BITSET_BITS, func(nae *mdnetlink.AttributeEncoder) error {
+		nae.Nested(unix.ETHTOOL_A_BITSET_BITS_BIT, func(bae *mdnetlink.AttributeEncoder) error {
+			bae.String(unix.ETHTOOL_A_BITSET_BIT_NAME, feature)
+			if on {
+				bae.Flag(unix.ETHTOOL_A_BITSET_BIT_VALUE, true)
+			}
+			return nil
+		})
+		return nil
+	})
+}
+
+// parseFeatures parses the attributes of an ethtool features reply message,
+// returning the changeable and active features with their active states.
+func parseFeatures(b []byte) (map[string]bool, error) {
+	ad, err := mdnetlink.NewAttributeDecoder(b)
+	if err != nil {
+		return nil, err
+	}
+	features := map[string]bool{}
+	for ad.Next() {
+		switch ad.Type() {
+		case unix.ETHTOOL_A_FEATURES_HW:
+			ad.Nested(func(nad *mdnetlink.AttributeDecoder) error {
+				for _, name := range parseBitsetNames(nad) {
+					if _, ok := features[name]; !ok {
+						features[name] = false
+					}
+				}
+				return nil
+			})
+		case unix.ETHTOOL_A_FEATURES_ACTIVE:
+			ad.Nested(func(nad *mdnetlink.AttributeDecoder) error {
+				for _, name := range parseBitsetNames(nad) {
+					features[name] = true
+				}
+				return nil
+			})
+		}
+	}
+	return features, ad.Err()
+}
+
+// parseBitsetNames returns the names of the bits in a verbose ethtool bitset
+// without mask, that is, the names of only the bits that are set.
+func parseBitsetNames(ad *mdnetlink.AttributeDecoder) []string {
+	var names []string
+	for ad.Next() {
+		if ad.Type() != unix.ETHTOOL_A_BITSET_BITS {
+			continue
+		}
+		ad.Nested(func(nad *mdnetlink.AttributeDecoder) error {
+			for nad.Next() {
+				if nad.Type() != unix.ETHTOOL_A_BITSET_BITS_BIT {
+					continue
+				}
+				nad.Nested(func(bad *mdnetlink.AttributeDecoder) error {
+					for bad.Next() {
+						if bad.Type() == unix.ETHTOOL_A_BITSET_BIT_NAME {
+							names = append(names, bad.String())
+						}
+					}
+					return nil
+				})
+			}
+			return nil
+		})
+	}
+	return names
+}
@@ -0,0 +1,75 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package netdevsim
+
+import (
+	"os"
+	"time"
+
+	"github.com/thediveo/notwork/link"
+	"github.com/thediveo/notwork/netns"
+	"github.com/vishvananda/netlink"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gleak"
+	. "github.com/thediveo/fdooze"
+)
+
+var _ = Describe("ethtool features", func() {
+
+	BeforeEach(func() {
+		if os.Getuid() != 0 {
+			Skip("needs root")
+		}
+		goodfds := Filedescriptors()
+		goodgos := Goroutines()
+		DeferCleanup(func() {
+			Eventually(Goroutines).Within(2 * time.Second).ProbeEvery(250 * time.Millisecond).
+				ShouldNot(HaveLeaked(goodgos))
+			Expect(Filedescriptors()).NotTo(HaveLeakedFds(goodfds))
+		})
+	})
+
+	// As the ethtool netlink API works with any network interface, we use a
+	// VETH network interface so that we can test even without netdevsim
+	// support.
+	It("gets and sets features", func() {
+		netnsfd := netns.NewTransient()
+		veth := link.NewTransient(&netlink.Veth{
+			LinkAttrs: netlink.LinkAttrs{Namespace: netlink.NsFd(netnsfd)},
+		}, "veth-")
+
+		features := Features(veth)
+		Expect(features).To(HaveKey("rx-gro"))
+
+		SetFeature(veth, "rx-gro", !features["rx-gro"])
+		Expect(Features(veth)).To(HaveKeyWithValue("rx-gro", !features["rx-gro"]))
+		SetFeature(veth, "rx-gro", features["rx-gro"])
+		Expect(Features(veth)).To(HaveKeyWithValue("rx-gro", features["rx-gro"]))
+	})
+
+	It("fails on unknown features", func() {
+		netnsfd := netns.NewTransient()
+		veth := link.NewTransient(&netlink.Veth{
+			LinkAttrs: netlink.LinkAttrs{Namespace: netlink.NsFd(netnsfd)},
+		}, "veth-")
+
+		Expect(InterceptGomegaFailure(func() {
+			SetFeature(veth, "rx-foobar", true)
+		})).To(MatchError(ContainSubstring("cannot set feature \"rx-foobar\"")))
+	})
+
+})
@@ -0,0 +1,110 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package netdevsim
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/mdlayher/devlink"
+	"github.com/vishvananda/netlink"
+
+	. "github.com/onsi/ginkgo/v2" //lint:ignore ST1001 rule does not apply
+	. "github.com/onsi/gomega"    //lint:ignore ST1001 rule does not apply
+)
+
+// Duplex modes, as used by ethtool.
+const (
+	DuplexHalf    = uint8(0x00)
+	DuplexFull    = uint8(0x01)
+	DuplexUnknown = uint8(0xff)
+)
+
+const netdevsimDebugfsRoot = "/sys/kernel/debug/" + netdevSimBus
+
+// linkSettingsDirs lists the locations of the faked “speed” and “duplex” link
+// settings control files, relative to a netdevsim port's debugfs directory, as
+// they differ between kernel versions.
+var linkSettingsDirs = []string{
+	"ethtool/link",
+	"ethtool",
+}
+
+// SetLinkSettings fakes the link speed (in Mb/s) and duplex mode (such as
+// [DuplexFull]) reported by ethtool for the specified netdevsim port network
+// interface. If the network interface is located in a network namespace other
+// than the current network namespace, its [netlink.LinkAttrs.Namespace] must be
+// set accordingly.
+//
+// SetLinkSettings writes the netdevsim control files in debugfs, so debugfs
+// needs to be mounted on “/sys/kernel/debug”. It fails the current test if the
+// netdevsim of the kernel in use doesn't support faking link settings.
+func SetLinkSettings(l netlink.Link, speed uint32, duplex uint8) {
+	GinkgoHelper()
+
+	Expect(l).NotTo(BeNil(), "link must be non-nil")
+	Expect(netdevsimDebugfsRoot).To(BeADirectory(),
+		"netdevsim debugfs not available, needs debugfs mounted on /sys/kernel/debug")
+
+	portdir := portDebugfsDir(l)
+	for _, dir := range linkSettingsDirs {
+		speedPath := filepath.Join(portdir, dir, "speed")
+		duplexPath := filepath.Join(portdir, dir, "duplex")
+		if _, err := os.Stat(speedPath); err != nil {
+			continue
+		}
+		if _, err := os.Stat(duplexPath); err != nil {
+			continue
+		}
+		Expect(os.WriteFile(speedPath,
+			[]byte(strconv.FormatUint(uint64(speed), 10)), 0)).To(Succeed(),
+			"cannot set speed of netdevsim network interface %q", l.Attrs().Name)
+		Expect(os.WriteFile(duplexPath,
+			[]byte(strconv.FormatUint(uint64(duplex), 10)), 0)).To(Succeed(),
+			"cannot set duplex mode of netdevsim network interface %q", l.Attrs().Name)
+		return
+	}
+	fail(fmt.Sprintf("netdevsim of this kernel doesn't support faking link settings of network interface %q",
+		l.Attrs().Name))
+}
+
+// portDebugfsDir returns the debugfs directory path of the netdevsim port
+// corresponding with the specified network interface.
+func portDebugfsDir(l netlink.Link) string {
+	GinkgoHelper()
+
+	// devlink instances of netdevsims live in the network namespace where the
+	// netdevsim was created in, so we need to ask in the network namespace of
+	// the network interface, which is where we created it.
+	var port *devlink.Port
+	findPort := func() {
+		cl, err := devlink.New()
+		Expect(err).NotTo(HaveOccurred(), "cannot connect to devlink")
+		defer cl.Close()
+		ports, err := cl.Ports()
+		Expect(err).NotTo(HaveOccurred(), "cannot list netdevsim ports")
+		for _, p := range ports {
+			if p.Bus == netdevSimBus && p.Name == l.Attrs().Name {
+				port = p
+				return
+			}
+		}
+	}
+	inLinkNetns(l, findPort)
+	Expect(port).NotTo(BeNil(), "network interface %q is not a netdevsim port", l.Attrs().Name)
+	return filepath.Join(netdevsimDebugfsRoot, port.Device, "ports", strconv.Itoa(port.Port))
+}
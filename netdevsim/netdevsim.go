@@ -16,15 +16,21 @@ package netdevsim
 
 import (
 	"fmt"
+	"net"
 	"os"
+	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/mdlayher/devlink"
+	"github.com/thediveo/notwork/internal/wait"
 	"github.com/thediveo/notwork/link"
+	"github.com/thediveo/notwork/mntns"
 	"github.com/thediveo/notwork/netns"
 	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
 
 	. "github.com/onsi/ginkgo/v2"   //lint:ignore ST1001 rule does not apply
 	. "github.com/onsi/gomega"      //lint:ignore ST1001 rule does not apply
@@ -37,12 +43,29 @@ const NetdevsimPrefix = "ndsi-"
 
 var fail = Fail // allow testing Fails without terminally failing the current test.
 
+// DefaultCreateAttempts is the default maximum number of attempts to create a
+// new netdevsim device using the next available ID; see also
+// [WithCreateAttempts].
+const DefaultCreateAttempts = 10
+
 type Options struct {
-	HasID      bool // false means: shut up and get me the next available ID!
-	ID         uint
-	Ports      uint
-	QueueCount uint // per RX and per TX respectively
-	NetnsFd    int  // valid when >= 0
+	HasID          bool // false means: shut up and get me the next available ID!
+	ID             uint
+	Ports          uint
+	QueueCount     uint // per RX and per TX respectively
+	NetnsFd        int  // valid when >= 0
+	CreateAttempts int  // max. number of attempts to create a netdevsim device
+	PortAttrs      []PortAttr
+	PortMACs       []net.HardwareAddr // MAC addresses of ports 0, 1, ...
+	PortsUp        bool               // bring port network interfaces up after creation
+}
+
+// PortAttr is a sysfs attribute value to set on a port network interface after
+// creating a netdevsim device; see also [WithPortAttr].
+type PortAttr struct {
+	Port  int    // port number, starting from 0
+	Attr  string // name of attribute in /sys/class/net/$NIFNAME/
+	Value string
 }
 
 // Opt is a configuration option when creating a new netdevsim network
@@ -69,18 +92,29 @@ func HasNetdevsim() bool {
 // NewTransient returns the “port” links created, with the first element being
 // port 0, the second port 1, and so on. The link objects returned have only
 // their [LinkAttrs.Name] set, and optionally their (network)
-// [LinkAttrs.Namespace] when configured with the option [InNamespace].
+// [LinkAttrs.Namespace] when configured with the option [InNamespace]. When
+// configured with the option [WithPortsUp], the link objects returned
+// additionally have their [LinkAttrs.Index] set.
 func NewTransient(opts ...Opt) (id uint, links []netlink.Link) {
 	GinkgoHelper()
 
 	options := &Options{
-		Ports:      1,
-		QueueCount: 1,
-		NetnsFd:    -1,
+		Ports:          1,
+		QueueCount:     1,
+		NetnsFd:        -1,
+		CreateAttempts: DefaultCreateAttempts,
 	}
 	for _, opt := range opts {
 		Expect(opt(options)).To(Succeed())
 	}
+	for _, portAttr := range options.PortAttrs {
+		Expect(portAttr.Port).To(BeNumerically("<", options.Ports),
+			"attribute %q for invalid port %d", portAttr.Attr, portAttr.Port)
+	}
+	if options.PortMACs != nil {
+		Expect(options.PortMACs).To(HaveLen(int(options.Ports)),
+			"number of port MAC addresses must match number of ports")
+	}
 
 	if options.NetnsFd >= 0 {
 		netns.Execute(options.NetnsFd, func() {
@@ -92,6 +126,18 @@ func NewTransient(opts ...Opt) (id uint, links []netlink.Link) {
 	return
 }
 
+// NewTransientTimed works like [NewTransient], but additionally returns how
+// long creating the netdevsim device took, including enumerating and renaming
+// its port network interfaces, as well as applying any port configuration. The
+// duration doesn't include the (later) removal of the netdevsim device.
+func NewTransientTimed(opts ...Opt) (id uint, links []netlink.Link, d time.Duration) {
+	GinkgoHelper()
+
+	start := time.Now()
+	id, links = NewTransient(opts...)
+	return id, links, time.Since(start)
+}
+
 // newTransient does the real work of creating a netdevsim device with the given
 // configuration options.
 //
@@ -124,7 +170,8 @@ func newTransient(options *Options) (uint, []netlink.Link) {
 		}
 	}()
 
-	for attempt := 1; attempt <= 10; attempt++ {
+	var lastErr error
+	for attempt := 1; attempt <= options.CreateAttempts; attempt++ {
 		// locate the "next" available netdevsim ID, unless explicitly specified
 		// by caller...
 		id = options.ID
@@ -143,6 +190,7 @@ func newTransient(options *Options) (uint, []netlink.Link) {
 				fail(fmt.Sprintf("cannot create a netdevsim with ID %d, reason: %s",
 					id, err.Error()))
 			}
+			lastErr = err
 			continue // another attempt
 		}
 		removeNetdevsim = true
@@ -154,7 +202,7 @@ func newTransient(options *Options) (uint, []netlink.Link) {
 			Within(2*time.Second).ProbeEvery(1*time.Millisecond).
 			Should(BeADirectory(), "netdevsim with ID %d failed to materialize", id)
 		// Get the names of the port network interfaces and then rename them using random names.
-		nifnames := Successful(portNifnames(devlink, id))
+		nifnames := waitPorts(devlink, id, int(options.Ports))
 		links := make([]netlink.Link, 0, len(nifnames))
 		var netns interface{}
 		if options.NetnsFd >= 0 {
@@ -185,6 +233,23 @@ func newTransient(options *Options) (uint, []netlink.Link) {
 			}
 			fail("too many failed attempts to generate a random port network interface name")
 		}
+		for port, mac := range options.PortMACs {
+			Expect(netlink.LinkSetHardwareAddr(links[port], mac)).To(Succeed(),
+				"cannot set MAC address of port %d network interface %s", port, links[port].Attrs().Name)
+			links[port].Attrs().HardwareAddr = mac
+		}
+		if len(options.PortAttrs) > 0 {
+			Expect(setPortAttrs(links, options.PortAttrs)).To(Succeed())
+		}
+		if options.PortsUp {
+			for port, l := range links {
+				nif, err := netlink.LinkByName(l.Attrs().Name)
+				Expect(err).NotTo(HaveOccurred(),
+					"cannot determine index of port %d network interface %s", port, l.Attrs().Name)
+				l.Attrs().Index = nif.Attrs().Index
+				link.EnsureUp(l)
+			}
+		}
 		removeNetdevsim = false
 		DeferCleanup(func() {
 			By(fmt.Sprintf("removing transient netdevsim with ID %d", id))
@@ -193,37 +258,99 @@ func newTransient(options *Options) (uint, []netlink.Link) {
 		})
 		return id, links
 	}
-	fail("too many failed attempts to create a transient netdevsim")
+	fail(fmt.Sprintf("too many failed attempts to create a transient netdevsim, last reason: %v",
+		lastErr))
 	return 0, nil // not reachable
 }
 
+// List returns the IDs of all netdevsim devices currently present on the
+// netdevsim bus, in ascending order. If the netdevsim kernel module isn't
+// loaded, List returns an empty list.
+func List() []uint {
+	GinkgoHelper()
+
+	if !HasNetdevsim() {
+		return nil
+	}
+	ids, err := listIDs()
+	Expect(err).NotTo(HaveOccurred())
+	return ids
+}
+
 // availableID returns the lowest available netdevsim ID.
 func availableID() (uint, error) {
+	ids, err := listIDs()
+	if err != nil {
+		return 0, err
+	}
+	// as the IDs are sorted, the lowest available ID is the first gap.
+	id := uint(0)
+	for _, usedID := range ids {
+		if usedID != id {
+			break
+		}
+		id++
+	}
+	return id, nil
+}
+
+// listIDs returns the sorted IDs of the existing netdevsim devices.
+func listIDs() ([]uint, error) {
 	devsdirf, err := os.Open(netdevsimDevicesPath)
 	if err != nil {
-		return 0, fmt.Errorf("cannot list existing netdevsim instances, reason: %w", err)
+		return nil, fmt.Errorf("cannot list existing netdevsim instances, reason: %w", err)
 	}
 	defer devsdirf.Close()
 	devDirEntries, err := devsdirf.ReadDir(-1)
 	if err != nil {
-		return 0, fmt.Errorf("cannot list existing netdevsim instances, reason: %w", err)
+		return nil, fmt.Errorf("cannot list existing netdevsim instances, reason: %w", err)
 	}
-	ids := map[uint]struct{}{}
+	ids := []uint{}
 	for _, devEntry := range devDirEntries {
 		name := strings.TrimPrefix(devEntry.Name(), netdevsimDevicePrefix)
 		id, err := strconv.ParseUint(name, 10, 32)
 		if err != nil {
 			continue
 		}
-		ids[uint(id)] = struct{}{}
-	}
-	id := uint(0)
-	for {
-		if _, ok := ids[id]; !ok {
-			return id, nil
-		}
-		id++
+		ids = append(ids, uint(id))
 	}
+	slices.Sort(ids)
+	return ids, nil
+}
+
+// WaitPorts waits for the netdevsim device with the specified ID to have
+// exactly n port network interfaces that can be enumerated via devlink. The
+// maximum wait duration can be optionally specified; it defaults to 2s.
+//
+// After creating a new netdevsim device, its port network interfaces might lag
+// behind the device appearing on the netdevsim bus, especially on slow systems.
+// Please note that [NewTransient] already waits for the port network interfaces
+// to become enumerable.
+func WaitPorts(id uint, n int, within ...time.Duration) {
+	GinkgoHelper()
+
+	cl := Successful(devlink.New())
+	defer cl.Close()
+	_ = waitPorts(cl, id, n, within...)
+}
+
+// waitPorts waits for the netdevsim device with the specified ID to have
+// exactly n port network interfaces with names, returning the names ordered
+// from port 0 on upwards.
+func waitPorts(cl *devlink.Client, id uint, n int, within ...time.Duration) []string {
+	GinkgoHelper()
+
+	atmost := wait.Duration(within, 2*time.Second)
+
+	var nifnames []string
+	Eventually(func() ([]string, error) {
+		var err error
+		nifnames, err = portNifnames(cl, id)
+		return nifnames, err
+	}).Within(atmost).ProbeEvery(10*time.Millisecond).
+		Should(And(HaveLen(n), Not(ContainElement(""))),
+			"netdevsim with ID %d failed to materialize %d port network interface(s)", id, n)
+	return nifnames
 }
 
 // portNifnames returns a list of network interface names corresponding with the
@@ -251,3 +378,35 @@ func portNifnames(cl *devlink.Client, id uint) ([]string, error) {
 	}
 	return nifnames, nil
 }
+
+// setPortAttrs sets the specified sysfs attributes of the port network
+// interfaces in links, which must be in the current network namespace.
+//
+// As sysfs only shows the network interfaces of the network namespace that was
+// current when mounting the sysfs instance, we need to write the attributes in
+// a transient mount namespace with a fresh sysfs instance mounted (read-write)
+// while in the current network namespace.
+func setPortAttrs(links []netlink.Link, portAttrs []PortAttr) error {
+	GinkgoHelper()
+
+	var err error
+	mntnsfd, _ := mntns.NewTransient()
+	mntns.Execute(mntnsfd, func() {
+		if err = unix.Mount("none", "/sys", "sysfs",
+			unix.MS_NODEV|unix.MS_NOEXEC|unix.MS_NOSUID|unix.MS_RELATIME, ""); err != nil {
+			err = fmt.Errorf("cannot mount new sysfs instance on /sys, reason: %w", err)
+			return
+		}
+		for _, portAttr := range portAttrs {
+			nifname := links[portAttr.Port].Attrs().Name
+			if err = os.WriteFile(
+				filepath.Join("/sys/class/net", nifname, portAttr.Attr),
+				[]byte(portAttr.Value), 0); err != nil {
+				err = fmt.Errorf("cannot set attribute %q of port %d network interface %s, reason: %w",
+					portAttr.Attr, portAttr.Port, nifname, err)
+				return
+			}
+		}
+	})
+	return err
+}
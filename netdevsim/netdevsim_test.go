@@ -19,9 +19,12 @@ import (
 	"fmt"
 	"net"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/mdlayher/devlink"
+	"github.com/thediveo/notwork/link"
+	"github.com/thediveo/notwork/mntns"
 	"github.com/thediveo/notwork/netns"
 	"github.com/vishvananda/netlink"
 
@@ -76,6 +79,13 @@ var _ = Describe("creates netdevsim network interfaces", Ordered, func() {
 		Expect(id2).NotTo(Equal(id1))
 	})
 
+	It("lists netdevsim IDs", func() {
+		defer netns.EnterTransient()()
+
+		id, _ := NewTransient()
+		Expect(List()).To(ContainElement(id))
+	})
+
 	Context("listing port nifnames", func() {
 
 		It("returns an empty list for a non-existing netdevsim device", func() {
@@ -114,12 +124,22 @@ var _ = Describe("creates netdevsim network interfaces", Ordered, func() {
 			Expect(portnifs).To(HaveLen(1))
 			Expect(portnifs[0]).To(And(
 				HaveField("Attrs().Name", HavePrefix(NetdevsimPrefix)),
-				HaveField("Type()", "device")))
+				HaveField("Type()", "device"),
+				WithTransform(link.IsHardware, BeTrue())))
 			Expect(portnifs[0].Attrs().Name).To(HavePrefix(NetdevsimPrefix))
 			Expect(Successful(net.Interfaces())).To(
 				ContainElement(HaveField("Name", portnifs[0].Attrs().Name)))
 		})
 
+		It("creates a netdevsim and times it", func() {
+			defer netns.EnterTransient()()
+
+			id, portnifs, d := NewTransientTimed(WithPorts(2))
+			Expect(List()).To(ContainElement(id))
+			Expect(portnifs).To(HaveLen(2))
+			Expect(d).To(BeNumerically(">", 0))
+		})
+
 		It("creates a multi-port netdevsim", func() {
 			defer netns.EnterTransient()()
 
@@ -141,6 +161,143 @@ var _ = Describe("creates netdevsim network interfaces", Ordered, func() {
 			Expect(netlink.LinkByName(portnifs[0].Attrs().Name)).Error().To(HaveOccurred())
 		})
 
+		It("waits for port network interfaces", func() {
+			id, _ := NewTransient(WithPorts(2))
+			WaitPorts(id, 2)
+			Expect(InterceptGomegaFailure(func() {
+				WaitPorts(id, 3, 100*time.Millisecond)
+			})).To(MatchError(ContainSubstring("failed to materialize 3 port network interface(s)")))
+			Expect(func() {
+				WaitPorts(id, 2, time.Second, time.Second)
+			}).To(PanicWith(ContainSubstring("single optional maximum wait duration")))
+		})
+
+		It("sets port attributes", func() {
+			netnsfd := netns.NewTransient()
+
+			_, portnifs := NewTransient(
+				WithPorts(2),
+				InNamespace(netnsfd),
+				WithPortAttr(1, "mtu", "1280"))
+			nlh := netns.NewNetlinkHandle(netnsfd)
+			Expect(Successful(nlh.LinkByName(portnifs[0].Attrs().Name)).Attrs().MTU).NotTo(Equal(1280))
+			Expect(Successful(nlh.LinkByName(portnifs[1].Attrs().Name)).Attrs().MTU).To(Equal(1280))
+		})
+
+		It("sets port MAC addresses", func() {
+			netnsfd := netns.NewTransient()
+
+			macs := []net.HardwareAddr{
+				{0x02, 0x00, 0x00, 0x00, 0x00, 0x01},
+				{0x02, 0x00, 0x00, 0x00, 0x00, 0x02},
+			}
+			_, portnifs := NewTransient(
+				WithPorts(2),
+				InNamespace(netnsfd),
+				WithPortMACs(macs...))
+			nlh := netns.NewNetlinkHandle(netnsfd)
+			Expect(Successful(nlh.LinkByName(portnifs[0].Attrs().Name)).Attrs().HardwareAddr).To(Equal(macs[0]))
+			Expect(Successful(nlh.LinkByName(portnifs[1].Attrs().Name)).Attrs().HardwareAddr).To(Equal(macs[1]))
+		})
+
+		It("brings ports up", func() {
+			netnsfd := netns.NewTransient()
+
+			_, portnifs := NewTransient(
+				WithPorts(2),
+				InNamespace(netnsfd),
+				WithPortsUp())
+			nlh := netns.NewNetlinkHandle(netnsfd)
+			for _, portnif := range portnifs {
+				Expect(portnif.Attrs().Index).NotTo(BeZero())
+				nif := Successful(nlh.LinkByIndex(portnif.Attrs().Index))
+				Expect(nif.Attrs().Name).To(Equal(portnif.Attrs().Name))
+				Expect(nif.Attrs().Flags & net.FlagUp).NotTo(BeZero())
+				Expect(nif.Attrs().OperState).To(BeElementOf(
+					netlink.LinkOperState(netlink.OperUp),
+					netlink.LinkOperState(netlink.OperUnknown)))
+			}
+		})
+
+		It("rejects a mismatching number of port MAC addresses", func() {
+			Expect(InterceptGomegaFailure(func() {
+				_, _ = NewTransient(WithPorts(2), WithPortMACs(net.HardwareAddr{0x02, 0, 0, 0, 0, 1}))
+			})).To(MatchError(ContainSubstring("must match number of ports")))
+		})
+
+		It("rejects attributes for non-existing ports", func() {
+			Expect(InterceptGomegaFailure(func() {
+				_, _ = NewTransient(WithPorts(2), WithPortAttr(2, "mtu", "1280"))
+			})).To(MatchError(ContainSubstring("invalid port 2")))
+		})
+
+	})
+
+	Context("devlink params", func() {
+
+		It("gets and sets a param", func() {
+			id, _ := NewTransient()
+
+			param := Successful(GetParam(id, "max_macs"))
+			Expect(param.Type).To(Equal(ParamTypeU32))
+			Expect(param.Values).To(HaveKey(ParamCModeDriverinit))
+
+			Expect(SetParam(id, "max_macs", ParamCModeDriverinit, uint32(42))).To(Succeed())
+			Expect(GetParam(id, "max_macs")).To(
+				HaveField("Values", HaveKeyWithValue(ParamCModeDriverinit, uint32(42))))
+
+			Expect(SetParam(id, "max_macs", ParamCModeDriverinit, "42")).To(
+				MatchError(ContainSubstring("invalid value")))
+		})
+
+		It("fails gracefully for a missing param", func() {
+			id, _ := NewTransient()
+
+			Expect(GetParam(id, "does_not_exist")).Error().To(HaveOccurred())
+			Expect(SetParam(id, "does_not_exist", ParamCModeRuntime, true)).To(HaveOccurred())
+		})
+
+	})
+
+	Context("faking link settings", func() {
+
+		It("rejects non-netdevsim network interfaces", func() {
+			if _, err := os.Stat(netdevsimDebugfsRoot); err != nil {
+				Skip("needs debugfs")
+			}
+			defer netns.EnterTransient()()
+			Expect(InterceptGomegaFailure(func() {
+				SetLinkSettings(Successful(netlink.LinkByName("lo")), 1000, DuplexFull)
+			})).To(MatchError(ContainSubstring("is not a netdevsim port")))
+		})
+
+		It("sets speed and duplex", func() {
+			if _, err := os.Stat(netdevsimDebugfsRoot); err != nil {
+				Skip("needs debugfs")
+			}
+			netnsfd := netns.NewTransient()
+			_, portnifs := NewTransient(InNamespace(netnsfd))
+			err := InterceptGomegaFailure(func() {
+				SetLinkSettings(portnifs[0], 2500, DuplexHalf)
+			})
+			if err != nil && strings.Contains(err.Error(), "doesn't support faking link settings") {
+				Skip("netdevsim doesn't support faking link settings")
+			}
+			Expect(err).NotTo(HaveOccurred())
+
+			mntnsfd, _ := mntns.NewTransient()
+			var speed, duplex []byte
+			netns.Execute(netnsfd, func() {
+				mntns.Execute(mntnsfd, func() {
+					mntns.MountSysfsRO()
+					speed = Successful(os.ReadFile("/sys/class/net/" + portnifs[0].Attrs().Name + "/speed"))
+					duplex = Successful(os.ReadFile("/sys/class/net/" + portnifs[0].Attrs().Name + "/duplex"))
+				})
+			})
+			Expect(strings.TrimSpace(string(speed))).To(Equal("2500"))
+			Expect(strings.TrimSpace(string(duplex))).To(Equal("half"))
+		})
+
 	})
 
 	Context("linking netdevsim interfaces", Ordered, func() {
@@ -192,6 +349,37 @@ var _ = Describe("creates netdevsim network interfaces", Ordered, func() {
 			Unlink(portnifs2[0])
 		})
 
+		It("creates a linked pair", func() {
+			netnsfd := netns.NewTransient()
+
+			id1, id2, a, b := NewTransientLinkedPair(InNamespace(netnsfd))
+			Expect(id1).NotTo(Equal(id2))
+			Expect(List()).To(ContainElements(id1, id2))
+			Expect(a.Attrs().Name).To(HavePrefix(NetdevsimPrefix))
+			Expect(b.Attrs().Name).To(HavePrefix(NetdevsimPrefix))
+			Relink(a, b)
+
+			Expect(InterceptGomegaFailure(func() {
+				_, _, _, _ = NewTransientLinkedPair(WithID(42))
+			})).To(MatchError(ContainSubstring("cannot use a fixed ID")))
+		})
+
+		It("relinks peers", func() {
+			defer netns.EnterTransient()()
+
+			_, portnifs1 := NewTransient()
+			_, portnifs2 := NewTransient()
+			_, portnifs3 := NewTransient()
+			Relink(portnifs1[0], portnifs2[0])
+			Relink(portnifs1[0], portnifs3[0])
+			Relink(portnifs2[0], portnifs3[0])
+			Unlink(portnifs3[0])
+		})
+
+		It("rejects unlinking nil links", func() {
+			Expect(unlink(nil)).To(MatchError(ContainSubstring("must be non-nil")))
+		})
+
 		It("links and unlinks two peers in two different network namespaces", func() {
 			netnsfd1 := netns.NewTransient()
 			_, portnifs1 := NewTransient(InNamespace(netnsfd1))
@@ -212,6 +400,19 @@ var _ = Describe("creates netdevsim network interfaces", Ordered, func() {
 			Unlink(portnifs1[0])
 		})
 
+		It("creates a pair linked across two different network namespaces", func() {
+			netnsfd1 := netns.NewTransient()
+			netnsfd2 := netns.NewTransient()
+
+			id1, id2, a, b := NewTransientLinkedAcross(netnsfd1, netnsfd2)
+			Expect(id1).NotTo(Equal(id2))
+			Expect(a.Attrs().Namespace).To(Equal(netlink.NsFd(netnsfd1)))
+			Expect(b.Attrs().Namespace).To(Equal(netlink.NsFd(netnsfd2)))
+			Expect(netns.NewNetlinkHandle(netnsfd1).LinkByName(a.Attrs().Name)).Error().NotTo(HaveOccurred())
+			Expect(netns.NewNetlinkHandle(netnsfd2).LinkByName(b.Attrs().Name)).Error().NotTo(HaveOccurred())
+			Relink(a, b)
+		})
+
 	})
 
 })
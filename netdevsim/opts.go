@@ -17,6 +17,8 @@ package netdevsim
 import (
 	"errors"
 	"fmt"
+	"net"
+	"strings"
 )
 
 // WithID configures a new netdevsim to use the specified ID, as opposed to the
@@ -51,6 +53,21 @@ func WithRxTxQueueCountEach(n uint) Opt {
 	}
 }
 
+// WithCreateAttempts configures the maximum number of attempts to create a new
+// netdevsim using the next available ID, defaulting to
+// [DefaultCreateAttempts]. On busy hosts, such as when running many test suites
+// in parallel, other netdevsims might grab the next available ID before we do,
+// so more attempts might be needed.
+func WithCreateAttempts(n int) Opt {
+	return func(o *Options) error {
+		if n <= 0 {
+			return fmt.Errorf("invalid number of create attempts %d", n)
+		}
+		o.CreateAttempts = n
+		return nil
+	}
+}
+
 // InNamespace configures a new netdevsim to have its port network interface(s)
 // to be created in the network namespace referenced by fdref, instead of
 // creating it in the current network namespace.
@@ -63,3 +80,64 @@ func InNamespace(fdref int) Opt {
 		return nil
 	}
 }
+
+// WithPortAttr configures a new netdevsim to set the sysfs attribute attr of the
+// specified port network interface to value after creation. Ports are numbered
+// starting from 0 and the port number must be less than the number of ports
+// configured (see [WithPorts]); otherwise, creating the netdevsim fails.
+//
+// The attribute is written to “/sys/class/net/$NIFNAME/attr”, with the sysfs
+// instance mounted in a transient mount namespace while in the network
+// namespace of the port network interface. This takes care of sysfs showing
+// only network interfaces of the network namespace that mounted it; please see
+// the [mntns] package for background information.
+//
+// [mntns]: https://pkg.go.dev/github.com/thediveo/notwork/mntns
+func WithPortAttr(port int, attr, value string) Opt {
+	return func(o *Options) error {
+		if port < 0 {
+			return fmt.Errorf("invalid port %d", port)
+		}
+		if attr == "" || strings.Contains(attr, "/") || attr == "." || attr == ".." {
+			return fmt.Errorf("invalid port attribute name %q", attr)
+		}
+		o.PortAttrs = append(o.PortAttrs, PortAttr{
+			Port:  port,
+			Attr:  attr,
+			Value: value,
+		})
+		return nil
+	}
+}
+
+// WithPortMACs configures a new netdevsim to set the MAC addresses of its port
+// network interfaces after creation, with the first MAC address going to port
+// 0, the second to port 1, and so on. The number of MAC addresses must match
+// the number of ports configured (see [WithPorts]); otherwise, creating the
+// netdevsim fails. Each MAC address must be 6 bytes long.
+func WithPortMACs(macs ...net.HardwareAddr) Opt {
+	return func(o *Options) error {
+		for port, mac := range macs {
+			if len(mac) != 6 {
+				return fmt.Errorf("invalid MAC address %q for port %d", mac, port)
+			}
+		}
+		o.PortMACs = macs
+		return nil
+	}
+}
+
+// WithPortsUp configures a new netdevsim to have all its port network
+// interfaces brought up after creation, waiting for them to become
+// operationally up before [NewTransient] returns. As netdevsim ports not linked
+// to a peer port don't signal any carrier changes, their operational state
+// usually ends up as “UNKNOWN” instead of “UP”; similar to [link.EnsureUp],
+// both operational states are accepted.
+//
+// [link.EnsureUp]: https://pkg.go.dev/github.com/thediveo/notwork/link#EnsureUp
+func WithPortsUp() Opt {
+	return func(o *Options) error {
+		o.PortsUp = true
+		return nil
+	}
+}
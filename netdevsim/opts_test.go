@@ -15,6 +15,8 @@
 package netdevsim
 
 import (
+	"net"
+
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 )
@@ -28,6 +30,7 @@ var _ = Describe("MACVLAN configuration options", func() {
 			WithID(123),
 			WithPorts(10),
 			WithRxTxQueueCountEach(666),
+			WithCreateAttempts(42),
 		} {
 			Expect(opt(o)).To(Succeed())
 		}
@@ -36,6 +39,48 @@ var _ = Describe("MACVLAN configuration options", func() {
 		Expect(o.ID).To(Equal(uint(123)))
 		Expect(o.Ports).To(Equal(uint(10)))
 		Expect(o.QueueCount).To(Equal(uint(666)))
+		Expect(o.CreateAttempts).To(Equal(42))
+	})
+
+	It("rejects invalid create attempts", func() {
+		Expect(WithCreateAttempts(0)(&Options{})).NotTo(Succeed())
+	})
+
+	It("configures port attributes", func() {
+		o := &Options{}
+		Expect(WithPortAttr(0, "mtu", "1280")(o)).To(Succeed())
+		Expect(WithPortAttr(1, "ifalias", "foo")(o)).To(Succeed())
+		Expect(o.PortAttrs).To(Equal([]PortAttr{
+			{Port: 0, Attr: "mtu", Value: "1280"},
+			{Port: 1, Attr: "ifalias", Value: "foo"},
+		}))
+	})
+
+	It("rejects invalid port attributes", func() {
+		Expect(WithPortAttr(-1, "mtu", "1280")(&Options{})).NotTo(Succeed())
+		Expect(WithPortAttr(0, "", "1280")(&Options{})).NotTo(Succeed())
+		Expect(WithPortAttr(0, "../mtu", "1280")(&Options{})).NotTo(Succeed())
+		Expect(WithPortAttr(0, "..", "1280")(&Options{})).NotTo(Succeed())
+	})
+
+	It("configures port MAC addresses", func() {
+		o := &Options{}
+		macs := []net.HardwareAddr{
+			{0x02, 0x00, 0x00, 0x00, 0x00, 0x01},
+			{0x02, 0x00, 0x00, 0x00, 0x00, 0x02},
+		}
+		Expect(WithPortMACs(macs...)(o)).To(Succeed())
+		Expect(o.PortMACs).To(Equal(macs))
+	})
+
+	It("rejects invalid port MAC addresses", func() {
+		Expect(WithPortMACs(net.HardwareAddr{0x02, 0x00})(&Options{})).NotTo(Succeed())
+	})
+
+	It("configures ports to be brought up", func() {
+		o := &Options{}
+		Expect(WithPortsUp()(o)).To(Succeed())
+		Expect(o.PortsUp).To(BeTrue())
 	})
 
 })
@@ -0,0 +1,281 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package netdevsim
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/mdlayher/genetlink"
+	"github.com/mdlayher/netlink"
+	"golang.org/x/sys/unix"
+)
+
+// ParamCMode is the configuration mode of a devlink parameter value.
+type ParamCMode uint8
+
+// Configuration modes of devlink parameter values.
+const (
+	ParamCModeRuntime    = ParamCMode(unix.DEVLINK_PARAM_CMODE_RUNTIME)    // applies immediately
+	ParamCModeDriverinit = ParamCMode(unix.DEVLINK_PARAM_CMODE_DRIVERINIT) // applies after devlink reload
+	ParamCModePermanent  = ParamCMode(unix.DEVLINK_PARAM_CMODE_PERMANENT)  // stored in device
+)
+
+// Types of devlink parameter values, as used by the Linux kernel.
+const (
+	ParamTypeU8     = uint8(1)
+	ParamTypeU16    = uint8(2)
+	ParamTypeU32    = uint8(3)
+	ParamTypeString = uint8(5)
+	ParamTypeBool   = uint8(6)
+)
+
+// Param describes a devlink parameter of a netdevsim device, with its values
+// for the configuration modes it supports. Depending on the parameter's Type,
+// values are of type uint8, uint16, uint32, string, or bool.
+type Param struct {
+	Name    string
+	Generic bool // generic devlink parameter, as opposed to driver-specific.
+	Type    uint8
+	Values  map[ParamCMode]any
+}
+
+// GetParam returns the devlink parameter with the specified name of the
+// netdevsim device with the specified ID, otherwise an error, such as when the
+// netdevsim lacks the named parameter.
+//
+// Please note that the devlink instance of a netdevsim device is located in the
+// network namespace the netdevsim device has been created in, so GetParam needs
+// to be called in this network namespace.
+func GetParam(id uint, name string) (Param, error) {
+	msgs, err := executeParamCmd(unix.DEVLINK_CMD_PARAM_GET, id, func(ae *netlink.AttributeEncoder) {
+		ae.String(unix.DEVLINK_ATTR_PARAM_NAME, name)
+	})
+	if err != nil {
+		return Param{}, fmt.Errorf("cannot get devlink param %q of netdevsim with ID %d, reason: %w",
+			name, id, err)
+	}
+	if len(msgs) == 0 {
+		return Param{}, fmt.Errorf("netdevsim with ID %d lacks devlink param %q", id, name)
+	}
+	return parseParam(msgs[0].Data)
+}
+
+// SetParam sets the value of the devlink parameter with the specified name of
+// the netdevsim device with the specified ID for the given configuration mode.
+// The value's type must match the parameter's type: uint8, uint16, uint32,
+// string, or bool. SetParam returns an error if the netdevsim lacks the named
+// parameter, the parameter doesn't support the configuration mode, or the
+// value is of the wrong type.
+//
+// Please note that values for the [ParamCModeDriverinit] configuration mode
+// only take effect after a devlink reload. See also [GetParam] with respect to
+// network namespaces.
+func SetParam(id uint, name string, cmode ParamCMode, value any) error {
+	param, err := GetParam(id, name)
+	if err != nil {
+		return err
+	}
+	if _, ok := param.Values[cmode]; !ok {
+		return fmt.Errorf("devlink param %q of netdevsim with ID %d doesn't support configuration mode %d",
+			name, id, cmode)
+	}
+	encodeValue, err := paramValueEncoder(param.Type, value)
+	if err != nil {
+		return fmt.Errorf("invalid value for devlink param %q of netdevsim with ID %d, reason: %w",
+			name, id, err)
+	}
+	_, err = executeParamCmd(unix.DEVLINK_CMD_PARAM_SET, id, func(ae *netlink.AttributeEncoder) {
+		ae.String(unix.DEVLINK_ATTR_PARAM_NAME, name)
+		ae.Uint8(unix.DEVLINK_ATTR_PARAM_TYPE, param.Type)
+		encodeValue(ae)
+		ae.Uint8(unix.DEVLINK_ATTR_PARAM_VALUE_CMODE, uint8(cmode))
+	})
+	if err != nil {
+		return fmt.Errorf("cannot set devlink param %q of netdevsim with ID %d, reason: %w",
+			name, id, err)
+	}
+	return nil
+}
+
+// executeParamCmd executes the specified devlink param command for the
+// netdevsim device with the specified ID, with additional attributes encoded
+// by fn.
+func executeParamCmd(cmd uint8, id uint, fn func(ae *netlink.AttributeEncoder)) ([]genetlink.Message, error) {
+	// The devlink package doesn't support devlink params, so we need to roll
+	// our own generic netlink requests.
+	conn, err := genetlink.Dial(nil)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	family, err := conn.GetFamily(unix.DEVLINK_GENL_NAME)
+	if err != nil {
+		return nil, err
+	}
+	ae := netlink.NewAttributeEncoder()
+	ae.String(unix.DEVLINK_ATTR_BUS_NAME, netdevSimBus)
+	ae.String(unix.DEVLINK_ATTR_DEV_NAME, fmt.Sprintf("%s%d", netdevsimDevicePrefix, id))
+	fn(ae)
+	data, err := ae.Encode()
+	if err != nil {
+		return nil, err
+	}
+	return conn.Execute(genetlink.Message{
+		Header: genetlink.Header{
+			Command: cmd,
+			Version: unix.DEVLINK_GENL_VERSION,
+		},
+		Data: data,
+	}, family.ID, netlink.Request|netlink.Acknowledge)
+}
+
+// paramValueEncoder returns a function encoding the specified param value
+// according to the param type, or an error if the value's type doesn't match.
+func paramValueEncoder(typ uint8, value any) (func(ae *netlink.AttributeEncoder), error) {
+	switch typ {
+	case ParamTypeU8:
+		if v, ok := value.(uint8); ok {
+			return func(ae *netlink.AttributeEncoder) {
+				ae.Uint8(unix.DEVLINK_ATTR_PARAM_VALUE_DATA, v)
+			}, nil
+		}
+	case ParamTypeU16:
+		if v, ok := value.(uint16); ok {
+			return func(ae *netlink.AttributeEncoder) {
+				ae.Uint16(unix.DEVLINK_ATTR_PARAM_VALUE_DATA, v)
+			}, nil
+		}
+	case ParamTypeU32:
+		if v, ok := value.(uint32); ok {
+			return func(ae *netlink.AttributeEncoder) {
+				ae.Uint32(unix.DEVLINK_ATTR_PARAM_VALUE_DATA, v)
+			}, nil
+		}
+	case ParamTypeString:
+		if v, ok := value.(string); ok {
+			return func(ae *netlink.AttributeEncoder) {
+				ae.String(unix.DEVLINK_ATTR_PARAM_VALUE_DATA, v)
+			}, nil
+		}
+	case ParamTypeBool:
+		if v, ok := value.(bool); ok {
+			// a bool is true if its data attribute is present, otherwise false.
+			return func(ae *netlink.AttributeEncoder) {
+				if v {
+					ae.Flag(unix.DEVLINK_ATTR_PARAM_VALUE_DATA, true)
+				}
+			}, nil
+		}
+	default:
+		return nil, fmt.Errorf("unsupported param type %d", typ)
+	}
+	return nil, fmt.Errorf("param type %d doesn't match value type %T", typ, value)
+}
+
+// parseParam parses a devlink param message's attributes.
+func parseParam(b []byte) (Param, error) {
+	ad, err := netlink.NewAttributeDecoder(b)
+	if err != nil {
+		return Param{}, err
+	}
+	param := Param{Values: map[ParamCMode]any{}}
+	found := false
+	for ad.Next() {
+		if ad.Type() != unix.DEVLINK_ATTR_PARAM {
+			continue
+		}
+		found = true
+		ad.Nested(func(nad *netlink.AttributeDecoder) error {
+			for nad.Next() {
+				switch nad.Type() {
+				case unix.DEVLINK_ATTR_PARAM_NAME:
+					param.Name = nad.String()
+				case unix.DEVLINK_ATTR_PARAM_GENERIC:
+					param.Generic = nad.Flag()
+				case unix.DEVLINK_ATTR_PARAM_TYPE:
+					param.Type = nad.Uint8()
+				case unix.DEVLINK_ATTR_PARAM_VALUES_LIST:
+					// the kernel always emits the param type before the list
+					// of values.
+					nad.Nested(func(vlad *netlink.AttributeDecoder) error {
+						for vlad.Next() {
+							if vlad.Type() != unix.DEVLINK_ATTR_PARAM_VALUE {
+								continue
+							}
+							vlad.Nested(func(vad *netlink.AttributeDecoder) error {
+								if err := parseParamValue(vad, &param); err != nil {
+									return err
+								}
+								return vad.Err()
+							})
+						}
+						return vlad.Err()
+					})
+				}
+			}
+			return nad.Err()
+		})
+	}
+	if err := ad.Err(); err != nil {
+		return Param{}, err
+	}
+	if !found {
+		return Param{}, errors.New("missing devlink param information")
+	}
+	return param, nil
+}
+
+// parseParamValue parses a single param value with its configuration mode,
+// adding it to the param's values.
+func parseParamValue(vad *netlink.AttributeDecoder, param *Param) error {
+	var cmode ParamCMode
+	var value any
+	switch param.Type {
+	case ParamTypeU8:
+		value = uint8(0)
+	case ParamTypeU16:
+		value = uint16(0)
+	case ParamTypeU32:
+		value = uint32(0)
+	case ParamTypeString:
+		value = ""
+	case ParamTypeBool:
+		value = false
+	default:
+		return fmt.Errorf("unsupported param type %d", param.Type)
+	}
+	for vad.Next() {
+		switch vad.Type() {
+		case unix.DEVLINK_ATTR_PARAM_VALUE_CMODE:
+			cmode = ParamCMode(vad.Uint8())
+		case unix.DEVLINK_ATTR_PARAM_VALUE_DATA:
+			switch param.Type {
+			case ParamTypeU8:
+				value = vad.Uint8()
+			case ParamTypeU16:
+				value = vad.Uint16()
+			case ParamTypeU32:
+				value = vad.Uint32()
+			case ParamTypeString:
+				value = vad.String()
+			case ParamTypeBool:
+				value = true
+			}
+		}
+	}
+	param.Values[cmode] = value
+	return nil
+}
@@ -0,0 +1,101 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package netdevsim
+
+import (
+	"github.com/mdlayher/netlink"
+	"golang.org/x/sys/unix"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/thediveo/success"
+)
+
+var _ = Describe("devlink params", func() {
+
+	It("parses a devlink param", func() {
+		ae := netlink.NewAttributeEncoder()
+		ae.String(unix.DEVLINK_ATTR_BUS_NAME, netdevSimBus)
+		ae.Nested(unix.DEVLINK_ATTR_PARAM, func(nae *netlink.AttributeEncoder) error {
+			nae.String(unix.DEVLINK_ATTR_PARAM_NAME, "test1")
+			nae.Uint8(unix.DEVLINK_ATTR_PARAM_TYPE, ParamTypeBool)
+			nae.Nested(unix.DEVLINK_ATTR_PARAM_VALUES_LIST, func(vlae *netlink.AttributeEncoder) error {
+				vlae.Nested(unix.DEVLINK_ATTR_PARAM_VALUE, func(vae *netlink.AttributeEncoder) error {
+					vae.Uint8(unix.DEVLINK_ATTR_PARAM_VALUE_CMODE, uint8(ParamCModeRuntime))
+					return nil
+				})
+				vlae.Nested(unix.DEVLINK_ATTR_PARAM_VALUE, func(vae *netlink.AttributeEncoder) error {
+					vae.Uint8(unix.DEVLINK_ATTR_PARAM_VALUE_CMODE, uint8(ParamCModeDriverinit))
+					vae.Flag(unix.DEVLINK_ATTR_PARAM_VALUE_DATA, true)
+					return nil
+				})
+				return nil
+			})
+			return nil
+		})
+		param := Successful(parseParam(Successful(ae.Encode())))
+		Expect(param).To(Equal(Param{
+			Name: "test1",
+			Type: ParamTypeBool,
+			Values: map[ParamCMode]any{
+				ParamCModeRuntime:    false,
+				ParamCModeDriverinit: true,
+			},
+		}))
+	})
+
+	It("rejects a message without param", func() {
+		ae := netlink.NewAttributeEncoder()
+		ae.String(unix.DEVLINK_ATTR_BUS_NAME, netdevSimBus)
+		Expect(parseParam(Successful(ae.Encode()))).Error().To(
+			MatchError(ContainSubstring("missing devlink param")))
+	})
+
+	It("rejects malformed nested param attributes", func() {
+		ae := netlink.NewAttributeEncoder()
+		ae.Nested(unix.DEVLINK_ATTR_PARAM, func(nae *netlink.AttributeEncoder) error {
+			nae.String(unix.DEVLINK_ATTR_PARAM_TYPE, "foobar")
+			return nil
+		})
+		Expect(parseParam(Successful(ae.Encode()))).Error().To(HaveOccurred())
+
+		ae = netlink.NewAttributeEncoder()
+		ae.Nested(unix.DEVLINK_ATTR_PARAM, func(nae *netlink.AttributeEncoder) error {
+			nae.Uint8(unix.DEVLINK_ATTR_PARAM_TYPE, 42)
+			nae.Nested(unix.DEVLINK_ATTR_PARAM_VALUES_LIST, func(vlae *netlink.AttributeEncoder) error {
+				vlae.Nested(unix.DEVLINK_ATTR_PARAM_VALUE, func(vae *netlink.AttributeEncoder) error {
+					vae.Uint8(unix.DEVLINK_ATTR_PARAM_VALUE_CMODE, uint8(ParamCModeRuntime))
+					return nil
+				})
+				return nil
+			})
+			return nil
+		})
+		Expect(parseParam(Successful(ae.Encode()))).Error().To(
+			MatchError(ContainSubstring("unsupported param type 42")))
+	})
+
+	It("encodes values of matching types only", func() {
+		Expect(paramValueEncoder(ParamTypeU32, uint32(42))).NotTo(BeNil())
+		Expect(paramValueEncoder(ParamTypeU32, 42)).Error().To(MatchError(ContainSubstring("doesn't match")))
+		Expect(paramValueEncoder(ParamTypeBool, false)).NotTo(BeNil())
+		Expect(paramValueEncoder(42, false)).Error().To(MatchError(ContainSubstring("unsupported")))
+
+		ae := netlink.NewAttributeEncoder()
+		Successful(paramValueEncoder(ParamTypeBool, true))(ae)
+		Expect(Successful(ae.Encode())).NotTo(BeEmpty())
+	})
+
+})
@@ -15,8 +15,10 @@
 package netdevsim
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"slices"
 
 	"github.com/vishvananda/netlink"
 	vishnetns "github.com/vishvananda/netns"
@@ -38,7 +40,7 @@ func Link(dupond, dupont netlink.Link) {
 	GinkgoHelper()
 
 	Expect(dupond).NotTo(BeNil(), "dupond/first link must be non-nil")
-	Expect(dupont).NotTo(BeNil(), "dupond/second link must be non-nil")
+	Expect(dupont).NotTo(BeNil(), "dupont/second link must be non-nil")
 
 	netnsfd1, ifindex1, err := linkFds(dupond)
 	Expect(err).NotTo(HaveOccurred(), "invalid dupond/first link information")
@@ -56,19 +58,111 @@ func Link(dupond, dupont netlink.Link) {
 		dupont.Attrs().Name, netnsfd2, ifindex2)
 }
 
+// NewTransientLinkedPair creates two transient single-port netdevsim devices and
+// links their port network interfaces with each other, returning the IDs of
+// both netdevsim devices as well as their linked port network interfaces. The
+// netdevsim devices are created with the same options passed in opts, such as
+// [InNamespace], except for their number of ports. As the devices need
+// different IDs, [WithID] cannot be used.
+//
+// NewTransientLinkedPair skips the current test if the Linux kernel doesn't
+// support linking netdevsims. Both netdevsim devices get automatically removed
+// when the current test ends.
+//
+// Note: requires Linux kernel 6.9+.
+func NewTransientLinkedPair(opts ...Opt) (id1, id2 uint, a, b netlink.Link) {
+	GinkgoHelper()
+
+	if _, err := os.Stat(netdevsimRoot + "/link_device"); err != nil {
+		Skip("linking netdevsims needs Linux kernel 6.9+")
+	}
+	options := &Options{}
+	for _, opt := range opts {
+		Expect(opt(options)).To(Succeed())
+	}
+	Expect(options.HasID).To(BeFalse(), "linked netdevsim pair cannot use a fixed ID")
+
+	opts = append(slices.Clone(opts), WithPorts(1))
+	id1, links1 := NewTransient(opts...)
+	id2, links2 := NewTransient(opts...)
+	Link(links1[0], links2[0])
+	return id1, id2, links1[0], links2[0]
+}
+
+// NewTransientLinkedAcross creates a transient single-port netdevsim device in
+// each of the network namespaces referenced by nsA and nsB, and links their
+// port network interfaces with each other across these network namespaces. It
+// returns the IDs of both netdevsim devices as well as their linked port
+// network interfaces, with their [netlink.LinkAttrs.Namespace] fields set to
+// reference nsA and nsB respectively.
+//
+// NewTransientLinkedAcross skips the current test if the Linux kernel doesn't
+// support linking netdevsims. Both netdevsim devices get automatically removed
+// when the current test ends.
+//
+// Note: requires Linux kernel 6.9+.
+func NewTransientLinkedAcross(nsA, nsB int) (idA, idB uint, a, b netlink.Link) {
+	GinkgoHelper()
+
+	if _, err := os.Stat(netdevsimRoot + "/link_device"); err != nil {
+		Skip("linking netdevsims needs Linux kernel 6.9+")
+	}
+	idA, linksA := NewTransient(InNamespace(nsA), WithPorts(1))
+	idB, linksB := NewTransient(InNamespace(nsB), WithPorts(1))
+	a, b = linksA[0], linksB[0]
+	a.Attrs().Namespace = netlink.NsFd(nsA)
+	b.Attrs().Namespace = netlink.NsFd(nsB)
+	Link(a, b)
+	return idA, idB, a, b
+}
+
 // Unlink the specified “port” interface from its peer.
 //
 // Note: requires Linux kernel 6.9+.
 func Unlink(l netlink.Link) {
 	GinkgoHelper()
 
-	Expect(l).NotTo(BeNil(), "link must be non-nil")
+	Expect(unlink(l)).To(Succeed())
+}
+
+// Relink the specified netdevsim “port” interfaces with each other, after first
+// unlinking them from any existing peers. Unlike [Link], Relink thus doesn't
+// fail if one or both ports are already linked, so tests can repeatedly
+// reconfigure netdevsim topologies. Please see [Link] for how to reference
+// netdevsim network interfaces in other network namespaces.
+//
+// Note: requires Linux kernel 6.9+.
+func Relink(dupond, dupont netlink.Link) {
+	GinkgoHelper()
 
+	Expect(dupond).NotTo(BeNil(), "dupond/first link must be non-nil")
+	Expect(dupont).NotTo(BeNil(), "dupont/second link must be non-nil")
+
+	// Depending on the kernel version, unlinking an unlinked port might be
+	// reported as an error, so we ignore unlinking errors. However, we don't
+	// ignore invalid link information.
+	for _, l := range []netlink.Link{dupond, dupont} {
+		netnsfd, _, err := linkFds(l)
+		Expect(err).NotTo(HaveOccurred(), "invalid link information")
+		unix.Close(netnsfd)
+		_ = unlink(l)
+	}
+	Link(dupond, dupont)
+}
+
+// unlink the specified “port” interface from its peer, returning an error if
+// this fails.
+func unlink(l netlink.Link) error {
+	if l == nil {
+		return errors.New("link must be non-nil")
+	}
 	netnsfd, ifindex, err := linkFds(l)
-	Expect(err).NotTo(HaveOccurred(), "invalid link information")
+	if err != nil {
+		return fmt.Errorf("invalid link information, reason: %w", err)
+	}
 	defer unix.Close(netnsfd)
-	Expect(os.WriteFile(netdevsimRoot+"/unlink_device",
-		[]byte(fmt.Sprintf("%d:%d", netnsfd, ifindex)), 0)).To(Succeed())
+	return os.WriteFile(netdevsimRoot+"/unlink_device",
+		[]byte(fmt.Sprintf("%d:%d", netnsfd, ifindex)), 0)
 }
 
 // linkFds returns a netns fd as well as the ifindex of the link in question,
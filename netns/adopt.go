@@ -0,0 +1,48 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package netns
+
+import (
+	"golang.org/x/sys/unix"
+
+	. "github.com/onsi/ginkgo/v2" //lint:ignore ST1001 rule does not apply
+	. "github.com/onsi/gomega"    //lint:ignore ST1001 rule does not apply
+)
+
+// Adopt returns a duplicate of the passed file descriptor referencing a network
+// namespace, such as a file descriptor received from another process over a
+// unix domain socket (SCM_RIGHTS). The returned file descriptor can be used
+// with the other functions of this package. Adopt fails if the passed file
+// descriptor doesn't reference a network namespace.
+//
+// Adopt schedules a DeferCleanup of the returned file descriptor to be closed
+// to avoid leaking it; the caller thus must not close the file descriptor
+// returned. The caller remains responsible for the passed file descriptor and
+// may close it immediately after Adopt returns.
+func Adopt(fd int) int {
+	GinkgoHelper()
+
+	nstype, err := unix.IoctlRetInt(fd, unix.NS_GET_NSTYPE)
+	Expect(err).NotTo(HaveOccurred(),
+		"file descriptor %d does not reference a namespace", fd)
+	Expect(nstype).To(Equal(unix.CLONE_NEWNET),
+		"file descriptor %d does not reference a network namespace", fd)
+	netnsfd, err := unix.FcntlInt(uintptr(fd), unix.F_DUPFD_CLOEXEC, 0)
+	Expect(err).NotTo(HaveOccurred(), "cannot duplicate network namespace reference")
+	DeferCleanup(func() {
+		_ = unix.Close(netnsfd)
+	})
+	return netnsfd
+}
@@ -0,0 +1,92 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package netns
+
+import (
+	"os"
+	"time"
+
+	"golang.org/x/sys/unix"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gleak"
+	. "github.com/thediveo/fdooze"
+	. "github.com/thediveo/success"
+)
+
+// passFd passes the specified file descriptor over a unix domain socket pair
+// using SCM_RIGHTS, returning the received file descriptor. The caller is
+// responsible for closing the received file descriptor.
+func passFd(fd int) int {
+	GinkgoHelper()
+
+	sockets := Successful(unix.Socketpair(unix.AF_UNIX, unix.SOCK_STREAM|unix.SOCK_CLOEXEC, 0))
+	defer unix.Close(sockets[0])
+	defer unix.Close(sockets[1])
+
+	Expect(unix.Sendmsg(sockets[0], []byte{0}, unix.UnixRights(fd), nil, 0)).To(Succeed())
+	oob := make([]byte, unix.CmsgSpace(4))
+	_, oobn, _, _, err := unix.Recvmsg(sockets[1], make([]byte, 1), oob, unix.MSG_CMSG_CLOEXEC)
+	Expect(err).NotTo(HaveOccurred())
+	scms := Successful(unix.ParseSocketControlMessage(oob[:oobn]))
+	Expect(scms).To(HaveLen(1))
+	fds := Successful(unix.ParseUnixRights(&scms[0]))
+	Expect(fds).To(HaveLen(1))
+	return fds[0]
+}
+
+var _ = Describe("adopting network namespaces", func() {
+
+	BeforeEach(func() {
+		if os.Getuid() != 0 {
+			Skip("needs root")
+		}
+		goodfds := Filedescriptors()
+		goodgos := Goroutines()
+		DeferCleanup(func() {
+			Eventually(Goroutines).Within(2 * time.Second).ProbeEvery(250 * time.Millisecond).
+				ShouldNot(HaveLeaked(goodgos))
+			Expect(Filedescriptors()).NotTo(HaveLeakedFds(goodfds))
+		})
+	})
+
+	It("adopts a network namespace passed over a unix domain socket", func() {
+		netnsfd, closer := NewTransientScoped()
+		receivedfd := passFd(netnsfd)
+		closer() // the received fd now is the only reference left
+		adoptedfd := Adopt(receivedfd)
+		Expect(unix.Close(receivedfd)).To(Succeed())
+
+		Expect(adoptedfd).NotTo(Equal(receivedfd))
+		Expect(Ino(adoptedfd)).NotTo(Equal(CurrentIno()))
+		Execute(adoptedfd, func() {
+			Expect(CurrentIno()).To(Equal(Ino(adoptedfd)))
+		})
+	})
+
+	It("rejects file descriptors not referencing a network namespace", func() {
+		utsfd := Successful(unix.Open("/proc/self/ns/uts", unix.O_RDONLY|unix.O_CLOEXEC, 0))
+		defer unix.Close(utsfd)
+		Expect(InterceptGomegaFailure(func() { _ = Adopt(utsfd) })).To(
+			MatchError(ContainSubstring("does not reference a network namespace")))
+
+		f := Successful(os.Open("/proc/self/status"))
+		defer f.Close()
+		Expect(InterceptGomegaFailure(func() { _ = Adopt(int(f.Fd())) })).To(
+			MatchError(ContainSubstring("does not reference a namespace")))
+	})
+
+})
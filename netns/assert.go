@@ -0,0 +1,140 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package netns
+
+import (
+	"errors"
+	"runtime"
+	"strings"
+
+	"github.com/vishvananda/netlink"
+	vishnetns "github.com/vishvananda/netns"
+	"golang.org/x/sys/unix"
+
+	. "github.com/onsi/ginkgo/v2" //lint:ignore ST1001 rule does not apply
+	. "github.com/onsi/gomega"    //lint:ignore ST1001 rule does not apply
+)
+
+// AssertNoLink asserts that there is no network interface with the specified
+// name in the network namespace referenced by netnsfd. In contrast to just
+// expecting an error when looking up the network interface, AssertNoLink fails
+// the current test on any other error than the network interface not being
+// found, such as lacking privileges.
+func AssertNoLink(netnsfd int, name string) {
+	GinkgoHelper()
+
+	nlh, err := netlink.NewHandleAt(vishnetns.NsHandle(netnsfd))
+	Expect(err).NotTo(HaveOccurred(), "cannot create netlink handle for network namespace")
+	defer nlh.Close()
+	_, err = nlh.LinkByName(name)
+	Expect(err).To(HaveOccurred(), "network interface %q unexpectedly present", name)
+	var notFoundErr netlink.LinkNotFoundError
+	Expect(errors.As(err, &notFoundErr)).To(BeTrue(),
+		"cannot determine absence of network interface %q, reason: %s", name, err)
+}
+
+// AssertLinkCount asserts that there are exactly n network interfaces in the
+// network namespace referenced by netnsfd. On a mismatch, AssertLinkCount fails
+// the current test, listing the names of the network interfaces actually
+// present. For instance, a freshly created network namespace contains only the
+// loopback network interface “lo”, so its link count is 1.
+func AssertLinkCount(netnsfd int, n int) {
+	GinkgoHelper()
+
+	nlh, err := netlink.NewHandleAt(vishnetns.NsHandle(netnsfd))
+	Expect(err).NotTo(HaveOccurred(), "cannot create netlink handle for network namespace")
+	defer nlh.Close()
+	links, err := nlh.LinkList()
+	Expect(err).NotTo(HaveOccurred(), "cannot list network interfaces")
+	names := make([]string, 0, len(links))
+	for _, l := range links {
+		names = append(names, l.Attrs().Name)
+	}
+	Expect(len(links)).To(Equal(n),
+		"expected %d network interface(s), but found: %s", n, strings.Join(names, ", "))
+}
+
+// AssertIsolated asserts that inNs returns true when run inside the network
+// namespace referenced by netnsfd, and that inHost returns true when run in
+// the current network namespace. This covers the common pattern of asserting
+// that something is present in a network namespace, such as a network
+// interface, but doesn't leak into the host. On failure, AssertIsolated tells
+// which side failed.
+//
+//	netns.AssertIsolated(netnsfd,
+//		func() bool { _, err := netlink.LinkByName("foo"); return err == nil },
+//		func() bool { _, err := netlink.LinkByName("foo"); return err != nil })
+func AssertIsolated(netnsfd int, inNs func() bool, inHost func() bool) {
+	GinkgoHelper()
+
+	var ok bool
+	Execute(netnsfd, func() { ok = inNs() })
+	Expect(ok).To(BeTrue(), "assertion failed inside network namespace: %s", Description(netnsfd))
+	Expect(inHost()).To(BeTrue(), "assertion failed in current network namespace net:[%d]", CurrentIno())
+}
+
+// Guard records the network namespace of the current OS-level thread and
+// returns a function that needs to be defer'ed in order to assert that the
+// current thread is still attached to the same network namespace when the
+// caller returns.
+//
+//	defer netns.Guard()()
+//
+// Guard is intended as a safety assertion when testing production code that
+// itself switches network namespaces, in order to catch the code under test
+// leaking the calling Go routine and its thread into a different network
+// namespace. As with [Current], the calling Go routine should be locked to its
+// OS-level thread.
+func Guard() func() {
+	GinkgoHelper()
+
+	ino := CurrentIno()
+	return func() {
+		GinkgoHelper()
+		Expect(CurrentIno()).To(Equal(ino),
+			"network namespace unexpectedly changed from net:[%d]", ino)
+	}
+}
+
+// MustNotChangeNamespace runs fn on the calling Go routine locked to its
+// OS-level thread and fails the current test if fn leaves the thread attached
+// to a different network namespace than before. In contrast to [Guard],
+// MustNotChangeNamespace then switches the thread back into its original
+// network namespace, so that the failing code under test doesn't contaminate
+// later tests. If switching back fails, the thread is thrown away when the
+// calling Go routine terminates.
+//
+//	netns.MustNotChangeNamespace(func() {
+//		codeUnderTest()
+//	})
+func MustNotChangeNamespace(fn func()) {
+	GinkgoHelper()
+
+	runtime.LockOSThread()
+	orignetnsfd := current()
+	ino := Ino(orignetnsfd)
+	defer func() {
+		defer unix.Close(orignetnsfd)
+		if CurrentIno() != ino {
+			if err := setns(orignetnsfd, unix.CLONE_NEWNET); err != nil {
+				return // no unlock, the thread is tainted.
+			}
+		}
+		unlockOSThread()
+	}()
+	fn()
+	Expect(CurrentIno()).To(Equal(ino),
+		"network namespace unexpectedly changed from net:[%d]", ino)
+}
@@ -0,0 +1,130 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package netns
+
+import (
+	"os"
+	"runtime"
+	"time"
+
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gleak"
+	. "github.com/thediveo/fdooze"
+	. "github.com/thediveo/success"
+)
+
+var _ = Describe("asserting network interfaces", func() {
+
+	BeforeEach(func() {
+		if os.Getuid() != 0 {
+			Skip("needs root")
+		}
+		goodfds := Filedescriptors()
+		goodgos := Goroutines()
+		DeferCleanup(func() {
+			Eventually(Goroutines).Within(2 * time.Second).ProbeEvery(250 * time.Millisecond).
+				ShouldNot(HaveLeaked(goodgos))
+			Expect(Filedescriptors()).NotTo(HaveLeakedFds(goodfds))
+		})
+	})
+
+	It("asserts absence of a network interface", func() {
+		netnsfd := NewTransient()
+		AssertNoLink(netnsfd, "foobar")
+		Expect(InterceptGomegaFailure(func() { AssertNoLink(netnsfd, "lo") })).To(
+			MatchError(ContainSubstring("network interface \"lo\" unexpectedly present")))
+	})
+
+	It("asserts the number of network interfaces", func() {
+		netnsfd := NewTransient()
+		AssertLinkCount(netnsfd, 1)
+		Expect(InterceptGomegaFailure(func() { AssertLinkCount(netnsfd, 2) })).To(
+			MatchError(ContainSubstring("expected 2 network interface(s), but found: lo")))
+
+		f := Successful(os.Open("/dev/null"))
+		defer f.Close()
+		Expect(InterceptGomegaFailure(func() { AssertLinkCount(int(f.Fd()), 1) })).To(
+			MatchError(ContainSubstring("cannot create netlink handle")))
+	})
+
+	It("asserts isolation between a network namespace and the host", func() {
+		netnsfd := NewTransient()
+		Execute(netnsfd, func() {
+			Expect(netlink.LinkAdd(&netlink.Veth{
+				LinkAttrs: netlink.LinkAttrs{Name: "isolated"},
+				PeerName:  "isolated-peer",
+			})).To(Succeed())
+		})
+		present := func() bool {
+			_, err := netlink.LinkByName("isolated")
+			return err == nil
+		}
+		absent := func() bool { return !present() }
+
+		AssertIsolated(netnsfd, present, absent)
+		Expect(InterceptGomegaFailure(func() { AssertIsolated(netnsfd, absent, absent) })).To(
+			MatchError(ContainSubstring("assertion failed inside network namespace")))
+		Expect(InterceptGomegaFailure(func() { AssertIsolated(netnsfd, present, present) })).To(
+			MatchError(ContainSubstring("assertion failed in current network namespace")))
+	})
+
+	It("guards against unexpected network namespace changes", func() {
+		runtime.LockOSThread()
+		defer runtime.UnlockOSThread()
+
+		Expect(func() {
+			defer Guard()()
+		}).NotTo(Panic())
+
+		netnsfd := NewTransient()
+		orignetnsfd := Current()
+		Expect(InterceptGomegaFailure(func() {
+			guard := Guard()
+			Expect(unix.Setns(netnsfd, unix.CLONE_NEWNET)).To(Succeed())
+			defer func() {
+				Expect(unix.Setns(orignetnsfd, unix.CLONE_NEWNET)).To(Succeed())
+			}()
+			guard()
+		})).To(MatchError(ContainSubstring("network namespace unexpectedly changed")))
+		Expect(CurrentIno()).To(Equal(Ino(orignetnsfd)))
+	})
+
+	It("asserts that functions don't change the network namespace", func() {
+		MustNotChangeNamespace(func() {
+			defer EnterTransient()()
+		})
+
+		netnsfd := NewTransient()
+		homeIno := CurrentIno()
+		Expect(InterceptGomegaFailure(func() {
+			MustNotChangeNamespace(func() {
+				Expect(unix.Setns(netnsfd, unix.CLONE_NEWNET)).To(Succeed())
+			})
+		})).To(MatchError(ContainSubstring("network namespace unexpectedly changed")))
+		Expect(CurrentIno()).To(Equal(homeIno))
+	})
+
+	It("doesn't mistake other errors for absence", func() {
+		f := Successful(os.Open("/dev/null"))
+		defer f.Close()
+		Expect(InterceptGomegaFailure(func() { AssertNoLink(int(f.Fd()), "foobar") })).To(
+			MatchError(ContainSubstring("cannot create netlink handle")))
+	})
+
+})
@@ -56,6 +56,22 @@ One end is located in the second network interfaces.
 As for the names of the VETH pair end variables, please refer to [Dupond et
 Dupont].
 
+# Rootless Testing
+
+This package deliberately doesn't offer to enter a transient user namespace
+together with a transient network namespace: the Linux kernel refuses
+unsharing or entering a user namespace from a multi-threaded process, and Go
+processes are always multi-threaded. Moreover, an OS-level thread cannot switch
+back into its original user namespace.
+
+Instead, run the test binary in a fresh user and network namespace in the
+first place, with the current user mapped to root, such as:
+
+	unshare --user --map-root-user --net go test ./...
+
+The tests then have CAP_NET_ADMIN with respect to their own network
+namespaces, including any transient network namespaces they create.
+
 [Dupond et Dupont]: https://en.wikipedia.org/wiki/Thomson_and_Thompson
 */
 package netns
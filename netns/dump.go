@@ -0,0 +1,101 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package netns
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/vishvananda/netlink"
+	vishnetns "github.com/vishvananda/netns"
+
+	. "github.com/onsi/ginkgo/v2" //lint:ignore ST1001 rule does not apply
+)
+
+// DumpAll writes the network interfaces of all network namespaces referenced by
+// open file descriptors of this process to the GinkgoWriter, together with
+// their addresses. This is a diagnostic aid to be called manually in a failing
+// test in order to see the full network topology across all the transient
+// network namespaces created so far, such as by [NewTransient].
+//
+// Network namespaces referenced multiple times are dumped only once. Network
+// namespaces that cannot be queried are reported, but don't fail the current
+// test.
+func DumpAll() {
+	GinkgoHelper()
+	dumpAll(GinkgoWriter)
+}
+
+// dumpAll writes the network interfaces of all network namespaces referenced by
+// open file descriptors to w.
+func dumpAll(w io.Writer) {
+	entries, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		fmt.Fprintf(w, "cannot list open file descriptors, reason: %s\n", err)
+		return
+	}
+	seen := map[string]struct{}{}
+	type netnsRef struct {
+		netns string // as in "net:[4026531840]"
+		fd    int
+	}
+	refs := []netnsRef{}
+	for _, entry := range entries {
+		fd, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		target, err := os.Readlink("/proc/self/fd/" + entry.Name())
+		if err != nil || !strings.HasPrefix(target, "net:[") {
+			continue
+		}
+		if _, ok := seen[target]; ok {
+			continue
+		}
+		seen[target] = struct{}{}
+		refs = append(refs, netnsRef{netns: target, fd: fd})
+	}
+	slices.SortFunc(refs, func(a, b netnsRef) int { return a.fd - b.fd })
+
+	for _, ref := range refs {
+		fmt.Fprintf(w, "network namespace %s (fd %d):\n", ref.netns, ref.fd)
+		nlh, err := netlink.NewHandleAt(vishnetns.NsHandle(ref.fd))
+		if err != nil {
+			fmt.Fprintf(w, "  cannot create netlink handle, reason: %s\n", err)
+			continue
+		}
+		links, err := nlh.LinkList()
+		if err != nil {
+			nlh.Close()
+			fmt.Fprintf(w, "  cannot list network interfaces, reason: %s\n", err)
+			continue
+		}
+		for _, l := range links {
+			attrs := l.Attrs()
+			fmt.Fprintf(w, "  %d: %s (%s) %s", attrs.Index, attrs.Name, l.Type(), attrs.OperState)
+			if addrs, err := nlh.AddrList(l, netlink.FAMILY_ALL); err == nil {
+				for _, addr := range addrs {
+					fmt.Fprintf(w, " %s", addr.IPNet)
+				}
+			}
+			fmt.Fprintln(w)
+		}
+		nlh.Close()
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package netns
+
+import (
+	"os"
+	"time"
+
+	"github.com/vishvananda/netlink"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	. "github.com/onsi/gomega/gleak"
+	. "github.com/thediveo/fdooze"
+	. "github.com/thediveo/success"
+)
+
+var _ = Describe("dumping network namespaces", func() {
+
+	BeforeEach(func() {
+		if os.Getuid() != 0 {
+			Skip("needs root")
+		}
+		goodfds := Filedescriptors()
+		goodgos := Goroutines()
+		DeferCleanup(func() {
+			Eventually(Goroutines).Within(2 * time.Second).ProbeEvery(250 * time.Millisecond).
+				ShouldNot(HaveLeaked(goodgos))
+			Expect(Filedescriptors()).NotTo(HaveLeakedFds(goodfds))
+		})
+	})
+
+	It("dumps the network interfaces of all referenced network namespaces", func() {
+		netnsfd := NewTransient()
+		nlh := NewNetlinkHandle(netnsfd)
+		lo, err := nlh.LinkByName("lo")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(nlh.AddrAdd(lo, Successful(netlink.ParseAddr("127.0.0.42/32")))).To(Succeed())
+
+		buff := gbytes.NewBuffer()
+		dumpAll(buff)
+		Expect(buff).To(gbytes.Say(`network namespace net:\[%d\] \(fd %d\):\n`, Ino(netnsfd), netnsfd))
+		Expect(buff).To(gbytes.Say(`  1: lo \(device\) down 127\.0\.0\.42/32\n`))
+		Expect(DumpAll).NotTo(Panic())
+	})
+
+})
@@ -0,0 +1,47 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package netns
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2" //lint:ignore ST1001 rule does not apply
+	. "github.com/onsi/gomega"    //lint:ignore ST1001 rule does not apply
+)
+
+// EventuallyIn repeatedly runs fn in the network namespace referenced by
+// netnsfd until fn returns true, probing every probe duration. EventuallyIn
+// fails the current test if fn doesn't return true within the specified
+// duration. Each probe locks the calling Go routine to its OS-level thread and
+// switches the thread into the network namespace only for the duration of fn,
+// in the same way as [Execute].
+//
+//	netns.EventuallyIn(netnsfd, func() bool {
+//		_, err := netlink.LinkByName("eth0")
+//		return err == nil
+//	}, 2*time.Second, 100*time.Millisecond)
+//
+// Please note that this function cannot be named “Eventually”, as it would
+// otherwise clash with Gomega's Eventually when dot-importing Gomega.
+func EventuallyIn(netnsfd int, fn func() bool, within, probe time.Duration) {
+	GinkgoHelper()
+
+	Eventually(func(g Gomega) bool {
+		var ok bool
+		execute(g, netnsfd, func() { ok = fn() })
+		return ok
+	}).Within(within).ProbeEvery(probe).Should(BeTrue(),
+		"condition never met in %s", Description(netnsfd))
+}
@@ -0,0 +1,77 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package netns
+
+import (
+	"os"
+	"time"
+
+	"github.com/vishvananda/netlink"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gleak"
+	. "github.com/thediveo/fdooze"
+)
+
+var _ = Describe("eventually in network namespaces", func() {
+
+	BeforeEach(func() {
+		if os.Getuid() != 0 {
+			Skip("needs root")
+		}
+		goodfds := Filedescriptors()
+		goodgos := Goroutines()
+		DeferCleanup(func() {
+			Eventually(Goroutines).Within(2 * time.Second).ProbeEvery(250 * time.Millisecond).
+				ShouldNot(HaveLeaked(goodgos))
+			Expect(Filedescriptors()).NotTo(HaveLeakedFds(goodfds))
+		})
+	})
+
+	It("polls in a different network namespace", func() {
+		netnsfd := NewTransient()
+		netnsIno := Ino(netnsfd)
+		homeIno := CurrentIno()
+		probes := 0
+		EventuallyIn(netnsfd, func() bool {
+			Expect(CurrentIno()).To(Equal(netnsIno))
+			probes++
+			if probes == 3 {
+				LoUp()
+			}
+			lo, err := netlink.LinkByName("lo")
+			return err == nil && lo.Attrs().OperState != netlink.OperDown
+		}, 2*time.Second, 10*time.Millisecond)
+		Expect(probes).To(BeNumerically(">=", 3))
+		Expect(CurrentIno()).To(Equal(homeIno))
+	})
+
+	It("fails when the condition is never met", func() {
+		netnsfd := NewTransient()
+		Expect(InterceptGomegaFailure(func() {
+			EventuallyIn(netnsfd, func() bool { return false },
+				50*time.Millisecond, 10*time.Millisecond)
+		})).To(MatchError(ContainSubstring("condition never met in netns ino=")))
+	})
+
+	It("fails for invalid network namespaces", func() {
+		Expect(InterceptGomegaFailure(func() {
+			EventuallyIn(-1, func() bool { return true },
+				50*time.Millisecond, 10*time.Millisecond)
+		})).To(MatchError(ContainSubstring("cannot switch into network namespace")))
+	})
+
+})
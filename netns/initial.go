@@ -0,0 +1,44 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package netns
+
+import (
+	"github.com/thediveo/notwork/internal/initialns"
+	"golang.org/x/sys/unix"
+
+	. "github.com/onsi/ginkgo/v2" //lint:ignore ST1001 rule does not apply
+	. "github.com/onsi/gomega"    //lint:ignore ST1001 rule does not apply
+)
+
+// Initial returns a file descriptor referencing the initial network namespace
+// of this process, that is, the network namespace the process was in when
+// initializing this package. Initial returns the same network namespace
+// independent of the network namespace of the caller's OS-level thread.
+//
+// Initial schedules a DeferCleanup of the returned file descriptor to be closed
+// to avoid leaking it; the caller thus must not close the file descriptor
+// returned.
+func Initial() int {
+	GinkgoHelper()
+
+	initialNetnsfd := initialns.Fd()
+	Expect(initialNetnsfd).NotTo(Equal(-1), "cannot determine initial network namespace")
+	netnsfd, err := unix.FcntlInt(uintptr(initialNetnsfd), unix.F_DUPFD_CLOEXEC, 0)
+	Expect(err).NotTo(HaveOccurred(), "cannot duplicate initial network namespace reference")
+	DeferCleanup(func() {
+		_ = unix.Close(netnsfd)
+	})
+	return netnsfd
+}
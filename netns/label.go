@@ -0,0 +1,59 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package netns
+
+import (
+	"sync"
+
+	. "github.com/onsi/ginkgo/v2" //lint:ignore ST1001 rule does not apply
+)
+
+// labels maps the identification/inode numbers of labelled network namespaces
+// to their labels.
+var labels sync.Map // map[uint64]string
+
+// NewTransientLabeled works like [NewTransient], but additionally labels the
+// newly created network namespace for diagnostic purposes, such as “router”.
+// [Description] then includes the label, making network namespaces in
+// topology-heavy tests easier to tell apart. The label gets dropped when the
+// file descriptor referencing the network namespace gets closed.
+func NewTransientLabeled(label string) int {
+	GinkgoHelper()
+
+	netnsfd := NewTransient()
+	ino := Ino(netnsfd)
+	labels.Store(ino, label)
+	// As DeferCleanup runs the cleanup functions in reverse order of their
+	// registration, the label gets dropped before the fd gets closed, so a
+	// new network namespace reusing the inode number won't inherit the label.
+	DeferCleanup(func() {
+		labels.Delete(ino)
+	})
+	return netnsfd
+}
+
+// LabelOf returns the label of the network namespace with the specified
+// identification/inode number, or an empty string if it hasn't been labelled
+// using [NewTransientLabeled].
+//
+// Please note that this function cannot be named “Label”, as it would then
+// clash with Ginkgo's Label decorator.
+func LabelOf(ino uint64) string {
+	label, ok := labels.Load(ino)
+	if !ok {
+		return ""
+	}
+	return label.(string)
+}
@@ -0,0 +1,66 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package netns
+
+import (
+	"time"
+
+	"github.com/vishvananda/netlink"
+	vishnetns "github.com/vishvananda/netns"
+
+	. "github.com/onsi/ginkgo/v2" //lint:ignore ST1001 rule does not apply
+	. "github.com/onsi/gomega"    //lint:ignore ST1001 rule does not apply
+)
+
+// LoUp brings the loopback network interface “lo” in the current network
+// namespace up and waits for it to become operationally “UP” or “UNKNOWN” (the
+// latter is what “lo” usually reports). Freshly created network namespaces,
+// such as by [EnterTransient], start with their “lo” down.
+func LoUp() {
+	GinkgoHelper()
+
+	// The zero handle value works like the netlink package-level functions,
+	// that is, in the current network namespace.
+	loUp(&netlink.Handle{})
+}
+
+// LoUpIn brings the loopback network interface “lo” in the network namespace
+// referenced by netnsfd up and waits for it to become operationally “UP” or
+// “UNKNOWN”.
+func LoUpIn(netnsfd int) {
+	GinkgoHelper()
+
+	nlh, err := netlink.NewHandleAt(vishnetns.NsHandle(netnsfd))
+	Expect(err).NotTo(HaveOccurred(), "cannot create netlink handle for network namespace")
+	defer nlh.Close()
+	loUp(nlh)
+}
+
+// loUp brings “lo” up using the specified netlink handle.
+func loUp(nlh *netlink.Handle) {
+	GinkgoHelper()
+
+	lo, err := nlh.LinkByName("lo")
+	Expect(err).NotTo(HaveOccurred(), "cannot find loopback network interface")
+	Expect(nlh.LinkSetUp(lo)).To(Succeed(), "cannot bring loopback network interface up")
+	Eventually(func() netlink.LinkOperState {
+		lo, err := nlh.LinkByIndex(lo.Attrs().Index)
+		Expect(err).NotTo(HaveOccurred(), "loopback network interface went missing")
+		return lo.Attrs().OperState
+	}).Within(2 * time.Second).ProbeEvery(20 * time.Millisecond).
+		Should(Or(
+			Equal(netlink.LinkOperState(netlink.OperUp)),
+			Equal(netlink.LinkOperState(netlink.OperUnknown))))
+}
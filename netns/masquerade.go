@@ -0,0 +1,185 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package netns
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/mdlayher/netlink"
+	"golang.org/x/sys/unix"
+)
+
+// masquerade adds a new nftables table with the specified name to the network
+// namespace referenced by netnsfd, masquerading IPv4 traffic from the specified
+// source subnet leaving via any network interface. The returned function
+// removes the table again.
+//
+// As there is no nftables CLI tool to rely on, we roll our own nftables netlink
+// messages, using the bare minimum of expressions required.
+func masquerade(netnsfd int, table string, subnet *net.IPNet) (remove func() error, err error) {
+	ip4 := subnet.IP.To4()
+	if ip4 == nil || len(subnet.Mask) != net.IPv4len {
+		return nil, fmt.Errorf("not an IPv4 subnet: %s", subnet)
+	}
+
+	tableMsg := nftMessage(unix.NFT_MSG_NEWTABLE, netlink.Create, func(ae *netlink.AttributeEncoder) {
+		ae.String(unix.NFTA_TABLE_NAME, table)
+	})
+	chainMsg := nftMessage(unix.NFT_MSG_NEWCHAIN, netlink.Create, func(ae *netlink.AttributeEncoder) {
+		ae.String(unix.NFTA_CHAIN_TABLE, table)
+		ae.String(unix.NFTA_CHAIN_NAME, "postrouting")
+		ae.Nested(unix.NFTA_CHAIN_HOOK, func(nae *netlink.AttributeEncoder) error {
+			nae.Uint32(unix.NFTA_HOOK_HOOKNUM, unix.NF_INET_POST_ROUTING)
+			nae.Uint32(unix.NFTA_HOOK_PRIORITY, 100) // NF_IP_PRI_NAT_SRC
+			return nil
+		})
+		ae.String(unix.NFTA_CHAIN_TYPE, "nat")
+	})
+	ruleMsg := nftMessage(unix.NFT_MSG_NEWRULE, netlink.Create|netlink.Append, func(ae *netlink.AttributeEncoder) {
+		ae.String(unix.NFTA_RULE_TABLE, table)
+		ae.String(unix.NFTA_RULE_CHAIN, "postrouting")
+		ae.Nested(unix.NFTA_RULE_EXPRESSIONS, func(nae *netlink.AttributeEncoder) error {
+			// load the IPv4 source address into register 1...
+			nftExpr(nae, "payload", func(eae *netlink.AttributeEncoder) {
+				eae.Uint32(unix.NFTA_PAYLOAD_DREG, unix.NFT_REG_1)
+				eae.Uint32(unix.NFTA_PAYLOAD_BASE, unix.NFT_PAYLOAD_NETWORK_HEADER)
+				eae.Uint32(unix.NFTA_PAYLOAD_OFFSET, 12)
+				eae.Uint32(unix.NFTA_PAYLOAD_LEN, net.IPv4len)
+			})
+			// ...mask it...
+			nftExpr(nae, "bitwise", func(eae *netlink.AttributeEncoder) {
+				eae.Uint32(unix.NFTA_BITWISE_SREG, unix.NFT_REG_1)
+				eae.Uint32(unix.NFTA_BITWISE_DREG, unix.NFT_REG_1)
+				eae.Uint32(unix.NFTA_BITWISE_LEN, net.IPv4len)
+				nftData(eae, unix.NFTA_BITWISE_MASK, subnet.Mask)
+				nftData(eae, unix.NFTA_BITWISE_XOR, make([]byte, net.IPv4len))
+			})
+			// ...compare it with the subnet...
+			nftExpr(nae, "cmp", func(eae *netlink.AttributeEncoder) {
+				eae.Uint32(unix.NFTA_CMP_SREG, unix.NFT_REG_1)
+				eae.Uint32(unix.NFTA_CMP_OP, unix.NFT_CMP_EQ)
+				nftData(eae, unix.NFTA_CMP_DATA, ip4.Mask(subnet.Mask))
+			})
+			// ...and masquerade on a match.
+			nftExpr(nae, "masq", nil)
+			return nil
+		})
+	})
+	if err := nftBatch(netnsfd, tableMsg, chainMsg, ruleMsg); err != nil {
+		return nil, fmt.Errorf("cannot add nftables masquerading, reason: %w", err)
+	}
+	return func() error {
+		delMsg := nftMessage(unix.NFT_MSG_DELTABLE, 0, func(ae *netlink.AttributeEncoder) {
+			ae.String(unix.NFTA_TABLE_NAME, table)
+		})
+		if err := nftBatch(netnsfd, delMsg); err != nil {
+			return fmt.Errorf("cannot remove nftables masquerading, reason: %w", err)
+		}
+		return nil
+	}, nil
+}
+
+// nftMessage returns a new nftables netlink message of the specified type for
+// the IPv4 family, with its attributes encoded by fn.
+func nftMessage(typ uint16, flags netlink.HeaderFlags, fn func(ae *netlink.AttributeEncoder)) netlink.Message {
+	ae := netlink.NewAttributeEncoder()
+	ae.ByteOrder = binary.BigEndian
+	fn(ae)
+	attrs, _ := ae.Encode() // only fails on invalid encoder functions
+	return netlink.Message{
+		Header: netlink.Header{
+			Type:  netlink.HeaderType(unix.NFNL_SUBSYS_NFTABLES<<8 | typ),
+			Flags: netlink.Request | netlink.Acknowledge | flags,
+		},
+		Data: append(nfgenmsg(unix.NFPROTO_IPV4, 0), attrs...),
+	}
+}
+
+// nftExpr encodes a list element with an nftables expression of the specified
+// name, with its expression data encoded by fn, if non-nil.
+func nftExpr(ae *netlink.AttributeEncoder, name string, fn func(eae *netlink.AttributeEncoder)) {
+	ae.Nested(unix.NFTA_LIST_ELEM, func(lae *netlink.AttributeEncoder) error {
+		lae.String(unix.NFTA_EXPR_NAME, name)
+		if fn != nil {
+			lae.Nested(unix.NFTA_EXPR_DATA, func(eae *netlink.AttributeEncoder) error {
+				fn(eae)
+				return nil
+			})
+		}
+		return nil
+	})
+}
+
+// nftData encodes an nftables data value attribute.
+func nftData(ae *netlink.AttributeEncoder, typ uint16, value []byte) {
+	ae.Nested(typ, func(dae *netlink.AttributeEncoder) error {
+		dae.Bytes(unix.NFTA_DATA_VALUE, value)
+		return nil
+	})
+}
+
+// nfgenmsg returns the binary nfgenmsg header.
+func nfgenmsg(family uint8, resid uint16) []byte {
+	return binary.BigEndian.AppendUint16([]byte{family, unix.NFNETLINK_V0}, resid)
+}
+
+// nftBatch sends the specified nftables messages as a single batch
+// (transaction) to the network namespace referenced by netnsfd and waits for
+// their acknowledgements.
+func nftBatch(netnsfd int, msgs ...netlink.Message) error {
+	conn, err := netlink.Dial(unix.NETLINK_NETFILTER, &netlink.Config{NetNS: netnsfd})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	batch := make([]netlink.Message, 0, len(msgs)+2)
+	batch = append(batch, netlink.Message{
+		Header: netlink.Header{
+			Type:  netlink.HeaderType(unix.NFNL_MSG_BATCH_BEGIN),
+			Flags: netlink.Request,
+		},
+		Data: nfgenmsg(unix.AF_UNSPEC, unix.NFNL_SUBSYS_NFTABLES),
+	})
+	batch = append(batch, msgs...)
+	batch = append(batch, netlink.Message{
+		Header: netlink.Header{
+			Type:  netlink.HeaderType(unix.NFNL_MSG_BATCH_END),
+			Flags: netlink.Request,
+		},
+		Data: nfgenmsg(unix.AF_UNSPEC, unix.NFNL_SUBSYS_NFTABLES),
+	})
+	if _, err := conn.SendMessages(batch); err != nil {
+		return err
+	}
+	for acks := 0; acks < len(msgs); {
+		replies, err := conn.Receive()
+		if err != nil {
+			return err
+		}
+		for _, reply := range replies {
+			if reply.Header.Type == netlink.Error {
+				acks++
+			}
+		}
+		if len(replies) == 0 {
+			return errors.New("no acknowledgement")
+		}
+	}
+	return nil
+}
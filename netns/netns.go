@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"math/rand"
 	"runtime"
+	"sync"
 
 	"github.com/vishvananda/netlink"
 	"golang.org/x/sys/unix"
@@ -26,6 +27,15 @@ import (
 	. "github.com/onsi/gomega"    //lint:ignore ST1001 rule does not apply
 )
 
+// unshare allows testing failures to create new network namespaces.
+var unshare = unix.Unshare
+
+// setns and unlockOSThread allow testing failures to switch network namespaces.
+var (
+	setns          = unix.Setns
+	unlockOSThread = runtime.UnlockOSThread
+)
+
 // EnterTransient creates and enters a new (and isolated) network namespace,
 // returning a function that needs to be defer'ed in order to correctly switch
 // the calling go routine and its locked OS-level thread back when the caller
@@ -41,7 +51,7 @@ func EnterTransient() func() {
 	runtime.LockOSThread()
 	netnsfd, err := unix.Open("/proc/thread-self/ns/net", unix.O_RDONLY, 0)
 	Expect(err).NotTo(HaveOccurred(), "cannot determine current network namespace from procfs")
-	Expect(unix.Unshare(unix.CLONE_NEWNET)).To(Succeed(), "cannot create new network namespace")
+	Expect(unshare(unix.CLONE_NEWNET)).To(Succeed(), "cannot create new network namespace")
 	return func() { // this cannot be DeferCleanup'ed: we need to restore the current locked go routine
 		if err := unix.Setns(netnsfd, 0); err != nil {
 			panic(fmt.Sprintf("cannot restore original network namespace, reason: %s", err.Error()))
@@ -59,22 +69,69 @@ func EnterTransient() func() {
 func NewTransient() int {
 	GinkgoHelper()
 
+	netnsfd := newTransient()
+	DeferCleanup(func() {
+		unix.Close(netnsfd)
+	})
+	return netnsfd
+}
+
+// NewTransientScoped works like [NewTransient], but instead of scheduling a
+// Ginkgo deferred cleanup it returns a function to explicitly close the fd
+// referencing the newly created network namespace. This allows specs that
+// create many short-lived network namespaces to release them promptly, instead
+// of piling them up until the end of the spec. The caller must not close the
+// returned fd itself, but call the returned closer function instead; calling
+// the closer multiple times is fine.
+//
+//	netnsfd, closer := netns.NewTransientScoped()
+//	defer closer()
+func NewTransientScoped() (netnsfd int, closer func()) {
+	GinkgoHelper()
+
+	netnsfd = newTransient()
+	return netnsfd, sync.OnceFunc(func() {
+		unix.Close(netnsfd)
+	})
+}
+
+// newTransient creates a new network namespace without entering it, returning
+// a file descriptor referencing the new network namespace. The caller is
+// responsible for closing the fd.
+func newTransient() int {
+	GinkgoHelper()
+
 	runtime.LockOSThread()
 	// no deferred unlock, as we need to throw away the OS-level thread if
-	// things go south.
+	// things go south. This includes a failed unshare, as we then cannot be
+	// sure in which state the thread has been left.
 	orignetnsfd := current()
 	defer unix.Close(orignetnsfd)
-	Expect(unix.Unshare(unix.CLONE_NEWNET)).To(Succeed(), "cannot create new network namespace")
+	Expect(unshare(unix.CLONE_NEWNET)).To(Succeed(), "cannot create new network namespace")
 	netnsfd, err := unix.Open("/proc/thread-self/ns/net", unix.O_RDONLY, 0)
 	Expect(err).NotTo(HaveOccurred(), "cannot determine new network namespace from procfs")
-	Expect(unix.Setns(orignetnsfd, unix.CLONE_NEWNET)).To(Succeed(), "cannot switch back into original network namespace")
-	DeferCleanup(func() {
+	if err := unix.Setns(orignetnsfd, unix.CLONE_NEWNET); err != nil {
+		// Don't leak the fd when failing to switch back.
 		unix.Close(netnsfd)
-	})
+		Expect(err).NotTo(HaveOccurred(), "cannot switch back into original network namespace")
+	}
 	runtime.UnlockOSThread()
 	return netnsfd
 }
 
+// NewTransientSetup creates a new network namespace, runs the setup function
+// once inside it, and then returns a file descriptor referencing the new network
+// namespace. This is a convenience for creating network namespace fixtures that
+// are pre-populated with network interfaces, addresses, et cetera. As with
+// [NewTransient], the caller must not close the file descriptor returned.
+func NewTransientSetup(setup func()) int {
+	GinkgoHelper()
+
+	netnsfd := NewTransient()
+	Execute(netnsfd, setup)
+	return netnsfd
+}
+
 // Execute a function fn in the network namespace referenced by the open file
 // descriptor netnsfd.
 func Execute(netnsfd int, fn func()) {
@@ -88,14 +145,104 @@ func execute(g Gomega, netnsfd int, fn func()) {
 	// things go south. Nota bene: Ginkgo runs its tests on fresh go routines.
 	orignetnsfd := current()
 	defer unix.Close(orignetnsfd)
-	g.Expect(unix.Setns(netnsfd, unix.CLONE_NEWNET)).To(Succeed(), "cannot switch into network namespace")
+	if err := setns(netnsfd, unix.CLONE_NEWNET); err != nil {
+		// we haven't left the original network namespace, so the thread is
+		// still untainted.
+		unlockOSThread()
+		g.Expect(err).NotTo(HaveOccurred(), "cannot switch into network namespace")
+		return // in case g doesn't panic on failure.
+	}
 	defer func() {
-		g.Expect(unix.Setns(orignetnsfd, unix.CLONE_NEWNET)).To(Succeed(), "cannot switch back into original network namespace")
+		if err := setns(orignetnsfd, unix.CLONE_NEWNET); err != nil {
+			// Never unlock the tainted thread, not even when g doesn't panic
+			// on failure, so that the thread gets thrown away when the Go
+			// routine terminates, instead of being returned into the pool.
+			g.Expect(err).NotTo(HaveOccurred(), "cannot switch back into original network namespace")
+			return
+		}
+		unlockOSThread()
+	}()
+	fn()
+}
+
+// Do executes a function fn inline on the calling Go routine in the network
+// namespace referenced by the open file descriptor netnsfd. Do always switches
+// the calling Go routine back into its original network namespace afterwards,
+// even if fn panics, such as when fn fails a Gomega assertion. After switching
+// back, Do then re-panics with the original panic value.
+//
+// If switching back fails, Do panics with an error description, leaving the
+// OS-level thread locked so that it will be thrown away when the Go routine
+// terminates.
+func Do(netnsfd int, fn func()) {
+	GinkgoHelper()
+
+	runtime.LockOSThread()
+	orignetnsfd := current()
+	defer unix.Close(orignetnsfd)
+	if err := unix.Setns(netnsfd, unix.CLONE_NEWNET); err != nil {
+		// we haven't left the original network namespace, so the thread is
+		// still untainted.
 		runtime.UnlockOSThread()
+		Expect(err).NotTo(HaveOccurred(), "cannot switch into network namespace")
+	}
+	defer func() {
+		r := recover()
+		if err := unix.Setns(orignetnsfd, unix.CLONE_NEWNET); err != nil {
+			panic(fmt.Sprintf("cannot restore original network namespace, reason: %s", err.Error()))
+		}
+		runtime.UnlockOSThread()
+		if r != nil {
+			panic(r)
+		}
 	}()
 	fn()
 }
 
+// Execute2 executes a function fn inline on the calling Go routine, passing it
+// two functions inA and inB that in turn run a snippet function in the network
+// namespace referenced by aFd or bFd, respectively. This allows rapidly
+// alternating between two network namespaces, such as when setting up both ends
+// of a tunnel, without nesting [Execute] calls.
+//
+//	netns.Execute2(aFd, bFd, func(inA, inB func(func())) {
+//	    inA(func() { ... })
+//	    inB(func() { ... })
+//	})
+//
+// Only the snippets run in the other network namespaces, whereas fn itself runs
+// in the original network namespace of the caller. Execute2 always switches the
+// calling Go routine back into its original network namespace afterwards, even
+// if a snippet panics, such as when it fails a Gomega assertion. If switching
+// back fails, Execute2 panics with an error description, leaving the OS-level
+// thread locked so that it will be thrown away when the Go routine terminates.
+func Execute2(aFd, bFd int, fn func(inA, inB func(func()))) {
+	GinkgoHelper()
+
+	runtime.LockOSThread()
+	orignetnsfd := current()
+	defer unix.Close(orignetnsfd)
+	defer func() {
+		r := recover()
+		if err := unix.Setns(orignetnsfd, unix.CLONE_NEWNET); err != nil {
+			panic(fmt.Sprintf("cannot restore original network namespace, reason: %s", err.Error()))
+		}
+		runtime.UnlockOSThread()
+		if r != nil {
+			panic(r)
+		}
+	}()
+	in := func(netnsfd int) func(func()) {
+		return func(snippet func()) {
+			GinkgoHelper()
+			Expect(unix.Setns(netnsfd, unix.CLONE_NEWNET)).To(Succeed(), "cannot switch into network namespace")
+			snippet()
+			Expect(unix.Setns(orignetnsfd, unix.CLONE_NEWNET)).To(Succeed(), "cannot switch back into original network namespace")
+		}
+	}
+	fn(in(aFd), in(bFd))
+}
+
 // Current returns a file descriptor referencing the current network namespace.
 // In particular, the current network namespace of the OS-level thread of the
 // caller's Go routine (which should ideally be thread-locked).
@@ -150,6 +297,38 @@ func CurrentIno() uint64 {
 	return Ino("/proc/thread-self/ns/net")
 }
 
+// Description returns a human-readable description of the passed network
+// namespace, either referenced by a file descriptor or a VFS path name, such as
+// “netns ino=4026531840 (from /proc/self/fd/7)”. Description is intended for use
+// in failure messages, so it never fails the current test, but instead
+// describes why it cannot determine the identification/inode number. For
+// network namespaces created using [NewTransientLabeled], Description
+// additionally includes the label, such as “netns "router" ino=4026532281
+// (from /proc/self/fd/8)”.
+//
+// Please note that this function cannot be named “Describe”, as it would then
+// clash with Ginkgo's Describe container node.
+func Description[R ~int | ~string](netns R) string {
+	var netnsStat unix.Stat_t
+	var from string
+	var err error
+	switch ref := any(netns).(type) {
+	case int:
+		from = fmt.Sprintf("/proc/self/fd/%d", ref)
+		err = unix.Fstat(ref, &netnsStat)
+	case string:
+		from = ref
+		err = unix.Stat(ref, &netnsStat)
+	}
+	if err != nil {
+		return fmt.Sprintf("netns ino=? (from %s, reason: %s)", from, err.Error())
+	}
+	if label := LabelOf(netnsStat.Ino); label != "" {
+		return fmt.Sprintf("netns %q ino=%d (from %s)", label, netnsStat.Ino, from)
+	}
+	return fmt.Sprintf("netns ino=%d (from %s)", netnsStat.Ino, from)
+}
+
 // NsID returns the so-called network namespace ID for the passed network
 // namespace, either referenced by a file descriptor or a VFS path name. The
 // nsid identifies the passed network namespace from the perspective of the
@@ -157,10 +336,23 @@ func CurrentIno() uint64 {
 //
 // If no nsid has been assigned yet to the passed network namespace from the
 // perspective of the current network namespace, NsID will assign a random nsid
-// and return it.
+// and return it. Use [NsIDEnsure] in order to find out whether NsID had to
+// assign a new nsid.
 func NsID[R ~int | ~string](netns R) int {
 	GinkgoHelper()
 
+	netnsid, _ := NsIDEnsure(netns)
+	return netnsid
+}
+
+// NsIDEnsure returns the so-called network namespace ID for the passed network
+// namespace, either referenced by a file descriptor or a VFS path name, similar
+// to [NsID]. Additionally, NsIDEnsure returns true if it assigned a new random
+// nsid because there wasn't any nsid assigned yet, otherwise false if it
+// returned an already existing nsid assignment.
+func NsIDEnsure[R ~int | ~string](netns R) (id int, created bool) {
+	GinkgoHelper()
+
 	var netnsfd int
 	switch ref := any(netns).(type) {
 	case int:
@@ -177,7 +369,7 @@ func NsID[R ~int | ~string](netns R) int {
 	// which begs the question why RTM_GETNSID simply isn't allocating a free
 	// one...?!
 	if netnsid != -1 {
-		return netnsid
+		return netnsid, false
 	}
 	for attempt := 1; attempt <= 10; attempt++ {
 		// as per https://elixir.bootlin.com/linux/v6.9.4/source/lib/idr.c#L87,
@@ -186,8 +378,8 @@ func NsID[R ~int | ~string](netns R) int {
 		if err := netlink.SetNetNsIdByFd(netnsfd, netnsid); err != nil {
 			continue
 		}
-		return netnsid
+		return netnsid, true
 	}
 	Fail("too many failed attempts to assign a new netnsid first")
-	return -1 // unreachable
+	return -1, false // unreachable
 }
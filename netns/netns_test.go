@@ -15,6 +15,7 @@
 package netns
 
 import (
+	"fmt"
 	"os"
 	"runtime"
 	"syscall"
@@ -23,6 +24,7 @@ import (
 	"github.com/onsi/gomega/gleak/goroutine"
 	"github.com/thediveo/notwork/link"
 	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -89,6 +91,56 @@ var _ = Describe("transient network namespaces", Ordered, func() {
 		Expect(netnsIno).NotTo(Equal(homeIno))
 	})
 
+	It("creates a transient network namespace and closes it on demand", func() {
+		goodfds := Filedescriptors()
+		homeIno := CurrentIno()
+
+		netnsfd, closer := NewTransientScoped()
+		netnsIno := Ino(netnsfd)
+		Expect(netnsIno).NotTo(BeZero())
+		Expect(netnsIno).NotTo(Equal(homeIno))
+		Expect(CurrentIno()).To(Equal(homeIno))
+
+		closer()
+		Expect(Filedescriptors()).NotTo(HaveLeakedFds(goodfds))
+		closer()
+	})
+
+	It("describes network namespaces", func() {
+		netnsfd := NewTransient()
+		Expect(Description(netnsfd)).To(Equal(
+			fmt.Sprintf("netns ino=%d (from /proc/self/fd/%d)", Ino(netnsfd), netnsfd)))
+		Expect(Description("/proc/self/ns/net")).To(Equal(
+			fmt.Sprintf("netns ino=%d (from /proc/self/ns/net)", Ino("/proc/self/ns/net"))))
+		Expect(Description("/nothing/here")).To(HavePrefix("netns ino=? (from /nothing/here, reason: "))
+	})
+
+	It("describes labelled network namespaces", func() {
+		var ino uint64
+		By("creating a labelled network namespace in a separate scope", func() {
+			DeferCleanup(func() {
+				Expect(LabelOf(ino)).To(BeEmpty())
+			})
+			netnsfd := NewTransientLabeled("router")
+			ino = Ino(netnsfd)
+			Expect(LabelOf(ino)).To(Equal("router"))
+			Expect(Description(netnsfd)).To(Equal(
+				fmt.Sprintf("netns \"router\" ino=%d (from /proc/self/fd/%d)", ino, netnsfd)))
+		})
+		Expect(LabelOf(CurrentIno())).To(BeEmpty())
+	})
+
+	It("doesn't leak when failing to create a new network namespace", func() {
+		homeIno := CurrentIno()
+		oldunshare := unshare
+		defer func() { unshare = oldunshare }()
+		unshare = func(int) error { return unix.EPERM }
+
+		Expect(InterceptGomegaFailure(func() { _ = NewTransient() })).To(
+			MatchError(ContainSubstring("cannot create new network namespace")))
+		Expect(CurrentIno()).To(Equal(homeIno))
+	})
+
 	It("cannot enter an invalid network namespace", func() {
 		var msg string
 		g := NewGomega(func(message string, callerSkip ...int) {
@@ -98,6 +150,54 @@ var _ = Describe("transient network namespaces", Ordered, func() {
 		Expect(msg).To(ContainSubstring("cannot switch into network namespace"))
 	})
 
+	It("doesn't execute when failing to switch into a network namespace", func() {
+		oldsetns, oldunlock := setns, unlockOSThread
+		defer func() { setns, unlockOSThread = oldsetns, oldunlock }()
+		setns = func(int, int) error { return unix.EPERM }
+		unlocks := 0
+		unlockOSThread = func() { unlocks++; oldunlock() }
+
+		var msg string
+		g := NewGomega(func(message string, callerSkip ...int) { msg = message })
+		called := false
+		execute(g, NewTransient(), func() { called = true })
+		Expect(msg).To(ContainSubstring("cannot switch into network namespace"))
+		Expect(called).To(BeFalse())
+		Expect(unlocks).To(Equal(1))
+	})
+
+	It("abandons the thread when failing to switch back", func() {
+		netnsfd := NewTransient()
+		oldsetns, oldunlock := setns, unlockOSThread
+		defer func() { setns, unlockOSThread = oldsetns, oldunlock }()
+		calls := 0
+		setns = func(fd int, nstype int) error {
+			calls++
+			if calls == 2 {
+				return unix.EPERM
+			}
+			return unix.Setns(fd, nstype)
+		}
+		unlocks := 0
+		unlockOSThread = func() { unlocks++ }
+
+		// Run on a separate Go routine, so that its tainted and still locked
+		// thread gets thrown away when the Go routine terminates.
+		var msg string
+		var stat unix.Stat_t
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			g := NewGomega(func(message string, callerSkip ...int) { msg = message })
+			execute(g, netnsfd, func() {})
+			_ = unix.Stat("/proc/thread-self/ns/net", &stat)
+		}()
+		Eventually(done).Should(BeClosed())
+		Expect(msg).To(ContainSubstring("cannot switch back into original network namespace"))
+		Expect(unlocks).To(BeZero())
+		Expect(stat.Ino).To(Equal(Ino(netnsfd)))
+	})
+
 	It("executes a function in a different network namespace", func() {
 		netnsfd := NewTransient()
 		netnsIno := Ino(netnsfd)
@@ -107,6 +207,89 @@ var _ = Describe("transient network namespaces", Ordered, func() {
 		Expect(currentnetnsIno).To(Equal(netnsIno))
 	})
 
+	It("creates a transient network namespace and sets it up", func() {
+		homeIno := CurrentIno()
+		var setupIno uint64
+		calls := 0
+		netnsfd := NewTransientSetup(func() {
+			calls++
+			setupIno = Ino("/proc/thread-self/ns/net")
+		})
+		Expect(calls).To(Equal(1))
+		Expect(setupIno).To(Equal(Ino(netnsfd)))
+		Expect(setupIno).NotTo(Equal(homeIno))
+		Expect(CurrentIno()).To(Equal(homeIno))
+	})
+
+	When("doing things inline", func() {
+
+		It("does a function in a different network namespace", func() {
+			homeIno := CurrentIno()
+			netnsfd := NewTransient()
+			netnsIno := Ino(netnsfd)
+			var currentnetnsIno uint64
+			Do(netnsfd, func() { currentnetnsIno = CurrentIno() })
+			Expect(currentnetnsIno).To(Equal(netnsIno))
+			Expect(CurrentIno()).To(Equal(homeIno))
+		})
+
+		It("switches back and re-panics", func() {
+			homeIno := CurrentIno()
+			netnsfd := NewTransient()
+			Expect(func() {
+				Do(netnsfd, func() { panic("canary") })
+			}).To(PanicWith("canary"))
+			Expect(CurrentIno()).To(Equal(homeIno))
+		})
+
+		It("cannot do in an invalid network namespace", func() {
+			Expect(InterceptGomegaFailure(func() { Do(0, func() {}) })).To(
+				MatchError(ContainSubstring("cannot switch into network namespace")))
+		})
+
+	})
+
+	When("executing in two network namespaces", func() {
+
+		It("alternates between two network namespaces", func() {
+			homeIno := CurrentIno()
+			netnsA := NewTransient()
+			netnsB := NewTransient()
+			var inos []uint64
+			Execute2(netnsA, netnsB, func(inA, inB func(func())) {
+				inA(func() { inos = append(inos, CurrentIno()) })
+				inos = append(inos, CurrentIno())
+				inB(func() { inos = append(inos, CurrentIno()) })
+				inA(func() { inos = append(inos, CurrentIno()) })
+			})
+			Expect(inos).To(Equal([]uint64{Ino(netnsA), homeIno, Ino(netnsB), Ino(netnsA)}))
+			Expect(CurrentIno()).To(Equal(homeIno))
+		})
+
+		It("switches back and re-panics", func() {
+			homeIno := CurrentIno()
+			netnsA := NewTransient()
+			Expect(func() {
+				Execute2(netnsA, netnsA, func(inA, _ func(func())) {
+					inA(func() { panic("canary") })
+				})
+			}).To(PanicWith("canary"))
+			Expect(CurrentIno()).To(Equal(homeIno))
+		})
+
+		It("cannot execute in an invalid network namespace", func() {
+			homeIno := CurrentIno()
+			netnsA := NewTransient()
+			Expect(InterceptGomegaFailure(func() {
+				Execute2(netnsA, 0, func(_, inB func(func())) {
+					inB(func() {})
+				})
+			})).To(MatchError(ContainSubstring("cannot switch into network namespace")))
+			Expect(CurrentIno()).To(Equal(homeIno))
+		})
+
+	})
+
 	It("cannot create a MACVLAN when the parent/master isn't in the current network namespace", func() {
 		// We need to create three separate new network namespaces in order to
 		// exactly know their configuration: only a lo(nely) lo at the
@@ -183,6 +366,18 @@ var _ = Describe("transient network namespaces", Ordered, func() {
 			Expect(NsID(netnsfd)).To(Equal(nsid))
 		})
 
+		It("tells whether it assigned a new netnsid", func() {
+			netnsfd := NewTransient()
+
+			nsid, created := NsIDEnsure(netnsfd)
+			Expect(nsid).NotTo(Equal(-1))
+			Expect(created).To(BeTrue())
+
+			nsid2, created := NsIDEnsure(netnsfd)
+			Expect(nsid2).To(Equal(nsid))
+			Expect(created).To(BeFalse())
+		})
+
 		It("gets a netnsid by path", func() {
 			orignetnsfd := Current()
 			defer EnterTransient()()
@@ -0,0 +1,98 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package netns
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/thediveo/notwork/internal/base62"
+	"golang.org/x/sys/unix"
+
+	. "github.com/onsi/ginkgo/v2" //lint:ignore ST1001 rule does not apply
+	. "github.com/onsi/gomega"    //lint:ignore ST1001 rule does not apply
+)
+
+// PinDir is the directory where [Pin] bind-mounts network namespaces, as
+// expected by the “ip netns” command and tools following its conventions.
+const PinDir = "/run/netns"
+
+// Pin exposes the network namespace referenced by netnsfd as
+// “/run/netns/<name>” by bind-mounting the network namespace onto this path,
+// creating the [PinDir] directory if necessary. This allows tools such as “ip
+// netns” to work with the network namespace by name. Pin fails the current
+// test if the name is invalid or already in use. Pin schedules a DeferCleanup
+// to unmount and remove the pin again.
+//
+// Please note that the pin lives in the mount namespace of the caller.
+func Pin(netnsfd int, name string) {
+	GinkgoHelper()
+
+	Expect(name).NotTo(BeElementOf("", ".", ".."), "invalid network namespace name %q", name)
+	Expect(strings.ContainsRune(name, '/')).To(BeFalse(), "invalid network namespace name %q", name)
+	Expect(os.MkdirAll(PinDir, 0755)).To(Succeed(), "cannot create %s", PinDir)
+
+	path := filepath.Join(PinDir, name)
+	// Creating the mount point exclusively detects name collisions without any
+	// race with other pinning tests.
+	f, err := os.OpenFile(path, os.O_RDONLY|os.O_CREATE|os.O_EXCL, 0444)
+	Expect(errors.Is(err, os.ErrExist)).To(BeFalse(), "network namespace name %q already pinned", name)
+	Expect(err).NotTo(HaveOccurred(), "cannot create pin %s", path)
+	_ = f.Close()
+	if err := unix.Mount(fmt.Sprintf("/proc/self/fd/%d", netnsfd), path, "none", unix.MS_BIND, ""); err != nil {
+		_ = os.Remove(path)
+		Expect(err).NotTo(HaveOccurred(), "cannot bind-mount network namespace onto %s", path)
+	}
+	DeferCleanup(func() {
+		_ = unix.Unmount(path, unix.MNT_DETACH)
+		_ = os.Remove(path)
+	})
+}
+
+// PinTransient works like [Pin], but pins the network namespace referenced by
+// netnsfd under a random name, returning the name.
+func PinTransient(netnsfd int) (name string) {
+	GinkgoHelper()
+
+	name = randomPinName("notwork-")
+	Pin(netnsfd, name)
+	return name
+}
+
+// NewTransientNamed works like [NewTransient], but additionally pins the newly
+// created network namespace under a random name starting with prefix, such as
+// “/run/netns/router-3DzuVm8K”, returning both the file descriptor and the
+// name. This makes transient network namespaces recognizable when debugging
+// failing tests, such as using “ip netns exec”. As with [NewTransient], the
+// caller must not close the file descriptor returned.
+func NewTransientNamed(prefix string) (netnsfd int, name string) {
+	GinkgoHelper()
+
+	netnsfd = NewTransient()
+	name = randomPinName(prefix)
+	// As DeferCleanup runs the cleanup functions in reverse order of their
+	// registration, the pin gets removed before the fd gets closed.
+	Pin(netnsfd, name)
+	return netnsfd, name
+}
+
+// randomPinName returns a random network namespace name with the specified
+// prefix.
+func randomPinName(prefix string) string {
+	return prefix + base62.Random(8)
+}
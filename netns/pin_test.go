@@ -0,0 +1,105 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package netns
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"golang.org/x/sys/unix"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gleak"
+	. "github.com/thediveo/fdooze"
+	. "github.com/thediveo/success"
+)
+
+var _ = Describe("pinning network namespaces", func() {
+
+	BeforeEach(func() {
+		if os.Getuid() != 0 {
+			Skip("needs root")
+		}
+		goodfds := Filedescriptors()
+		goodgos := Goroutines()
+		DeferCleanup(func() {
+			Eventually(Goroutines).Within(2 * time.Second).ProbeEvery(250 * time.Millisecond).
+				ShouldNot(HaveLeaked(goodgos))
+			Expect(Filedescriptors()).NotTo(HaveLeakedFds(goodfds))
+		})
+	})
+
+	It("pins a network namespace and removes the pin afterwards", func() {
+		var path string
+		DeferCleanup(func() {
+			Expect(path).NotTo(BeAnExistingFile())
+		})
+
+		By("pinning in a separate scope", func() {
+			netnsfd := NewTransient()
+			name := PinTransient(netnsfd)
+			Expect(name).To(HavePrefix("notwork-"))
+			path = filepath.Join(PinDir, name)
+			Expect(Ino(path)).To(Equal(Ino(netnsfd)))
+
+			if _, err := exec.LookPath("ip"); err == nil {
+				Expect(string(Successful(exec.Command("ip", "netns", "list").Output()))).To(
+					ContainSubstring(name))
+			}
+
+			Expect(InterceptGomegaFailure(func() {
+				Pin(netnsfd, name)
+			})).To(MatchError(ContainSubstring("already pinned")))
+			Expect(Ino(path)).To(Equal(Ino(netnsfd)))
+		})
+	})
+
+	It("creates a named network namespace and removes it afterwards", func() {
+		var path string
+		DeferCleanup(func() {
+			Expect(path).NotTo(BeAnExistingFile())
+		})
+
+		By("creating a named network namespace in a separate scope", func() {
+			netnsfd, name := NewTransientNamed("router-")
+			Expect(name).To(MatchRegexp(`^router-[0-9A-Za-z]{8}$`))
+			path = filepath.Join(PinDir, name)
+			Expect(Ino(path)).To(Equal(Ino(netnsfd)))
+
+			nsfd := Successful(unix.Open(path, unix.O_RDONLY|unix.O_CLOEXEC, 0))
+			defer unix.Close(nsfd)
+			Expect(Ino(nsfd)).To(Equal(Ino(netnsfd)))
+		})
+	})
+
+	It("rejects invalid names", func() {
+		for _, name := range []string{"", ".", "..", "foo/bar"} {
+			Expect(InterceptGomegaFailure(func() {
+				Pin(-1, name)
+			})).To(MatchError(ContainSubstring("invalid network namespace name")), "name %q", name)
+		}
+	})
+
+	It("reports bind-mount failures and doesn't leave a pin behind", func() {
+		Expect(InterceptGomegaFailure(func() {
+			Pin(int(^uint32(0)>>1), "notwork-invalid")
+		})).To(MatchError(ContainSubstring("cannot bind-mount")))
+		Expect(unix.Access(filepath.Join(PinDir, "notwork-invalid"), unix.F_OK)).NotTo(Succeed())
+	})
+
+})
@@ -0,0 +1,133 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package netns
+
+import (
+	"sync"
+
+	"github.com/thediveo/notwork/internal/base62"
+	"github.com/vishvananda/netlink"
+
+	. "github.com/onsi/ginkgo/v2" //lint:ignore ST1001 rule does not apply
+	. "github.com/onsi/gomega"    //lint:ignore ST1001 rule does not apply
+)
+
+// Addresses and subnets used by [Router] on the VETH pairs between the router
+// network namespace and the “left” and “right” network namespaces.
+const (
+	RouterLeftAddr  = "10.0.1.1/24" // router end towards the left side
+	LeftAddr        = "10.0.1.2/24" // left end
+	RouterRightAddr = "10.0.2.1/24" // router end towards the right side
+	RightAddr       = "10.0.2.2/24" // right end
+)
+
+// routerNifPrefix is the name prefix of the VETH network interfaces created by
+// Router.
+const routerNifPrefix = "rtr-"
+
+// Router creates a transient “router” network namespace connected to the two
+// (existing) network namespaces referenced by leftfd and rightfd using VETH
+// pairs. Router enables IPv4 forwarding in the router network namespace,
+// assigns the addresses [RouterLeftAddr], [LeftAddr], [RouterRightAddr], and
+// [RightAddr], and brings all VETH network interfaces up. Additionally, it adds
+// a route in the left network namespace to the right subnet via the router, and
+// vice versa.
+//
+// The VETH pairs are returned in form of two-element arrays, where the first
+// element is the end in the router network namespace and the second element
+// the end in the left or right network namespace, respectively. The
+// Attrs().Namespace of all returned links reference their network namespaces.
+//
+// Router schedules a DeferCleanup to remove the VETH pairs and to close the
+// router network namespace. The returned cleanup function allows removing the
+// VETH pairs earlier; it is safe to call it multiple times.
+func Router(leftfd, rightfd int) (routerfd int, leftVeth, rightVeth [2]netlink.Link, cleanup func()) {
+	GinkgoHelper()
+
+	routerfd = NewTransient()
+	SetSysctl(routerfd, "net.ipv4.ip_forward", "1")
+
+	routerh := NewNetlinkHandle(routerfd)
+	cleanup = sync.OnceFunc(func() {
+		for _, veth := range []netlink.Link{leftVeth[0], rightVeth[0]} {
+			if veth == nil {
+				continue
+			}
+			// removing the router end also removes its peer end.
+			_ = routerh.LinkDel(veth)
+		}
+	})
+	DeferCleanup(cleanup)
+
+	leftVeth = routerVeth(routerh, routerfd, leftfd, routerNifPrefix,
+		RouterLeftAddr, LeftAddr, RightAddr)
+	rightVeth = routerVeth(routerh, routerfd, rightfd, routerNifPrefix,
+		RouterRightAddr, RightAddr, LeftAddr)
+	return
+}
+
+// routerVeth creates a VETH pair between the router network namespace and an
+// “edge” network namespace, with names beginning with the specified prefix,
+// assigning the router and edge addresses, bringing both ends up, and finally
+// routing the remote subnet via the router.
+func routerVeth(
+	routerh *netlink.Handle, routerfd int, edgefd int, prefix string,
+	routerCIDR string, edgeCIDR string, remoteCIDR string,
+) [2]netlink.Link {
+	GinkgoHelper()
+
+	suffixLen := 15 - len(prefix) // IFNAMSIZ-1
+	veth := &netlink.Veth{
+		LinkAttrs: netlink.LinkAttrs{
+			Name:      prefix + base62.Random(suffixLen),
+			Namespace: netlink.NsFd(routerfd),
+		},
+		PeerName:      prefix + base62.Random(suffixLen),
+		PeerNamespace: netlink.NsFd(edgefd),
+	}
+	Expect(netlink.LinkAdd(veth)).To(Succeed(), "cannot create router VETH pair")
+
+	edgeh := NewNetlinkHandle(edgefd)
+	routerEnd, err := routerh.LinkByName(veth.Name)
+	Expect(err).NotTo(HaveOccurred(), "router VETH end went missing")
+	edgeEnd, err := edgeh.LinkByName(veth.PeerName)
+	Expect(err).NotTo(HaveOccurred(), "edge VETH end went missing")
+
+	routerAddr, err := netlink.ParseAddr(routerCIDR)
+	Expect(err).NotTo(HaveOccurred())
+	edgeAddr, err := netlink.ParseAddr(edgeCIDR)
+	Expect(err).NotTo(HaveOccurred())
+	remoteAddr, err := netlink.ParseAddr(remoteCIDR)
+	Expect(err).NotTo(HaveOccurred())
+
+	Expect(routerh.AddrAdd(routerEnd, routerAddr)).To(Succeed(),
+		"cannot assign address to router VETH end")
+	Expect(edgeh.AddrAdd(edgeEnd, edgeAddr)).To(Succeed(),
+		"cannot assign address to edge VETH end")
+	Expect(routerh.LinkSetUp(routerEnd)).To(Succeed(), "cannot bring router VETH end up")
+	Expect(edgeh.LinkSetUp(edgeEnd)).To(Succeed(), "cannot bring edge VETH end up")
+
+	remoteSubnet := remoteAddr.IPNet
+	remoteSubnet.IP = remoteSubnet.IP.Mask(remoteSubnet.Mask)
+	Expect(edgeh.RouteAdd(&netlink.Route{
+		LinkIndex: edgeEnd.Attrs().Index,
+		Dst:       remoteSubnet,
+		Gw:        routerAddr.IP,
+	})).To(Succeed(), "cannot route remote subnet via router")
+
+	routerEnd.Attrs().Namespace = netlink.NsFd(routerfd)
+	edgeEnd.Attrs().Namespace = netlink.NsFd(edgefd)
+	return [2]netlink.Link{routerEnd, edgeEnd}
+}
@@ -0,0 +1,107 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package netns
+
+import (
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/vishvananda/netlink"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gleak"
+	. "github.com/thediveo/fdooze"
+	. "github.com/thediveo/success"
+)
+
+var _ = Describe("router network namespace", func() {
+
+	BeforeEach(func() {
+		if os.Getuid() != 0 {
+			Skip("needs root")
+		}
+		goodfds := Filedescriptors()
+		goodgos := Goroutines()
+		DeferCleanup(func() {
+			Eventually(Goroutines).Within(2 * time.Second).ProbeEvery(250 * time.Millisecond).
+				ShouldNot(HaveLeaked(goodgos))
+			Expect(Filedescriptors()).NotTo(HaveLeakedFds(goodfds))
+		})
+	})
+
+	It("routes between two network namespaces", func() {
+		leftfd := NewTransient()
+		rightfd := NewTransient()
+		routerfd, leftVeth, rightVeth, cleanup := Router(leftfd, rightfd)
+		Expect(routerfd).NotTo(BeZero())
+		Expect(cleanup).NotTo(BeNil())
+		Expect(Ino(routerfd)).NotTo(Equal(Ino(leftfd)))
+		Expect(Ino(routerfd)).NotTo(Equal(Ino(rightfd)))
+
+		By("checking IPv4 forwarding")
+		var forwarding []byte
+		Execute(routerfd, func() {
+			forwarding = Successful(os.ReadFile("/proc/sys/net/ipv4/ip_forward"))
+		})
+		Expect(strings.TrimSpace(string(forwarding))).To(Equal("1"))
+
+		By("checking the VETH ends")
+		routerh := NewNetlinkHandle(routerfd)
+		Expect(Successful(routerh.LinkByName(leftVeth[0].Attrs().Name)).Attrs().Flags & net.FlagUp).NotTo(BeZero())
+		Expect(Successful(routerh.LinkByName(rightVeth[0].Attrs().Name)).Attrs().Flags & net.FlagUp).NotTo(BeZero())
+		lefth := NewNetlinkHandle(leftfd)
+		Expect(Successful(lefth.AddrList(leftVeth[1], netlink.FAMILY_V4))).To(ContainElement(
+			HaveField("IPNet.String()", LeftAddr)))
+		righth := NewNetlinkHandle(rightfd)
+		Expect(Successful(righth.AddrList(rightVeth[1], netlink.FAMILY_V4))).To(ContainElement(
+			HaveField("IPNet.String()", RightAddr)))
+
+		By("connecting from left to right via the router")
+		var l net.Listener
+		Execute(rightfd, func() {
+			l = Successful(net.Listen("tcp", "0.0.0.0:0"))
+		})
+		defer l.Close()
+		go func() {
+			defer GinkgoRecover()
+			conn, err := l.Accept()
+			if err == nil {
+				conn.Close()
+			}
+		}()
+		rightIP := Successful(netlink.ParseAddr(RightAddr)).IP
+		port := l.Addr().(*net.TCPAddr).Port
+		Execute(leftfd, func() {
+			Eventually(func() error {
+				conn, err := net.DialTimeout("tcp",
+					(&net.TCPAddr{IP: rightIP, Port: port}).String(), 250*time.Millisecond)
+				if err == nil {
+					conn.Close()
+				}
+				return err
+			}).Within(5 * time.Second).ProbeEvery(100 * time.Millisecond).Should(Succeed())
+		})
+
+		By("cleaning up early")
+		cleanup()
+		Expect(lefth.LinkByName(leftVeth[1].Attrs().Name)).Error().To(HaveOccurred())
+		Expect(righth.LinkByName(rightVeth[1].Attrs().Name)).Error().To(HaveOccurred())
+		Expect(cleanup).NotTo(Panic())
+	})
+
+})
@@ -0,0 +1,82 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package netns
+
+import (
+	"os"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2" //lint:ignore ST1001 rule does not apply
+	. "github.com/onsi/gomega"    //lint:ignore ST1001 rule does not apply
+)
+
+// Sysctl returns the value of the (network-related) sysctl with the specified
+// name in the network namespace referenced by netnsfd. The name is in sysctl(8)
+// dotted notation, such as “net.ipv4.ip_forward”; alternatively, slashes can
+// be used as separators, such as when names contain dots themselves. Trailing
+// whitespace, such as the final newline, is removed from the returned value.
+func Sysctl(netnsfd int, name string) string {
+	GinkgoHelper()
+
+	var value []byte
+	var err error
+	Execute(netnsfd, func() {
+		value, err = os.ReadFile(sysctlPath(name))
+	})
+	Expect(err).NotTo(HaveOccurred(), "cannot read sysctl %q", name)
+	return strings.TrimRight(string(value), " \t\n")
+}
+
+// SetSysctl sets the (network-related) sysctl with the specified name in the
+// network namespace referenced by netnsfd to the specified value; see [Sysctl]
+// for the name format.
+func SetSysctl(netnsfd int, name string, value string) {
+	GinkgoHelper()
+
+	var err error
+	Execute(netnsfd, func() {
+		err = os.WriteFile(sysctlPath(name), []byte(value), 0)
+	})
+	Expect(err).NotTo(HaveOccurred(), "cannot set sysctl %q to %q", name, value)
+}
+
+// WithForwarding enables IPv4 and IPv6 forwarding in the network namespace
+// referenced by netnsfd, runs fn, and finally restores the previous forwarding
+// settings, even if fn panics, such as when it fails a Gomega assertion.
+func WithForwarding(netnsfd int, fn func()) {
+	GinkgoHelper()
+
+	forwardings := []string{"net.ipv4.ip_forward", "net.ipv6.conf.all.forwarding"}
+	oldvalues := map[string]string{}
+	defer func() {
+		for name, value := range oldvalues {
+			SetSysctl(netnsfd, name, value)
+		}
+	}()
+	for _, name := range forwardings {
+		oldvalue := Sysctl(netnsfd, name)
+		SetSysctl(netnsfd, name, "1")
+		oldvalues[name] = oldvalue
+	}
+	fn()
+}
+
+// sysctlPath returns the procfs path for the sysctl with the specified name.
+func sysctlPath(name string) string {
+	if !strings.Contains(name, "/") {
+		name = strings.ReplaceAll(name, ".", "/")
+	}
+	return "/proc/sys/" + strings.TrimPrefix(name, "/")
+}
@@ -0,0 +1,80 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package netns
+
+import (
+	"os"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gleak"
+	. "github.com/thediveo/fdooze"
+)
+
+var _ = Describe("sysctls", func() {
+
+	BeforeEach(func() {
+		if os.Getuid() != 0 {
+			Skip("needs root")
+		}
+		goodfds := Filedescriptors()
+		goodgos := Goroutines()
+		DeferCleanup(func() {
+			Eventually(Goroutines).Within(2 * time.Second).ProbeEvery(250 * time.Millisecond).
+				ShouldNot(HaveLeaked(goodgos))
+			Expect(Filedescriptors()).NotTo(HaveLeakedFds(goodfds))
+		})
+	})
+
+	It("maps sysctl names to procfs paths", func() {
+		Expect(sysctlPath("net.ipv4.ip_forward")).To(Equal("/proc/sys/net/ipv4/ip_forward"))
+		Expect(sysctlPath("net/ipv4/conf/eth0.42/forwarding")).To(Equal("/proc/sys/net/ipv4/conf/eth0.42/forwarding"))
+	})
+
+	It("gets and sets sysctls in a different network namespace", func() {
+		netnsfd := NewTransient()
+		homeValue := Sysctl(Current(), "net.ipv4.ip_default_ttl")
+		SetSysctl(netnsfd, "net.ipv4.ip_default_ttl", "42")
+		Expect(Sysctl(netnsfd, "net.ipv4.ip_default_ttl")).To(Equal("42"))
+		Expect(Sysctl(Current(), "net.ipv4.ip_default_ttl")).To(Equal(homeValue))
+	})
+
+	It("fails for non-existing sysctls", func() {
+		netnsfd := NewTransient()
+		Expect(InterceptGomegaFailure(func() { _ = Sysctl(netnsfd, "net.foo.bar") })).To(
+			MatchError(ContainSubstring("cannot read sysctl \"net.foo.bar\"")))
+		Expect(InterceptGomegaFailure(func() { SetSysctl(netnsfd, "net.foo.bar", "1") })).To(
+			MatchError(ContainSubstring("cannot set sysctl \"net.foo.bar\"")))
+	})
+
+	It("temporarily enables forwarding", func() {
+		netnsfd := NewTransient()
+		Expect(Sysctl(netnsfd, "net.ipv4.ip_forward")).To(Equal("0"))
+		Expect(Sysctl(netnsfd, "net.ipv6.conf.all.forwarding")).To(Equal("0"))
+		WithForwarding(netnsfd, func() {
+			Expect(Sysctl(netnsfd, "net.ipv4.ip_forward")).To(Equal("1"))
+			Expect(Sysctl(netnsfd, "net.ipv6.conf.all.forwarding")).To(Equal("1"))
+		})
+		Expect(Sysctl(netnsfd, "net.ipv4.ip_forward")).To(Equal("0"))
+		Expect(Sysctl(netnsfd, "net.ipv6.conf.all.forwarding")).To(Equal("0"))
+
+		Expect(func() {
+			WithForwarding(netnsfd, func() { panic("canary") })
+		}).To(PanicWith("canary"))
+		Expect(Sysctl(netnsfd, "net.ipv4.ip_forward")).To(Equal("0"))
+	})
+
+})
@@ -0,0 +1,95 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package netns
+
+import (
+	"sync"
+
+	"github.com/thediveo/notwork/internal/base62"
+	"github.com/vishvananda/netlink"
+
+	. "github.com/onsi/ginkgo/v2" //lint:ignore ST1001 rule does not apply
+	. "github.com/onsi/gomega"    //lint:ignore ST1001 rule does not apply
+)
+
+// Addresses used by [NewTransientUplinked] on the VETH pair between the current
+// (“host”) network namespace and the new transient network namespace.
+const (
+	UplinkHostAddr = "10.0.3.1/24" // host end
+	UplinkAddr     = "10.0.3.2/24" // end in the transient network namespace
+)
+
+// uplinkNifPrefix is the name prefix of the VETH network interfaces created by
+// NewTransientUplinked.
+const uplinkNifPrefix = "upl-"
+
+// NewTransientUplinked creates a new transient network namespace that is
+// connected to the current (“host”) network namespace using a VETH pair, and
+// returns a file descriptor referencing the new network namespace, as well as
+// a cleanup function. As with [NewTransient], the caller must not close the
+// returned file descriptor.
+//
+// NewTransientUplinked assigns the addresses [UplinkHostAddr] and [UplinkAddr]
+// to the VETH ends and brings them up. It then adds a default route via the
+// host end to the transient network namespace. In the host network namespace,
+// NewTransientUplinked enables IPv4 forwarding and masquerades (source NATs)
+// IPv4 traffic from the uplink subnet using a new nftables table. This results
+// in a transient network namespace with “internet-like” egress, as far as the
+// host network namespace has such connectivity.
+//
+// NewTransientUplinked schedules a DeferCleanup to remove the nftables table
+// and the VETH pair, and to restore the previous IPv4 forwarding setting of the
+// host network namespace. The returned cleanup function allows doing so
+// earlier; it is safe to call it multiple times.
+//
+// As the uplink subnet is fixed, only a single uplinked transient network
+// namespace per host network namespace is supported at any time. Please
+// consider calling NewTransientUplinked from inside a transient “host” network
+// namespace, such as when using [EnterTransient], in order to not touch the
+// configuration of the initial network namespace.
+func NewTransientUplinked() (netnsfd int, cleanup func()) {
+	GinkgoHelper()
+
+	hostfd := Current()
+	netnsfd = NewTransient()
+	hosth := NewNetlinkHandle(hostfd)
+
+	oldForwarding := Sysctl(hostfd, "net.ipv4.ip_forward")
+	var veth [2]netlink.Link
+	var unmasquerade func() error
+	cleanup = sync.OnceFunc(func() {
+		if unmasquerade != nil {
+			_ = unmasquerade()
+		}
+		if veth[0] != nil {
+			// removing the host end also removes its peer end.
+			_ = hosth.LinkDel(veth[0])
+		}
+		SetSysctl(hostfd, "net.ipv4.ip_forward", oldForwarding)
+	})
+	DeferCleanup(cleanup)
+
+	SetSysctl(hostfd, "net.ipv4.ip_forward", "1")
+	veth = routerVeth(hosth, hostfd, netnsfd, uplinkNifPrefix,
+		UplinkHostAddr, UplinkAddr, "0.0.0.0/0")
+
+	uplinkAddr, err := netlink.ParseAddr(UplinkAddr)
+	Expect(err).NotTo(HaveOccurred())
+	subnet := *uplinkAddr.IPNet
+	subnet.IP = subnet.IP.Mask(subnet.Mask)
+	unmasquerade, err = masquerade(hostfd, "notwork-"+base62.Random(8), &subnet)
+	Expect(err).NotTo(HaveOccurred(), "cannot masquerade uplink subnet")
+	return
+}
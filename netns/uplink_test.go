@@ -0,0 +1,102 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package netns
+
+import (
+	"net"
+	"os"
+	"time"
+
+	"github.com/thediveo/notwork/link"
+	"github.com/vishvananda/netlink"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gleak"
+	. "github.com/thediveo/fdooze"
+	. "github.com/thediveo/success"
+)
+
+var _ = Describe("uplinked network namespaces", func() {
+
+	BeforeEach(func() {
+		if os.Getuid() != 0 {
+			Skip("needs root")
+		}
+		goodfds := Filedescriptors()
+		goodgos := Goroutines()
+		DeferCleanup(func() {
+			Eventually(Goroutines).Within(2 * time.Second).ProbeEvery(250 * time.Millisecond).
+				ShouldNot(HaveLeaked(goodgos))
+			Expect(Filedescriptors()).NotTo(HaveLeakedFds(goodfds))
+		})
+	})
+
+	It("creates a network namespace with masqueraded egress", func() {
+		// We use a transient network namespace as the “host”, so we don't
+		// touch the configuration of the initial network namespace.
+		defer EnterTransient()()
+		hostfd := Current()
+		Expect(Sysctl(hostfd, "net.ipv4.ip_forward")).To(Equal("0"))
+
+		By("connecting a remote network namespace to the host")
+		remotefd := NewTransient()
+		hostVeth := link.NewTransient(&netlink.Veth{
+			PeerNamespace: netlink.NsFd(remotefd),
+		}, "veth-", link.WithAddr("10.0.9.1/24"))
+		Expect(netlink.LinkSetUp(hostVeth)).To(Succeed())
+		remoteh := NewNetlinkHandle(remotefd)
+		remoteVeth := Successful(remoteh.LinkByName(hostVeth.(*netlink.Veth).PeerName))
+		Expect(remoteh.AddrAdd(remoteVeth, Successful(netlink.ParseAddr("10.0.9.2/24")))).To(Succeed())
+		Expect(remoteh.LinkSetUp(remoteVeth)).To(Succeed())
+
+		By("creating an uplinked network namespace")
+		netnsfd, cleanup := NewTransientUplinked()
+		Expect(Sysctl(hostfd, "net.ipv4.ip_forward")).To(Equal("1"))
+		nlh := NewNetlinkHandle(netnsfd)
+		Expect(Successful(nlh.RouteList(nil, netlink.FAMILY_V4))).To(ContainElement(
+			HaveField("Dst", Or(BeNil(), WithTransform(func(dst *net.IPNet) string { return dst.String() }, Equal("0.0.0.0/0"))))))
+
+		By("connecting from the uplinked network namespace to the remote")
+		var l net.Listener
+		Execute(remotefd, func() {
+			l = Successful(net.Listen("tcp", "10.0.9.2:0"))
+		})
+		defer l.Close()
+		peers := make(chan net.Addr, 1)
+		go func() {
+			defer GinkgoRecover()
+			conn, err := l.Accept()
+			if err == nil {
+				peers <- conn.RemoteAddr()
+				conn.Close()
+			}
+		}()
+		Execute(netnsfd, func() {
+			conn := Successful(net.DialTimeout("tcp", l.Addr().String(), 2*time.Second))
+			conn.Close()
+		})
+		var peer net.Addr
+		Eventually(peers).Should(Receive(&peer))
+		Expect(peer.(*net.TCPAddr).IP.String()).To(Equal("10.0.9.1"), "not masqueraded")
+
+		By("cleaning up early")
+		cleanup()
+		Expect(Sysctl(hostfd, "net.ipv4.ip_forward")).To(Equal("0"))
+		Expect(Successful(nlh.LinkList())).To(HaveLen(1))
+		Expect(cleanup).NotTo(Panic())
+	})
+
+})
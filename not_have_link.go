@@ -0,0 +1,65 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notwork
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/onsi/gomega/gcustom"
+	"github.com/onsi/gomega/types"
+	"github.com/thediveo/notwork/link"
+	"github.com/vishvananda/netlink"
+)
+
+// NotHaveLink succeeds if the network namespace (in form of a file descriptor
+// referencing it) or [netlink.Handle] doesn't have a network interface with the
+// specified name. In contrast to just expecting an error when looking up the
+// network interface, NotHaveLink errors on any other error than the network
+// interface not being found, such as lacking privileges.
+//
+//	Expect(netnsfd).To(notwork.NotHaveLink("eth0"))
+func NotHaveLink(name string) types.GomegaMatcher {
+	return gcustom.MakeMatcher(func(actual any) (bool, error) {
+		var nlh *netlink.Handle
+		switch ref := actual.(type) {
+		case int:
+			var err error
+			nlh, err = link.NewHandle(&netlink.Device{
+				LinkAttrs: netlink.LinkAttrs{Namespace: netlink.NsFd(ref)},
+			})
+			if err != nil {
+				return false, fmt.Errorf("NotHaveLink cannot create netlink handle for network namespace, reason: %w", err)
+			}
+			defer nlh.Close()
+		case *netlink.Handle:
+			if ref == nil {
+				return false, errors.New("NotHaveLink expects non-nil *netlink.Handle")
+			}
+			nlh = ref
+		default:
+			return false, fmt.Errorf("NotHaveLink expects a network namespace fd or *netlink.Handle, but got %T", actual)
+		}
+		_, err := nlh.LinkByName(name)
+		if err == nil {
+			return false, nil
+		}
+		var notFoundErr netlink.LinkNotFoundError
+		if !errors.As(err, &notFoundErr) {
+			return false, fmt.Errorf("NotHaveLink cannot determine absence of network interface %q, reason: %w", name, err)
+		}
+		return true, nil
+	}).WithTemplate("Expected:\n{{.FormattedActual}}\n{{.To}} not have network interface {{.Data}}", name)
+}
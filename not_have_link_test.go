@@ -0,0 +1,53 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notwork
+
+import (
+	"os"
+
+	"github.com/thediveo/notwork/netns"
+	"github.com/vishvananda/netlink"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/thediveo/success"
+)
+
+var _ = Describe("NotHaveLink matcher", func() {
+
+	It("rejects invalid actual values", func() {
+		Expect(NotHaveLink("lo").Match("42")).Error().To(
+			MatchError(ContainSubstring("expects a network namespace fd or *netlink.Handle")))
+		Expect(NotHaveLink("lo").Match((*netlink.Handle)(nil))).Error().To(
+			MatchError(ContainSubstring("expects non-nil")))
+	})
+
+	It("matches missing network interfaces", func() {
+		if os.Getuid() != 0 {
+			Skip("needs root")
+		}
+		netnsfd := netns.NewTransient()
+		Expect(netnsfd).To(NotHaveLink("foobar"))
+		Expect(netnsfd).NotTo(NotHaveLink("lo"))
+		nlh := netns.NewNetlinkHandle(netnsfd)
+		Expect(nlh).To(NotHaveLink("foobar"))
+		Expect(nlh).NotTo(NotHaveLink("lo"))
+		f := Successful(os.Open("/dev/null"))
+		defer f.Close()
+		Expect(NotHaveLink("foobar").Match(int(f.Fd()))).Error().To(
+			MatchError(ContainSubstring("cannot create netlink handle")))
+	})
+
+})
@@ -0,0 +1,31 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+Package tuntap helps with creating transient [TUN/TAP] network interfaces for
+testing purposes, together with the file descriptors for their queues in order
+to drive network traffic. It leverages the [Ginkgo] testing framework and
+matching (erm, sic!) [Gomega] matchers.
+
+These TUN/TAP network interfaces are transient because they automatically get
+removed at the end of the a test (spec, block/group, suite, et cetera) using
+Ginkgo's [DeferCleanup]. The queue file descriptors get closed before removing
+the network interface.
+
+[TUN/TAP]: https://docs.kernel.org/networking/tuntap.html
+[Ginkgo]: https://github.com/onsi/ginkgo
+[Gomega]: https://github.com/onsi/gomega
+[DeferCleanup]: https://pkg.go.dev/github.com/onsi/ginkgo/v2#DeferCleanup
+*/
+package tuntap
@@ -0,0 +1,79 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tuntap
+
+import (
+	"fmt"
+
+	"github.com/thediveo/notwork/link"
+	"github.com/vishvananda/netlink"
+)
+
+// WithMultiQueue configures a TUN/TAP network interface to have n queues,
+// with [NewTransient] returning a file for each queue.
+func WithMultiQueue(n int) Opt {
+	return func(l *link.Link) error {
+		if n <= 0 {
+			return fmt.Errorf("invalid number of queues %d", n)
+		}
+		tuntap := l.Link.(*netlink.Tuntap)
+		tuntap.Queues = n
+		tuntap.Flags |= netlink.TUNTAP_MULTI_QUEUE
+		return nil
+	}
+}
+
+// WithNonPersist configures a TUN/TAP network interface to not be persistent,
+// so that it automatically vanishes as soon as its queue files get closed.
+// The network interface then isn't explicitly removed anymore, but instead
+// goes away when the deferred cleanup closes its queue files.
+func WithNonPersist() Opt {
+	return func(l *link.Link) error {
+		l.Link.(*netlink.Tuntap).NonPersist = true
+		l.NoCleanup = true
+		return nil
+	}
+}
+
+// WithOwner configures the owning user and group of a TUN/TAP network
+// interface, allowing the specified user and group to attach to the network
+// interface.
+func WithOwner(uid, gid int) Opt {
+	return func(l *link.Link) error {
+		if uid < 0 || gid < 0 {
+			return fmt.Errorf("invalid owner %d:%d", uid, gid)
+		}
+		tuntap := l.Link.(*netlink.Tuntap)
+		tuntap.Owner = uint32(uid)
+		tuntap.Group = uint32(gid)
+		return nil
+	}
+}
+
+// WithUp configures a TUN/TAP network interface to be brought up right after
+// creation.
+func WithUp() Opt {
+	return func(l *link.Link) error {
+		l.SetupFns = append(l.SetupFns, netlink.LinkSetUp)
+		return nil
+	}
+}
+
+// WithAddr configures an IPv4 or IPv6 address in CIDR notation (such as
+// “10.0.0.1/24” or “fd00::1/64”) to be assigned to a TUN/TAP network interface
+// right after creation.
+func WithAddr(cidr string) Opt {
+	return Opt(link.WithAddr(cidr))
+}
@@ -0,0 +1,59 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tuntap
+
+import (
+	"github.com/thediveo/notwork/link"
+	"github.com/vishvananda/netlink"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("TUN/TAP configuration options", func() {
+
+	It("configures TUN/TAPs", func() {
+		l := &link.Link{Link: &netlink.Tuntap{Flags: netlink.TUNTAP_NO_PI}}
+		for _, opt := range []Opt{
+			WithMultiQueue(4),
+			WithNonPersist(),
+			WithOwner(1000, 1001),
+			WithUp(),
+		} {
+			Expect(opt(l)).To(Succeed())
+		}
+		Expect(l.Link).To(And(
+			HaveField("Queues", 4),
+			HaveField("Flags", netlink.TUNTAP_NO_PI|netlink.TUNTAP_MULTI_QUEUE),
+			HaveField("NonPersist", true),
+			HaveField("Owner", uint32(1000)),
+			HaveField("Group", uint32(1001))))
+		Expect(l.NoCleanup).To(BeTrue())
+		Expect(l.SetupFns).To(HaveLen(1))
+	})
+
+	It("rejects invalid options", func() {
+		Expect(WithMultiQueue(0)(&link.Link{Link: &netlink.Tuntap{}})).NotTo(Succeed())
+		Expect(WithOwner(-1, 0)(&link.Link{Link: &netlink.Tuntap{}})).NotTo(Succeed())
+		Expect(WithOwner(0, -1)(&link.Link{Link: &netlink.Tuntap{}})).NotTo(Succeed())
+	})
+
+	It("configures an address", func() {
+		l := &link.Link{Link: &netlink.Tuntap{}}
+		Expect(WithAddr("10.0.0.1/24")(l)).To(Succeed())
+		Expect(l.Addrs).To(ConsistOf(HaveField("IPNet.String()", "10.0.0.1/24")))
+	})
+
+})
@@ -0,0 +1,77 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tuntap
+
+import (
+	"os"
+
+	"github.com/thediveo/notwork/link"
+	"github.com/vishvananda/netlink"
+
+	. "github.com/onsi/ginkgo/v2" //lint:ignore ST1001 rule does not apply
+	. "github.com/onsi/gomega"    //lint:ignore ST1001 rule does not apply
+)
+
+// TuntapPrefix is the name prefix used for transient TUN/TAP network
+// interfaces.
+const TuntapPrefix = "tunt-"
+
+// Opt is a configuration option when creating a new TUN/TAP network interface.
+type Opt func(*link.Link) error
+
+// NewTransient creates a transient TUN/TAP network interface in the current
+// network namespace, returning the link together with the file(s) for its
+// queue(s). The mode is either [netlink.TUNTAP_MODE_TUN] or
+// [netlink.TUNTAP_MODE_TAP]. Unless configured otherwise using
+// [WithMultiQueue], the TUN/TAP network interface has a single queue. Packets
+// read from and written to the returned files come without any additional
+// packet information header.
+//
+// NewTransient automatically defers closing the returned files, followed by
+// proper automatic removal of the TUN/TAP network interface.
+//
+// As TUN/TAP network interfaces are always created in the current network
+// namespace, use [netns.EnterTransient] or [netns.Execute] in order to create
+// them in a different network namespace.
+//
+// [netns.EnterTransient]: https://pkg.go.dev/github.com/thediveo/notwork/netns#EnterTransient
+// [netns.Execute]: https://pkg.go.dev/github.com/thediveo/notwork/netns#Execute
+func NewTransient(mode netlink.TuntapMode, opts ...Opt) (netlink.Link, []*os.File) {
+	GinkgoHelper()
+
+	Expect(mode).To(BeElementOf(netlink.TUNTAP_MODE_TUN, netlink.TUNTAP_MODE_TAP),
+		"unsupported TUN/TAP mode %d", mode)
+	tuntap := &link.Link{
+		Link: &netlink.Tuntap{
+			Mode:   mode,
+			Flags:  netlink.TUNTAP_NO_PI,
+			Queues: 1,
+		},
+	}
+	for _, opt := range opts {
+		Expect(opt(tuntap)).To(Succeed())
+	}
+	l := link.NewTransient(tuntap, TuntapPrefix)
+	// As DeferCleanup runs the cleanup functions in reverse order of their
+	// registration, the files get closed before the network interface gets
+	// removed.
+	fds := l.(*netlink.Tuntap).Fds
+	DeferCleanup(func() {
+		for _, fd := range fds {
+			_ = fd.Close()
+		}
+	})
+	return l, fds
+}
@@ -0,0 +1,123 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tuntap
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"time"
+
+	"github.com/thediveo/notwork/bridge"
+	"github.com/thediveo/notwork/netns"
+	"github.com/vishvananda/netlink"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gleak"
+	. "github.com/thediveo/fdooze"
+	. "github.com/thediveo/success"
+)
+
+// ethertype from the IEEE 802 local experimental range, so that we can tell
+// our test frames from any other traffic, such as IPv6 neighbor discovery.
+const localExperimentalEthertype = 0x88b5
+
+var _ = Describe("provides transient TUN/TAP network interfaces", func() {
+
+	BeforeEach(func() {
+		if os.Getuid() != 0 {
+			Skip("needs root")
+		}
+		goodfds := Filedescriptors()
+		goodgos := Goroutines()
+		DeferCleanup(func() {
+			Eventually(Goroutines).Within(2 * time.Second).ProbeEvery(250 * time.Millisecond).
+				ShouldNot(HaveLeaked(goodgos))
+			Expect(Filedescriptors()).NotTo(HaveLeakedFds(goodfds))
+		})
+	})
+
+	It("rejects an invalid mode", func() {
+		Expect(InterceptGomegaFailure(func() {
+			_, _ = NewTransient(netlink.TuntapMode(42))
+		})).To(MatchError(ContainSubstring("unsupported TUN/TAP mode 42")))
+	})
+
+	It("creates a multi-queue TUN", func() {
+		defer netns.EnterTransient()()
+		tun, fds := NewTransient(netlink.TUNTAP_MODE_TUN, WithMultiQueue(3))
+		Expect(tun.Attrs().Name).To(HavePrefix(TuntapPrefix))
+		Expect(fds).To(HaveLen(3))
+		l := Successful(netlink.LinkByName(tun.Attrs().Name))
+		Expect(l.Type()).To(Equal("tuntap"))
+		Expect(l).To(HaveField("Mode", netlink.TUNTAP_MODE_TUN))
+	})
+
+	It("creates a non-persistent TAP that vanishes after closing its files", func() {
+		defer netns.EnterTransient()()
+		var name string
+		By("creating a non-persistent TAP in a separate scope", func() {
+			DeferCleanup(func() {
+				Expect(netlink.LinkByName(name)).Error().To(
+					BeAssignableToTypeOf(netlink.LinkNotFoundError{}))
+			})
+			tap, _ := NewTransient(netlink.TUNTAP_MODE_TAP, WithNonPersist())
+			name = tap.Attrs().Name
+			Expect(netlink.LinkByName(name)).Error().NotTo(HaveOccurred())
+		})
+	})
+
+	It("passes frames between TAPs via their files", func() {
+		defer netns.EnterTransient()()
+
+		tap1, fds1 := NewTransient(netlink.TUNTAP_MODE_TAP, WithUp())
+		tap2, fds2 := NewTransient(netlink.TUNTAP_MODE_TAP, WithUp())
+		Expect(fds1).To(HaveLen(1))
+		Expect(fds2).To(HaveLen(1))
+
+		br := bridge.NewTransient()
+		bridge.Enslave(br, tap1)
+		bridge.Enslave(br, tap2)
+		Expect(netlink.LinkSetUp(br)).To(Succeed())
+
+		frame := []byte{
+			0xff, 0xff, 0xff, 0xff, 0xff, 0xff, // broadcast destination
+			0x02, 0x00, 0x00, 0x00, 0x00, 0x01, // locally administered source
+			localExperimentalEthertype >> 8, localExperimentalEthertype & 0xff,
+		}
+		frame = append(frame, []byte("Hello, TAP!")...)
+
+		// As the bridge ports might not yet be forwarding, keep on sending
+		// the frame until it finally shows up at the other TAP.
+		buff := make([]byte, 2048)
+		Eventually(func() []byte {
+			_ = Successful(fds1[0].Write(frame))
+			Expect(fds2[0].SetReadDeadline(time.Now().Add(100 * time.Millisecond))).To(Succeed())
+			for {
+				n, err := fds2[0].Read(buff)
+				if errors.Is(err, os.ErrDeadlineExceeded) {
+					return nil
+				}
+				Expect(err).NotTo(HaveOccurred())
+				if bytes.Equal(buff[:n], frame) {
+					return buff[:n]
+				}
+			}
+		}).Within(5 * time.Second).ProbeEvery(100 * time.Millisecond).
+			Should(Equal(frame))
+	})
+
+})
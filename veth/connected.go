@@ -0,0 +1,95 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package veth
+
+import (
+	"time"
+
+	"github.com/thediveo/notwork/link"
+	"github.com/thediveo/notwork/netns"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+
+	. "github.com/onsi/ginkgo/v2" //lint:ignore ST1001 rule does not apply
+	. "github.com/onsi/gomega"    //lint:ignore ST1001 rule does not apply
+)
+
+// dadTimeout is the maximum duration to wait for IPv6 duplicate address
+// detection to finish.
+const dadTimeout = 5 * time.Second
+
+// NewConnected creates a new (and transient) VETH pair of network interfaces,
+// assigns the IPv4 or IPv6 addresses aCIDR and bCIDR in CIDR notation to the
+// “first” and peer VETH ends respectively, and brings both ends up. For IPv6
+// addresses, NewConnected additionally waits for duplicate address detection
+// to finish. The VETH ends can be placed into other network namespaces using
+// the [InNamespace] and [WithPeerNamespace] options.
+//
+// The returned ok function pings the peer end's address from the network
+// namespace of the “first” VETH end, returning true if an echo reply arrived
+// in time. Please note that when both VETH ends are in the same network
+// namespace, the Linux kernel routes the pings locally, so ok doesn't tell
+// much in this case.
+func NewConnected(aCIDR, bCIDR string, opts ...Opt) (a, b netlink.Link, ok func() bool) {
+	GinkgoHelper()
+
+	aAddr, err := netlink.ParseAddr(aCIDR)
+	Expect(err).NotTo(HaveOccurred(), "invalid address %q", aCIDR)
+	bAddr, err := netlink.ParseAddr(bCIDR)
+	Expect(err).NotTo(HaveOccurred(), "invalid address %q", bCIDR)
+	Expect(aAddr.IP.To4() != nil).To(Equal(bAddr.IP.To4() != nil),
+		"addresses %q and %q must be of the same IP family", aCIDR, bCIDR)
+
+	a, b = NewTransientUp(opts...)
+	// Resolve unset network namespace references right now, as the caller
+	// might call ok later from a different network namespace.
+	var current netlink.NsFd = -1
+	for _, l := range []netlink.Link{a, b} {
+		if _, ok := l.Attrs().Namespace.(netlink.NsFd); ok {
+			continue
+		}
+		if current < 0 {
+			current = netlink.NsFd(netns.Current())
+		}
+		l.Attrs().Namespace = current
+	}
+	aNetns := a.Attrs().Namespace.(netlink.NsFd)
+
+	link.AddAddr(a, aCIDR)
+	link.AddAddr(b, bCIDR)
+	if aAddr.IP.To4() == nil {
+		// DAD starts after a random delay of up to a second and then takes
+		// another second, so we need to be more patient than usual.
+		link.WaitAddressReady(a, aAddr.IP, dadTimeout)
+		link.WaitAddressReady(b, bAddr.IP, dadTimeout)
+	}
+
+	dst := bAddr.IP
+	var seq uint16
+	ok = func() bool {
+		GinkgoHelper()
+
+		seq++
+		reached := false
+		netns.Execute(int(aNetns), func() {
+			fd, err := pingSocket(dst)
+			Expect(err).NotTo(HaveOccurred(), "cannot create ICMP socket")
+			defer unix.Close(fd)
+			reached = ping(fd, dst, seq, time.Second)
+		})
+		return reached
+	}
+	return
+}
@@ -15,6 +15,11 @@
 package veth
 
 import (
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+
 	"github.com/thediveo/notwork/link"
 	"github.com/vishvananda/netlink"
 )
@@ -39,3 +44,94 @@ func WithPeerNamespace(fd int) Opt {
 		return nil
 	}
 }
+
+// WithAddr configures an IPv4 or IPv6 address in CIDR notation (such as
+// “10.0.0.1/24” or “fd00::1/64”) to be assigned to the “first” VETH network
+// interface right after creation. The VETH peer end doesn't get any address
+// assigned.
+func WithAddr(cidr string) Opt {
+	return Opt(link.WithAddr(cidr))
+}
+
+// WithNames configures the names of both VETH ends explicitly, instead of using
+// random names. The first name is for the “first” VETH network interface and
+// the second name for the VETH peer end. Names must be at most 15 characters
+// long and differ from each other; creating the VETH pair fails if a name is
+// already in use. The VETH pair is still scheduled for automatic removal.
+//
+// Please note that fixed names are prone to clash when running tests in
+// parallel, so this option is mainly intended for reproducible debugging.
+func WithNames(a, b string) Opt {
+	return func(l *link.Link) error {
+		for _, name := range []string{a, b} {
+			if name == "" || len(name) > 15 {
+				return fmt.Errorf("invalid VETH network interface name %q", name)
+			}
+		}
+		if a == b {
+			return errors.New("VETH network interface names must differ")
+		}
+		l.Attrs().Name = a
+		l.Link.(*netlink.Veth).PeerName = b
+		l.FixedNames = true
+		return nil
+	}
+}
+
+// WithMTU configures both VETH ends to be created with the specified MTU, which
+// must be positive and must not exceed 65535.
+func WithMTU(mtu int) Opt {
+	return Opt(link.WithMTU(mtu))
+}
+
+// WithHardwareAddr configures the “first” VETH network interface to be created
+// with the specified MAC address, which must be 6 bytes long.
+func WithHardwareAddr(mac net.HardwareAddr) Opt {
+	return func(l *link.Link) error {
+		if len(mac) != 6 {
+			return fmt.Errorf("invalid MAC address %q, must be 6 bytes long", mac)
+		}
+		l.Attrs().HardwareAddr = slices.Clone(mac)
+		return nil
+	}
+}
+
+// WithPeerHardwareAddr configures the VETH peer end to get the specified MAC
+// address, which must be 6 bytes long, right after creation. The MAC address
+// gets set in the network namespace of the peer end, see also
+// [WithPeerNamespace].
+func WithPeerHardwareAddr(mac net.HardwareAddr) Opt {
+	return func(l *link.Link) error {
+		if len(mac) != 6 {
+			return fmt.Errorf("invalid MAC address %q, must be 6 bytes long", mac)
+		}
+		mac := slices.Clone(mac)
+		l.SetupFns = append(l.SetupFns, func(veth netlink.Link) error {
+			return setPeerHardwareAddr(veth.(*netlink.Veth), mac)
+		})
+		return nil
+	}
+}
+
+// setPeerHardwareAddr sets the MAC address of the peer end of the specified
+// VETH pair in the peer's network namespace, as referenced by
+// [netlink.Veth.PeerNamespace]; if unset, in the current network namespace.
+func setPeerHardwareAddr(veth *netlink.Veth, mac net.HardwareAddr) error {
+	nlh, err := link.NewHandle(&netlink.Device{
+		LinkAttrs: netlink.LinkAttrs{Namespace: veth.PeerNamespace},
+	})
+	if err != nil {
+		return err
+	}
+	defer nlh.Close()
+	peer, err := nlh.LinkByName(veth.PeerName)
+	if err != nil {
+		return fmt.Errorf("cannot find VETH peer network interface %q, reason: %w",
+			veth.PeerName, err)
+	}
+	if err := nlh.LinkSetHardwareAddr(peer, mac); err != nil {
+		return fmt.Errorf("cannot set MAC address of network interface %q to %s, reason: %w",
+			veth.PeerName, mac, err)
+	}
+	return nil
+}
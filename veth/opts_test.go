@@ -15,6 +15,8 @@
 package veth
 
 import (
+	"net"
+
 	"github.com/thediveo/notwork/link"
 	"github.com/vishvananda/netlink"
 
@@ -36,4 +38,49 @@ var _ = Describe("MACVLAN configuration options", func() {
 		Expect(l.Link).To(HaveField("PeerNamespace", netlink.NsFd(666)))
 	})
 
+	It("configures fixed names", func() {
+		l := &link.Link{Link: &netlink.Veth{}}
+		Expect(WithNames("dupond", "dupont")(l)).To(Succeed())
+		Expect(l.Attrs().Name).To(Equal("dupond"))
+		Expect(l.Link).To(HaveField("PeerName", "dupont"))
+		Expect(l.FixedNames).To(BeTrue())
+	})
+
+	It("rejects invalid names", func() {
+		Expect(WithNames("", "dupont")(&link.Link{Link: &netlink.Veth{}})).NotTo(Succeed())
+		Expect(WithNames("dupond", "a-very-long-name-1")(&link.Link{Link: &netlink.Veth{}})).NotTo(Succeed())
+		Expect(WithNames("dupond", "dupond")(&link.Link{Link: &netlink.Veth{}})).To(
+			MatchError(ContainSubstring("must differ")))
+	})
+
+	It("configures MAC addresses", func() {
+		mac := net.HardwareAddr{0x02, 0x00, 0x00, 0x00, 0x00, 0x01}
+		l := &link.Link{Link: &netlink.Veth{}}
+		Expect(WithHardwareAddr(mac)(l)).To(Succeed())
+		Expect(WithPeerHardwareAddr(mac)(l)).To(Succeed())
+		Expect(l.Attrs().HardwareAddr).To(Equal(mac))
+		Expect(l.SetupFns).To(HaveLen(1))
+	})
+
+	It("rejects invalid MAC addresses", func() {
+		mac := net.HardwareAddr{0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01}
+		Expect(WithHardwareAddr(mac)(&link.Link{Link: &netlink.Veth{}})).To(
+			MatchError(ContainSubstring("must be 6 bytes long")))
+		Expect(WithPeerHardwareAddr(nil)(&link.Link{Link: &netlink.Veth{}})).To(
+			MatchError(ContainSubstring("must be 6 bytes long")))
+	})
+
+	It("configures an address", func() {
+		l := &link.Link{Link: &netlink.Veth{}}
+		Expect(WithAddr("10.0.0.1/24")(l)).To(Succeed())
+		Expect(l.Addrs).To(ConsistOf(HaveField("IPNet.String()", "10.0.0.1/24")))
+	})
+
+	It("configures the MTU", func() {
+		l := &link.Link{Link: &netlink.Veth{}}
+		Expect(WithMTU(9000)(l)).To(Succeed())
+		Expect(l.Attrs().MTU).To(Equal(9000))
+		Expect(WithMTU(0)(l)).NotTo(Succeed())
+	})
+
 })
@@ -0,0 +1,121 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package veth
+
+import (
+	"encoding/binary"
+	"net"
+	"os"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	icmpv4EchoRequest = 8
+	icmpv4EchoReply   = 0
+	icmpv6EchoRequest = 128
+	icmpv6EchoReply   = 129
+)
+
+// pingSocket returns a raw ICMP or ICMPv6 socket, depending on the IP family
+// of dst, in the current network namespace.
+func pingSocket(dst net.IP) (int, error) {
+	if dst.To4() != nil {
+		return unix.Socket(unix.AF_INET, unix.SOCK_RAW|unix.SOCK_CLOEXEC, unix.IPPROTO_ICMP)
+	}
+	return unix.Socket(unix.AF_INET6, unix.SOCK_RAW|unix.SOCK_CLOEXEC, unix.IPPROTO_ICMPV6)
+}
+
+// ping sends a single ICMP or ICMPv6 echo request to dst using the raw socket
+// fd, as returned by [pingSocket], and waits at most for the specified
+// duration for the matching echo reply, returning true if it arrived in time.
+func ping(fd int, dst net.IP, seq uint16, within time.Duration) bool {
+	id := uint16(os.Getpid())
+	req := make([]byte, 8, 8+16)
+	binary.BigEndian.PutUint16(req[4:], id)
+	binary.BigEndian.PutUint16(req[6:], seq)
+	req = append(req, "notwork notwork!"...)
+
+	var sa unix.Sockaddr
+	replyType := byte(icmpv6EchoReply)
+	if ip4 := dst.To4(); ip4 != nil {
+		req[0] = icmpv4EchoRequest
+		binary.BigEndian.PutUint16(req[2:], checksum(req))
+		sa = &unix.SockaddrInet4{Addr: [4]byte(ip4)}
+		replyType = icmpv4EchoReply
+	} else {
+		// the Linux kernel calculates the ICMPv6 checksum for us.
+		req[0] = icmpv6EchoRequest
+		sa = &unix.SockaddrInet6{Addr: [16]byte(dst.To16())}
+	}
+	if err := unix.Sendto(fd, req, 0, sa); err != nil {
+		return false
+	}
+
+	deadline := time.Now().Add(within)
+	buff := make([]byte, 1500)
+	for {
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return false
+		}
+		tv := unix.NsecToTimeval(remaining.Nanoseconds())
+		if err := unix.SetsockoptTimeval(fd, unix.SOL_SOCKET, unix.SO_RCVTIMEO, &tv); err != nil {
+			return false
+		}
+		n, from, err := unix.Recvfrom(fd, buff, 0)
+		if err != nil {
+			if err == unix.EINTR {
+				continue
+			}
+			return false
+		}
+		reply := buff[:n]
+		if from4, ok := from.(*unix.SockaddrInet4); ok {
+			if !net.IP(from4.Addr[:]).Equal(dst) {
+				continue
+			}
+			// raw IPv4 sockets pass us the IP header too, so skip it.
+			if len(reply) < 1 || len(reply) < int(reply[0]&0x0f)*4 {
+				continue
+			}
+			reply = reply[int(reply[0]&0x0f)*4:]
+		} else if from6, ok := from.(*unix.SockaddrInet6); !ok || !net.IP(from6.Addr[:]).Equal(dst) {
+			continue
+		}
+		if len(reply) < 8 || reply[0] != replyType ||
+			binary.BigEndian.Uint16(reply[4:]) != id ||
+			binary.BigEndian.Uint16(reply[6:]) != seq {
+			continue
+		}
+		return true
+	}
+}
+
+// checksum returns the Internet checksum (RFC 1071) of b.
+func checksum(b []byte) uint16 {
+	var sum uint32
+	for ; len(b) >= 2; b = b[2:] {
+		sum += uint32(b[0])<<8 | uint32(b[1])
+	}
+	if len(b) == 1 {
+		sum += uint32(b[0]) << 8
+	}
+	for sum > 0xffff {
+		sum = sum>>16 + sum&0xffff
+	}
+	return ^uint16(sum)
+}
@@ -16,6 +16,7 @@ package veth
 
 import (
 	"github.com/thediveo/notwork/link"
+	"github.com/thediveo/notwork/netns"
 	"github.com/vishvananda/netlink"
 	vishnetns "github.com/vishvananda/netns"
 
@@ -34,31 +35,50 @@ type Opt func(*link.Link) error
 // NewTransient creates and returns a new (and transient) VETH pair of network
 // interfaces. The one VETH end is created in the current network namespace,
 // while the other VETH end can optionally be created in a differend network
-// namespace using [WithPeerNamespace].
+// namespace using [WithPeerNamespace]. In this case, the returned peer link
+// references its network namespace in [netlink.LinkAttrs.Namespace].
 //
 // See also: https://en.wikipedia.org/wiki/Thomson_and_Thompson
 func NewTransient(opts ...Opt) (dupond netlink.Link, dupont netlink.Link) {
 	GinkgoHelper()
-	veth := &link.Link{
-		Link: &netlink.Veth{
-			LinkAttrs: netlink.LinkAttrs{},
-		},
-	}
+
+	lopts := make([]link.Opt, 0, len(opts))
 	for _, opt := range opts {
-		Expect(opt(veth)).To(Succeed())
+		lopts = append(lopts, link.Opt(opt))
 	}
-	dupond = link.NewTransient(veth, VethPrefix)
+	veth, dupond := link.NewTransientTyped(&netlink.Veth{}, VethPrefix, lopts...)
 	// Now things get tricky as want to return proper link information about the
 	// peer; unfortunately, RTNETLINK again acts odd: with the destination
 	// network namespace set, if the peer network namespace is unset then the
 	// peer will end up in the current(!) network namespace, not in the
 	// destination network namespace. Yuck.
-	if peerNamespace := veth.Link.(*netlink.Veth).PeerNamespace; peerNamespace != nil {
+	if peerNamespace := veth.PeerNamespace; peerNamespace != nil {
 		nlh := Successful(netlink.NewHandleAt(vishnetns.NsHandle(int(peerNamespace.(netlink.NsFd)))))
 		defer nlh.Close()
-		dupont = Successful(nlh.LinkByName(dupond.(*netlink.Veth).PeerName))
+		dupont = Successful(nlh.LinkByName(veth.PeerName))
+		dupont.Attrs().Namespace = peerNamespace
 		return
 	}
-	dupont = Successful(netlink.LinkByName(dupond.(*netlink.Veth).PeerName))
+	dupont = Successful(netlink.LinkByName(veth.PeerName))
+	return
+}
+
+// NewTransientUp works like [NewTransient], but additionally brings both VETH
+// ends up and waits for them to become operationally up, each in its own
+// network namespace.
+func NewTransientUp(opts ...Opt) (dupond netlink.Link, dupont netlink.Link) {
+	GinkgoHelper()
+
+	dupond, dupont = NewTransient(opts...)
+	// A VETH end only becomes operationally up when its peer is (at least
+	// administratively) up too, so bring up the peer first without waiting.
+	nlh := &netlink.Handle{} // works in the current network namespace
+	if netnsfd, ok := dupont.Attrs().Namespace.(netlink.NsFd); ok {
+		nlh = netns.NewNetlinkHandle(int(netnsfd))
+	}
+	Expect(nlh.LinkSetUp(dupont)).To(Succeed(),
+		"cannot bring up network interface %q", dupont.Attrs().Name)
+	link.EnsureUp(dupond)
+	link.EnsureUp(dupont)
 	return
 }
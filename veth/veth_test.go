@@ -15,6 +15,7 @@
 package veth
 
 import (
+	"net"
 	"os"
 	"time"
 
@@ -110,4 +111,94 @@ var _ = Describe("provides transient VETH network interface pairs", Ordered, fun
 		Expect(netlink.LinkByName(dupont.Attrs().Name)).Error().To(HaveOccurred())
 	})
 
+	It("creates a VETH pair with a jumbo MTU", func() {
+		defer netns.EnterTransient()()
+
+		dupond, dupont := NewTransient(WithMTU(9000))
+		Expect(Successful(netlink.LinkByName(dupond.Attrs().Name)).Attrs().MTU).To(Equal(9000))
+		Expect(Successful(netlink.LinkByName(dupont.Attrs().Name)).Attrs().MTU).To(Equal(9000))
+	})
+
+	It("creates a VETH pair with fixed names", func() {
+		netnsfd := netns.NewTransient()
+
+		dupond, dupont := NewTransient(
+			InNamespace(netnsfd), WithPeerNamespace(netnsfd),
+			WithNames("dupond", "dupont"))
+		Expect(dupond.Attrs().Name).To(Equal("dupond"))
+		Expect(dupont.Attrs().Name).To(Equal("dupont"))
+		nlh := netns.NewNetlinkHandle(netnsfd)
+		Expect(Successful(nlh.LinkByName("dupond")).Attrs().Index).To(Equal(dupond.Attrs().Index))
+		Expect(Successful(nlh.LinkByName("dupont")).Attrs().Index).To(Equal(dupont.Attrs().Index))
+	})
+
+	It("creates a VETH pair with fixed MAC addresses across network namespaces", func() {
+		dupondNetnsfd := netns.NewTransient()
+		dupontNetnsfd := netns.NewTransient()
+		dupondMAC := net.HardwareAddr{0x02, 0x00, 0x00, 0x00, 0x00, 0x01}
+		dupontMAC := net.HardwareAddr{0x02, 0x00, 0x00, 0x00, 0x00, 0x02}
+
+		dupond, dupont := NewTransient(
+			InNamespace(dupondNetnsfd), WithPeerNamespace(dupontNetnsfd),
+			WithHardwareAddr(dupondMAC), WithPeerHardwareAddr(dupontMAC))
+		Expect(Successful(netns.NewNetlinkHandle(dupondNetnsfd).LinkByIndex(dupond.Attrs().Index))).To(
+			HaveField("Attrs().HardwareAddr", dupondMAC))
+		Expect(Successful(netns.NewNetlinkHandle(dupontNetnsfd).LinkByIndex(dupont.Attrs().Index))).To(
+			HaveField("Attrs().HardwareAddr", dupontMAC))
+		Expect(dupont.Attrs().HardwareAddr).To(Equal(dupontMAC))
+	})
+
+	It("creates a VETH pair across network namespaces with both ends up", func() {
+		dupondNetnsfd := netns.NewTransient()
+		dupontNetnsfd := netns.NewTransient()
+
+		dupond, dupont := NewTransientUp(
+			InNamespace(dupondNetnsfd), WithPeerNamespace(dupontNetnsfd))
+		Expect(dupont.Attrs().Namespace).To(Equal(netlink.NsFd(dupontNetnsfd)))
+		Expect(Successful(netns.NewNetlinkHandle(dupondNetnsfd).LinkByIndex(dupond.Attrs().Index))).To(
+			HaveField("Attrs().OperState", netlink.LinkOperState(netlink.OperUp)))
+		Expect(Successful(netns.NewNetlinkHandle(dupontNetnsfd).LinkByIndex(dupont.Attrs().Index))).To(
+			HaveField("Attrs().OperState", netlink.LinkOperState(netlink.OperUp)))
+	})
+
+	It("creates a VETH pair in the current network namespace with both ends up", func() {
+		defer netns.EnterTransient()()
+
+		dupond, dupont := NewTransientUp()
+		Expect(dupont.Attrs().Namespace).To(BeNil())
+		Expect(Successful(netlink.LinkByIndex(dupond.Attrs().Index))).To(
+			HaveField("Attrs().OperState", netlink.LinkOperState(netlink.OperUp)))
+		Expect(Successful(netlink.LinkByIndex(dupont.Attrs().Index))).To(
+			HaveField("Attrs().OperState", netlink.LinkOperState(netlink.OperUp)))
+	})
+
+	It("connects two network namespaces using IPv4 and checks reachability", func() {
+		anetnsfd := netns.NewTransient()
+		bnetnsfd := netns.NewTransient()
+
+		a, b, ok := NewConnected("192.168.99.1/30", "192.168.99.2/30",
+			InNamespace(anetnsfd), WithPeerNamespace(bnetnsfd))
+		Expect(a.Attrs().Namespace).To(Equal(netlink.NsFd(anetnsfd)))
+		Expect(b.Attrs().Namespace).To(Equal(netlink.NsFd(bnetnsfd)))
+		Expect(ok()).To(BeTrue())
+
+		Expect(netns.NewNetlinkHandle(bnetnsfd).LinkSetDown(b)).To(Succeed())
+		Expect(ok()).To(BeFalse())
+	})
+
+	It("connects two network namespaces using IPv6", func() {
+		anetnsfd := netns.NewTransient()
+		bnetnsfd := netns.NewTransient()
+
+		_, _, ok := NewConnected("fd99::1/64", "fd99::2/64",
+			InNamespace(anetnsfd), WithPeerNamespace(bnetnsfd))
+		Expect(ok()).To(BeTrue())
+	})
+
+	It("rejects mixed IP families", func() {
+		Expect(InterceptGomegaFailure(func() {
+			_, _, _ = NewConnected("192.168.99.1/30", "fd99::2/64")
+		})).To(MatchError(ContainSubstring("same IP family")))
+	})
+
 })
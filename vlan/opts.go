@@ -0,0 +1,82 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vlan
+
+import (
+	"fmt"
+
+	"github.com/thediveo/notwork/link"
+	"github.com/vishvananda/netlink"
+)
+
+// InNamespace configures a VLAN network interface to be created in the network
+// namespace referenced by fdref, instead of creating it in the current network
+// namespace.
+func InNamespace(fdref int) Opt {
+	return func(l *link.Link) error {
+		l.Attrs().Namespace = netlink.NsFd(fdref)
+		return nil
+	}
+}
+
+// WithLinkNamespace specifies the “reference” or “link” network namespace other
+// than the current network namespace when creating a new network interface.
+// This is where the parent network interface of the VLAN is located.
+func WithLinkNamespace(fdref int) Opt {
+	return func(l *link.Link) error {
+		l.LinkNamespace = netlink.NsFd(fdref)
+		return nil
+	}
+}
+
+// WithProtocol configures the VLAN tagging protocol, either IEEE 802.1Q
+// ([netlink.VLAN_PROTOCOL_8021Q]) or IEEE 802.1ad
+// ([netlink.VLAN_PROTOCOL_8021AD]).
+func WithProtocol(proto netlink.VlanProtocol) Opt {
+	return func(l *link.Link) error {
+		switch proto {
+		case netlink.VLAN_PROTOCOL_8021Q, netlink.VLAN_PROTOCOL_8021AD:
+		default:
+			return fmt.Errorf("unsupported VLAN protocol %s", proto)
+		}
+		l.Link.(*netlink.Vlan).VlanProtocol = proto
+		return nil
+	}
+}
+
+// WithAddr configures an IPv4 or IPv6 address in CIDR notation (such as
+// “10.0.0.1/24” or “fd00::1/64”) to be assigned to a VLAN network interface
+// right after creation.
+func WithAddr(cidr string) Opt {
+	return Opt(link.WithAddr(cidr))
+}
+
+// withVlanID configures the VLAN ID, which must be in the range of 1 to 4094,
+// inclusive.
+func withVlanID(vid int) Opt {
+	return func(l *link.Link) error {
+		if vid < 1 || vid > 4094 {
+			return fmt.Errorf("invalid VLAN ID %d, must be in 1..4094", vid)
+		}
+		l.Link.(*netlink.Vlan).VlanId = vid
+		return nil
+	}
+}
+
+// WithMTU configures a VLAN network interface to be created with the
+// specified MTU, which must be positive and must not exceed 65535.
+func WithMTU(mtu int) Opt {
+	return Opt(link.WithMTU(mtu))
+}
@@ -0,0 +1,66 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vlan
+
+import (
+	"github.com/thediveo/notwork/link"
+	"github.com/vishvananda/netlink"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("VLAN configuration options", func() {
+
+	It("configures VLANs", func() {
+		l := &link.Link{Link: &netlink.Vlan{}}
+		for _, opt := range []Opt{
+			InNamespace(42),
+			WithLinkNamespace(666),
+			WithProtocol(netlink.VLAN_PROTOCOL_8021AD),
+			withVlanID(4094),
+		} {
+			Expect(opt(l)).To(Succeed())
+		}
+		Expect(l.Link).To(HaveField("Namespace", netlink.NsFd(42)))
+		Expect(l.LinkNamespace).To(Equal(netlink.NsFd(666)))
+		Expect(l.Link).To(And(
+			HaveField("VlanProtocol", netlink.VLAN_PROTOCOL_8021AD),
+			HaveField("VlanId", 4094)))
+	})
+
+	It("rejects invalid VLAN IDs", func() {
+		Expect(withVlanID(0)(&link.Link{Link: &netlink.Vlan{}})).NotTo(Succeed())
+		Expect(withVlanID(4095)(&link.Link{Link: &netlink.Vlan{}})).NotTo(Succeed())
+	})
+
+	It("rejects invalid VLAN protocols", func() {
+		Expect(WithProtocol(netlink.VLAN_PROTOCOL_UNKNOWN)(&link.Link{Link: &netlink.Vlan{}})).NotTo(Succeed())
+	})
+
+	It("configures an address", func() {
+		l := &link.Link{Link: &netlink.Vlan{}}
+		Expect(WithAddr("10.0.0.1/24")(l)).To(Succeed())
+		Expect(l.Addrs).To(ConsistOf(HaveField("IPNet.String()", "10.0.0.1/24")))
+	})
+
+	It("configures the MTU", func() {
+		l := &link.Link{Link: &netlink.Vlan{}}
+		Expect(WithMTU(9000)(l)).To(Succeed())
+		Expect(l.Attrs().MTU).To(Equal(9000))
+		Expect(WithMTU(0)(l)).NotTo(Succeed())
+	})
+
+})
@@ -0,0 +1,55 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vlan
+
+import (
+	"github.com/thediveo/notwork/link"
+	"github.com/vishvananda/netlink"
+
+	. "github.com/onsi/ginkgo/v2" //lint:ignore ST1001 rule does not apply
+	. "github.com/onsi/gomega"    //lint:ignore ST1001 rule does not apply
+)
+
+// VlanPrefix is the name prefix used for transient VLAN network interfaces.
+const VlanPrefix = "vlan-"
+
+// Opt is a configuration option when creating a new VLAN network interface.
+type Opt func(*link.Link) error
+
+// NewTransient creates and returns a new (and transient) VLAN network interface
+// with the specified VLAN ID, attached to the specified parent network
+// interface. The VLAN ID must be in the range of 1 to 4094, inclusive.
+// NewTransient automatically defers proper automatic removal of the VLAN
+// network interface.
+//
+// Unless configured otherwise using [WithProtocol], the VLAN network interface
+// uses IEEE 802.1Q tagging. If the parent network interface is located in a
+// different network namespace than the current one, use [WithLinkNamespace].
+func NewTransient(parent netlink.Link, vid int, opts ...Opt) netlink.Link {
+	GinkgoHelper()
+
+	vlan := &link.Link{
+		Link: &netlink.Vlan{
+			LinkAttrs: netlink.LinkAttrs{
+				ParentIndex: parent.Attrs().Index,
+			},
+			VlanProtocol: netlink.VLAN_PROTOCOL_8021Q,
+		},
+	}
+	for _, opt := range append([]Opt{withVlanID(vid)}, opts...) {
+		Expect(opt(vlan)).To(Succeed())
+	}
+	return link.NewTransient(vlan, VlanPrefix)
+}
@@ -0,0 +1,78 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vlan
+
+import (
+	"os"
+	"time"
+
+	"github.com/thediveo/notwork/link"
+	"github.com/thediveo/notwork/netns"
+	"github.com/vishvananda/netlink"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gleak"
+	. "github.com/thediveo/fdooze"
+	. "github.com/thediveo/success"
+)
+
+var _ = Describe("provides transient VLAN network interfaces", func() {
+
+	BeforeEach(func() {
+		if os.Getuid() != 0 {
+			Skip("needs root")
+		}
+		goodfds := Filedescriptors()
+		goodgos := Goroutines()
+		DeferCleanup(func() {
+			Eventually(Goroutines).Within(2 * time.Second).ProbeEvery(250 * time.Millisecond).
+				ShouldNot(HaveLeaked(goodgos))
+			Expect(Filedescriptors()).NotTo(HaveLeakedFds(goodfds))
+		})
+	})
+
+	It("rejects invalid VLAN IDs", func() {
+		Expect(InterceptGomegaFailure(func() {
+			_ = NewTransient(&netlink.Veth{}, 0)
+		})).To(MatchError(ContainSubstring("invalid VLAN ID 0")))
+	})
+
+	It("creates a VLAN with its parent in a different network namespace", func() {
+		parentnetnsfd := netns.NewTransient()
+		netnsfd := netns.NewTransient()
+		parent := link.NewTransient(&netlink.Veth{
+			LinkAttrs: netlink.LinkAttrs{
+				Namespace: netlink.NsFd(parentnetnsfd),
+			},
+			PeerNamespace: netlink.NsFd(parentnetnsfd),
+		}, "veth-")
+
+		vlan := NewTransient(parent, 42,
+			InNamespace(netnsfd),
+			WithLinkNamespace(parentnetnsfd),
+			WithProtocol(netlink.VLAN_PROTOCOL_8021AD))
+		Expect(vlan.Attrs().Name).To(HavePrefix(VlanPrefix))
+
+		nlh := netns.NewNetlinkHandle(netnsfd)
+		l := Successful(nlh.LinkByName(vlan.Attrs().Name))
+		Expect(l.Type()).To(Equal("vlan"))
+		Expect(l).To(And(
+			HaveField("VlanId", 42),
+			HaveField("VlanProtocol", netlink.VLAN_PROTOCOL_8021AD)))
+		Expect(l.Attrs().ParentIndex).To(Equal(parent.Attrs().Index))
+	})
+
+})
@@ -0,0 +1,10 @@
+/*
+Package vrf helps with attaching network interfaces to [VRF] (virtual routing
+and forwarding) devices for testing purposes. It leverages the [Ginkgo] testing
+framework and matching (erm, sic!) [Gomega] matchers.
+
+[VRF]: https://docs.kernel.org/networking/vrf.html
+[Ginkgo]: https://github.com/onsi/ginkgo
+[Gomega]: https://github.com/onsi/gomega
+*/
+package vrf
@@ -0,0 +1,48 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vrf
+
+import (
+	"github.com/thediveo/notwork/link"
+	"github.com/vishvananda/netlink"
+
+	. "github.com/onsi/ginkgo/v2"   //lint:ignore ST1001 rule does not apply
+	. "github.com/onsi/gomega"      //lint:ignore ST1001 rule does not apply
+	. "github.com/thediveo/success" //lint:ignore ST1001 rule does not apply
+)
+
+// EnslaveByName enslaves the child network interface to the VRF master with the
+// specified name. This covers the situation where the VRF master has been set
+// up by other code and only its name is known.
+//
+// The VRF master is looked up in the network namespace of the child: if the
+// child's [netlink.LinkAttrs.Namespace] is set, then in the referenced network
+// namespace, otherwise in the current network namespace. If the child link
+// description lacks an interface index, it is resolved by name in the same
+// network namespace.
+func EnslaveByName(vrfName string, child netlink.Link) {
+	GinkgoHelper()
+
+	Expect(child).NotTo(BeNil(), "need a non-nil child link description")
+
+	nlh := Successful(link.NewHandle(child))
+	defer nlh.Close()
+
+	master, err := nlh.LinkByName(vrfName)
+	Expect(err).NotTo(HaveOccurred(), "cannot find VRF master %q", vrfName)
+	Expect(master.Type()).To(Equal("vrf"), "network interface %q is not a VRF", vrfName)
+	Expect(nlh.LinkSetMasterByIndex(child, master.Attrs().Index)).To(Succeed(),
+		"cannot enslave network interface %q to VRF %q", child.Attrs().Name, vrfName)
+}
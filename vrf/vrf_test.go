@@ -0,0 +1,81 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vrf
+
+import (
+	"os"
+	"time"
+
+	"github.com/thediveo/notwork/dummy"
+	"github.com/thediveo/notwork/link"
+	"github.com/thediveo/notwork/netns"
+	"github.com/vishvananda/netlink"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gleak"
+	. "github.com/thediveo/fdooze"
+	. "github.com/thediveo/success"
+)
+
+var _ = Describe("VRF enslavement", func() {
+
+	BeforeEach(func() {
+		if os.Getuid() != 0 {
+			Skip("needs root")
+		}
+		goodfds := Filedescriptors()
+		goodgos := Goroutines()
+		DeferCleanup(func() {
+			Eventually(Goroutines).Within(2 * time.Second).ProbeEvery(250 * time.Millisecond).
+				ShouldNot(HaveLeaked(goodgos))
+			Expect(Filedescriptors()).NotTo(HaveLeakedFds(goodfds))
+		})
+	})
+
+	It("enslaves to a VRF by name in the current network namespace", func() {
+		defer netns.EnterTransient()()
+
+		vrf := link.NewTransient(&netlink.Vrf{Table: 42}, "vrf-")
+		dmy := dummy.NewTransient()
+		EnslaveByName(vrf.Attrs().Name, dmy)
+		Expect(Successful(netlink.LinkByIndex(dmy.Attrs().Index))).To(
+			HaveField("Attrs().MasterIndex", vrf.Attrs().Index))
+	})
+
+	It("enslaves to a VRF by name in the child's network namespace", func() {
+		netnsfd := netns.NewTransient()
+
+		vrf := link.NewTransient(&netlink.Vrf{
+			LinkAttrs: netlink.LinkAttrs{Namespace: netlink.NsFd(netnsfd)},
+			Table:     42,
+		}, "vrf-")
+		dmy := dummy.NewTransient(dummy.InNamespace(netnsfd))
+		EnslaveByName(vrf.Attrs().Name, dmy)
+		nlh := netns.NewNetlinkHandle(netnsfd)
+		Expect(Successful(nlh.LinkByIndex(dmy.Attrs().Index))).To(
+			HaveField("Attrs().MasterIndex", vrf.Attrs().Index))
+	})
+
+	It("rejects non-VRF masters", func() {
+		defer netns.EnterTransient()()
+
+		master := dummy.NewTransient()
+		dmy := dummy.NewTransient()
+		Expect(InterceptGomegaFailure(func() { EnslaveByName(master.Attrs().Name, dmy) })).
+			To(MatchError(ContainSubstring("is not a VRF")))
+	})
+
+})
@@ -0,0 +1,105 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vxlan
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/thediveo/notwork/link"
+	"github.com/vishvananda/netlink"
+)
+
+// maxVNI is the largest VXLAN network identifier, as VNIs are 24 bits wide.
+const maxVNI = 1<<24 - 1
+
+// InNamespace configures a VXLAN network interface to be created in the network
+// namespace referenced by fdref, instead of creating it in the current network
+// namespace.
+func InNamespace(fdref int) Opt {
+	return func(l *link.Link) error {
+		l.Attrs().Namespace = netlink.NsFd(fdref)
+		return nil
+	}
+}
+
+// WithLinkNamespace specifies the “reference” or “link” network namespace other
+// than the current network namespace when creating a new network interface.
+// This is where the VTEP network interface of the VXLAN is located.
+func WithLinkNamespace(fdref int) Opt {
+	return func(l *link.Link) error {
+		l.LinkNamespace = netlink.NsFd(fdref)
+		return nil
+	}
+}
+
+// WithVNI configures the VXLAN network identifier, which must be in the range
+// of 0 to 16777215, inclusive.
+func WithVNI(vni int) Opt {
+	return func(l *link.Link) error {
+		if vni < 0 || vni > maxVNI {
+			return fmt.Errorf("invalid VXLAN network identifier %d, must be in 0..%d",
+				vni, maxVNI)
+		}
+		l.Link.(*netlink.Vxlan).VxlanId = vni
+		return nil
+	}
+}
+
+// WithVtepDevice configures the network interface to use as the VXLAN tunnel
+// endpoint (VTEP) device.
+func WithVtepDevice(vtep netlink.Link) Opt {
+	return func(l *link.Link) error {
+		l.Link.(*netlink.Vxlan).VtepDevIndex = vtep.Attrs().Index
+		return nil
+	}
+}
+
+// WithGroup configures the multicast group IP address to join, or the unicast
+// remote IP address to send to.
+func WithGroup(group net.IP) Opt {
+	return func(l *link.Link) error {
+		l.Link.(*netlink.Vxlan).Group = group
+		return nil
+	}
+}
+
+// WithPort configures the UDP destination port of the remote VXLAN tunnel
+// endpoint. The port must be in the range of 1 to 65535, inclusive.
+func WithPort(port int) Opt {
+	return func(l *link.Link) error {
+		if port < 1 || port > 65535 {
+			return fmt.Errorf("invalid UDP destination port %d, must be in 1..65535", port)
+		}
+		l.Link.(*netlink.Vxlan).Port = port
+		return nil
+	}
+}
+
+// WithLearning configures whether the VXLAN network interface learns unknown
+// source link layer addresses and IP addresses, or not.
+func WithLearning(on bool) Opt {
+	return func(l *link.Link) error {
+		l.Link.(*netlink.Vxlan).Learning = on
+		return nil
+	}
+}
+
+// WithAddr configures an IPv4 or IPv6 address in CIDR notation (such as
+// “10.0.0.1/24” or “fd00::1/64”) to be assigned to a VXLAN network interface
+// right after creation.
+func WithAddr(cidr string) Opt {
+	return Opt(link.WithAddr(cidr))
+}
@@ -0,0 +1,68 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vxlan
+
+import (
+	"net"
+
+	"github.com/thediveo/notwork/link"
+	"github.com/vishvananda/netlink"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("VXLAN configuration options", func() {
+
+	It("configures VXLANs", func() {
+		l := &link.Link{Link: &netlink.Vxlan{}}
+		for _, opt := range []Opt{
+			InNamespace(42),
+			WithLinkNamespace(666),
+			WithVNI(maxVNI),
+			WithVtepDevice(&netlink.Veth{LinkAttrs: netlink.LinkAttrs{Index: 123}}),
+			WithGroup(net.ParseIP("239.1.2.3")),
+			WithPort(4789),
+			WithLearning(true),
+		} {
+			Expect(opt(l)).To(Succeed())
+		}
+		Expect(l.Link).To(HaveField("Namespace", netlink.NsFd(42)))
+		Expect(l.LinkNamespace).To(Equal(netlink.NsFd(666)))
+		Expect(l.Link).To(And(
+			HaveField("VxlanId", maxVNI),
+			HaveField("VtepDevIndex", 123),
+			HaveField("Group", BeEquivalentTo(net.ParseIP("239.1.2.3"))),
+			HaveField("Port", 4789),
+			HaveField("Learning", true)))
+	})
+
+	It("rejects invalid VNIs", func() {
+		Expect(WithVNI(-1)(&link.Link{Link: &netlink.Vxlan{}})).NotTo(Succeed())
+		Expect(WithVNI(maxVNI + 1)(&link.Link{Link: &netlink.Vxlan{}})).NotTo(Succeed())
+	})
+
+	It("rejects invalid ports", func() {
+		Expect(WithPort(0)(&link.Link{Link: &netlink.Vxlan{}})).NotTo(Succeed())
+		Expect(WithPort(65536)(&link.Link{Link: &netlink.Vxlan{}})).NotTo(Succeed())
+	})
+
+	It("configures an address", func() {
+		l := &link.Link{Link: &netlink.Vxlan{}}
+		Expect(WithAddr("10.0.0.1/24")(l)).To(Succeed())
+		Expect(l.Addrs).To(ConsistOf(HaveField("IPNet.String()", "10.0.0.1/24")))
+	})
+
+})
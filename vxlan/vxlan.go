@@ -0,0 +1,55 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vxlan
+
+import (
+	"github.com/thediveo/notwork/link"
+	"github.com/vishvananda/netlink"
+
+	. "github.com/onsi/ginkgo/v2" //lint:ignore ST1001 rule does not apply
+	. "github.com/onsi/gomega"    //lint:ignore ST1001 rule does not apply
+)
+
+// VxlanPrefix is the name prefix used for transient VXLAN network interfaces.
+const VxlanPrefix = "vxln-"
+
+// Opt is a configuration option when creating a new VXLAN network interface.
+type Opt func(*link.Link) error
+
+// NewTransient creates and returns a new (and transient) VXLAN network
+// interface. The VXLAN network identifier (VNI) must be specified using
+// [WithVNI]. NewTransient automatically defers proper automatic removal of the
+// VXLAN network interface.
+//
+// Similar to “ip link add ... type vxlan”, address learning is enabled unless
+// configured otherwise using [WithLearning]. If the VTEP network interface
+// specified using [WithVtepDevice] is located in a different network namespace
+// than the current one, use [WithLinkNamespace].
+func NewTransient(opts ...Opt) netlink.Link {
+	GinkgoHelper()
+
+	vxlan := &link.Link{
+		Link: &netlink.Vxlan{
+			VxlanId:  -1,
+			Learning: true,
+		},
+	}
+	for _, opt := range opts {
+		Expect(opt(vxlan)).To(Succeed())
+	}
+	Expect(vxlan.Link.(*netlink.Vxlan).VxlanId).NotTo(BeNumerically("<", 0),
+		"missing VXLAN network identifier, use WithVNI")
+	return link.NewTransient(vxlan, VxlanPrefix)
+}
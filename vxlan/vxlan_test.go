@@ -0,0 +1,101 @@
+// Copyright 2024 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vxlan
+
+import (
+	"net"
+	"os"
+	"time"
+
+	"github.com/thediveo/notwork/link"
+	"github.com/thediveo/notwork/netns"
+	"github.com/vishvananda/netlink"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gleak"
+	. "github.com/thediveo/fdooze"
+	. "github.com/thediveo/success"
+)
+
+var _ = Describe("provides transient VXLAN network interfaces", func() {
+
+	BeforeEach(func() {
+		if os.Getuid() != 0 {
+			Skip("needs root")
+		}
+		goodfds := Filedescriptors()
+		goodgos := Goroutines()
+		DeferCleanup(func() {
+			Eventually(Goroutines).Within(2 * time.Second).ProbeEvery(250 * time.Millisecond).
+				ShouldNot(HaveLeaked(goodgos))
+			Expect(Filedescriptors()).NotTo(HaveLeakedFds(goodfds))
+		})
+	})
+
+	It("rejects a missing VNI", func() {
+		Expect(InterceptGomegaFailure(func() {
+			_ = NewTransient()
+		})).To(MatchError(ContainSubstring("missing VXLAN network identifier")))
+	})
+
+	It("rejects an invalid VNI", func() {
+		Expect(InterceptGomegaFailure(func() {
+			_ = NewTransient(WithVNI(maxVNI + 1))
+		})).To(MatchError(ContainSubstring("invalid VXLAN network identifier")))
+	})
+
+	It("creates a VXLAN with its VTEP device in a different network namespace", func() {
+		vtepnetnsfd := netns.NewTransient()
+		netnsfd := netns.NewTransient()
+		vtep := link.NewTransient(&netlink.Veth{
+			LinkAttrs: netlink.LinkAttrs{
+				Namespace: netlink.NsFd(vtepnetnsfd),
+			},
+			PeerNamespace: netlink.NsFd(vtepnetnsfd),
+		}, "veth-")
+
+		vxlan := NewTransient(
+			WithVNI(42),
+			WithVtepDevice(vtep),
+			WithGroup(net.ParseIP("239.1.2.3")),
+			WithPort(4789),
+			WithLearning(false),
+			InNamespace(netnsfd),
+			WithLinkNamespace(vtepnetnsfd))
+		Expect(vxlan.Attrs().Name).To(HavePrefix(VxlanPrefix))
+
+		nlh := netns.NewNetlinkHandle(netnsfd)
+		l := Successful(nlh.LinkByName(vxlan.Attrs().Name))
+		Expect(l.Type()).To(Equal("vxlan"))
+		Expect(l).To(And(
+			HaveField("VxlanId", 42),
+			HaveField("Port", 4789),
+			HaveField("VtepDevIndex", vtep.Attrs().Index),
+			HaveField("Learning", false)))
+		Expect(l.(*netlink.Vxlan).Group.Equal(net.ParseIP("239.1.2.3"))).To(BeTrue())
+	})
+
+	It("creates a VXLAN with learning enabled by default", func() {
+		defer netns.EnterTransient()()
+		vxlan := NewTransient(WithVNI(0), WithPort(4789))
+		l := Successful(netlink.LinkByName(vxlan.Attrs().Name))
+		Expect(l).To(And(
+			HaveField("VxlanId", 0),
+			HaveField("Port", 4789),
+			HaveField("Learning", true)))
+	})
+
+})